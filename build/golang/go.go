@@ -84,18 +84,21 @@ type TestConfig struct {
 // env gets environment variables set in the system excluding Go env vars that
 // causes conflict with the build tools used by crust. For example having
 // the GOROOT and GOPATH that point to another version of Go, build binaries
-// with incompatible Go version that fails to run properly.
+// with incompatible Go version that fails to run properly. Toolchain switching
+// is disabled, so the version of Go installed by crust is always used, because
+// some dependencies can't be linked with the newer ones.
 func env() []string {
 	osEnv := os.Environ()
 	envVars := make([]string, 0, len(osEnv))
 	for _, envVar := range osEnv {
 		e := strings.ToUpper(envVar)
 		if !strings.Contains(e, "GOROOT=") && !strings.Contains(e, "GOPATH=") &&
-			!strings.Contains(e, "PATH=") && !strings.Contains(e, "GOVCS=") {
+			!strings.Contains(e, "PATH=") && !strings.Contains(e, "GOVCS=") && !strings.Contains(e, "GOTOOLCHAIN=") {
 			envVars = append(envVars, envVar)
 		}
 	}
 	envVars = append(envVars, "GOVCS=public:git|hg|bzr") // we add bzr here because some modules use it
+	envVars = append(envVars, "GOTOOLCHAIN=local")
 	return append(envVars, "PATH="+lo.Must1(filepath.Abs(filepath.Join(repoPath, "bin")))+":"+os.Getenv("PATH"))
 }
 
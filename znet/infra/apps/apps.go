@@ -12,6 +12,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/pkg/errors"
+	"github.com/samber/lo"
 
 	"github.com/CoreumFoundation/coreum/v6/pkg/config/constant"
 	"github.com/CoreumFoundation/crust/znet/infra"
@@ -53,10 +54,12 @@ func (f *Factory) CoredNetwork(
 	ctx context.Context,
 	namePrefix string,
 	firstPorts cored.Ports,
-	validatorCount, sentryCount, seedCount, fullCount int,
+	definition infra.CoredDefinition,
 	binaryVersion string,
-	genDEX bool,
 ) (cored.Cored, []cored.Cored, error) {
+	validatorCount, sentryCount, seedCount, fullCount := definition.Validators, definition.Sentries, definition.Seeds,
+		definition.FullNodes
+
 	config := sdk.GetConfig()
 	addressPrefix := constant.AddressPrefixDev
 
@@ -93,22 +96,32 @@ func (f *Factory) CoredNetwork(
 		GenTxs: make([]json.RawMessage, 0),
 	}
 	// optionally enable DEX generation
-	if genDEX {
+	if definition.DEX {
 		var err error
-		genesisConfig, err = cored.AddDEXGenesisConfig(ctx, genesisConfig)
+		genesisConfig, err = cored.AddDEXGenesisConfig(ctx, genesisConfig, definition.DEXBooks)
 		if err != nil {
 			return cored.Cored{}, nil, err
 		}
 	}
 
-	wallet, genesisConfig := cored.NewFundedWallet(genesisConfig)
+	wallet, genesisConfig, err := cored.NewFundedWallet(genesisConfig, validatorCount, definition.StakerSeed)
+	if err != nil {
+		return cored.Cored{}, nil, err
+	}
+	genesisConfig, err = wallet.DelegateStakes(ctx, genesisConfig, definition.StakeWeights)
+	if err != nil {
+		return cored.Cored{}, nil, err
+	}
 
-	if validatorCount > wallet.GetStakersMnemonicsCount() {
-		return cored.Cored{}, nil, errors.Errorf(
-			"unsupported validators count: %d, max: %d",
-			validatorCount,
-			wallet.GetStakersMnemonicsCount(),
-		)
+	if len(f.config.GenesisFixture) > 0 {
+		fixture, err := cored.ParseGenesisFixture(f.config.GenesisFixture)
+		if err != nil {
+			return cored.Cored{}, nil, err
+		}
+		genesisConfig, err = cored.AddGenesisFixture(ctx, genesisConfig, fixture, genesisSigners(definition, wallet))
+		if err != nil {
+			return cored.Cored{}, nil, err
+		}
 	}
 
 	nodes := make([]cored.Cored, 0, validatorCount+seedCount+sentryCount+fullCount)
@@ -184,9 +197,17 @@ func (f *Factory) CoredNetwork(
 			FundingMnemonic: cored.FundingMnemonic,
 			FaucetMnemonic:  cored.FaucetMnemonic,
 			GasPriceStr:     cored.DefaultGasPriceStr,
-			BinaryVersion:   binaryVersion,
-			TimeoutCommit:   f.spec.TimeoutCommit,
-			Upgrades:        f.config.CoredUpgrades,
+			BinaryVersion: func() string {
+				if version, exists := definition.Versions[name]; exists {
+					return version
+				}
+				return binaryVersion
+			}(),
+			// All the nodes must generate identical genesis, so it is done using the same binary.
+			GenesisBinaryVersion: binaryVersion,
+			TimeoutCommit:        f.spec.TimeoutCommit,
+			Upgrades:             f.config.CoredUpgrades,
+			GenesisPatch:         f.config.GenesisPatch,
 		})
 		if isValidator {
 			valNodes = append(valNodes, node)
@@ -197,9 +218,28 @@ func (f *Factory) CoredNetwork(
 		lastNode = node
 		nodes = append(nodes, node)
 	}
+
+	for nodeName := range definition.Versions {
+		if !lo.ContainsBy(nodes, func(node cored.Cored) bool { return node.Name() == nodeName }) {
+			return cored.Cored{}, nil, errors.Errorf("version is defined for unknown cored node %s", nodeName)
+		}
+	}
 	return lastNode, nodes, nil
 }
 
+// genesisSigners returns mnemonics of the accounts signing genesis transactions, indexed by their descriptions.
+func genesisSigners(definition infra.CoredDefinition, wallet *cored.Wallet) map[string]string {
+	signers := map[string]string{}
+	if definition.DEX {
+		signers["DEX funding account"] = cored.FundingMnemonic
+		signers["DEX taker account"] = cored.DEXTakerMnemonic
+	}
+	for i := range wallet.GetStakersMnemonicsCount() {
+		signers[fmt.Sprintf("staker %d", i)] = wallet.GetStakersMnemonic(i)
+	}
+	return signers
+}
+
 // Faucet creates new faucet.
 func (f *Factory) Faucet(name string, coredApp cored.Cored) faucet.Faucet {
 	return faucet.New(faucet.Config{
@@ -260,40 +300,52 @@ func (f *Factory) BlockExplorer(prefix string, coredApp cored.Cored) blockexplor
 }
 
 // IBC creates set of applications required to test IBC.
-func (f *Factory) IBC(prefix string, coredApp cored.Cored) infra.AppSet {
-	nameGaia := BuildPrefixedAppName(prefix, string(gaiad.AppType))
-	nameOsmosis := BuildPrefixedAppName(prefix, string(osmosis.AppType))
+func (f *Factory) IBC(prefix string, coredApp cored.Cored, chains []string) infra.AppSet {
 	nameRelayerHermes := BuildPrefixedAppName(prefix, string(hermes.AppType))
 
-	gaiaApp := gaiad.New(cosmoschain.AppConfig{
-		Name:              nameGaia,
-		HomeDir:           filepath.Join(f.config.AppDir, nameGaia),
-		ChainID:           gaiad.DefaultChainID,
-		HomeName:          gaiad.DefaultHomeName,
-		AppInfo:           f.spec.DescribeApp(gaiad.AppType, nameGaia),
-		Ports:             gaiad.DefaultPorts,
-		RelayerMnemonic:   gaiad.RelayerMnemonic,
-		FundingMnemonic:   gaiad.FundingMnemonic,
-		TimeoutCommit:     f.config.TimeoutCommit,
-		WrapperDir:        f.config.WrapperDir,
-		GasPriceStr:       gaiad.DefaultGasPriceStr,
-		RunScriptTemplate: gaiad.RunScriptTemplate,
-	})
-
-	osmosisApp := osmosis.New(cosmoschain.AppConfig{
-		Name:              nameOsmosis,
-		HomeDir:           filepath.Join(f.config.AppDir, nameOsmosis),
-		ChainID:           osmosis.DefaultChainID,
-		HomeName:          osmosis.DefaultHomeName,
-		AppInfo:           f.spec.DescribeApp(osmosis.AppType, nameOsmosis),
-		Ports:             osmosis.DefaultPorts,
-		RelayerMnemonic:   osmosis.RelayerMnemonic,
-		FundingMnemonic:   osmosis.FundingMnemonic,
-		TimeoutCommit:     f.config.TimeoutCommit,
-		WrapperDir:        f.config.WrapperDir,
-		GasPriceStr:       osmosis.DefaultGasPriceStr,
-		RunScriptTemplate: osmosis.RunScriptTemplate,
-	})
+	appSet := make(infra.AppSet, 0, len(chains)+1)
+	peeredChains := make([]cosmoschain.BaseApp, 0, len(chains))
+	for _, chain := range chains {
+		var chainApp cosmoschain.BaseApp
+		switch chain {
+		case infra.IBCChainGaia:
+			nameGaia := BuildPrefixedAppName(prefix, string(gaiad.AppType))
+			chainApp = gaiad.New(cosmoschain.AppConfig{
+				Name:              nameGaia,
+				HomeDir:           filepath.Join(f.config.AppDir, nameGaia),
+				ChainID:           gaiad.DefaultChainID,
+				HomeName:          gaiad.DefaultHomeName,
+				AppInfo:           f.spec.DescribeApp(gaiad.AppType, nameGaia),
+				Ports:             gaiad.DefaultPorts,
+				RelayerMnemonic:   gaiad.RelayerMnemonic,
+				FundingMnemonic:   gaiad.FundingMnemonic,
+				TimeoutCommit:     f.config.TimeoutCommit,
+				WrapperDir:        f.config.WrapperDir,
+				GasPriceStr:       gaiad.DefaultGasPriceStr,
+				RunScriptTemplate: gaiad.RunScriptTemplate,
+			})
+		case infra.IBCChainOsmosis:
+			nameOsmosis := BuildPrefixedAppName(prefix, string(osmosis.AppType))
+			chainApp = osmosis.New(cosmoschain.AppConfig{
+				Name:              nameOsmosis,
+				HomeDir:           filepath.Join(f.config.AppDir, nameOsmosis),
+				ChainID:           osmosis.DefaultChainID,
+				HomeName:          osmosis.DefaultHomeName,
+				AppInfo:           f.spec.DescribeApp(osmosis.AppType, nameOsmosis),
+				Ports:             osmosis.DefaultPorts,
+				RelayerMnemonic:   osmosis.RelayerMnemonic,
+				FundingMnemonic:   osmosis.FundingMnemonic,
+				TimeoutCommit:     f.config.TimeoutCommit,
+				WrapperDir:        f.config.WrapperDir,
+				GasPriceStr:       osmosis.DefaultGasPriceStr,
+				RunScriptTemplate: osmosis.RunScriptTemplate,
+			})
+		default:
+			panic(fmt.Sprintf("unsupported IBC chain: %s", chain))
+		}
+		appSet = append(appSet, chainApp)
+		peeredChains = append(peeredChains, chainApp)
+	}
 
 	hermesApp := hermes.New(hermes.Config{
 		Name:                  nameRelayerHermes,
@@ -302,14 +354,10 @@ func (f *Factory) IBC(prefix string, coredApp cored.Cored) infra.AppSet {
 		TelemetryPort:         hermes.DefaultTelemetryPort,
 		Cored:                 coredApp,
 		CoreumRelayerMnemonic: cored.RelayerMnemonic,
-		PeeredChains:          []cosmoschain.BaseApp{gaiaApp, osmosisApp},
+		PeeredChains:          peeredChains,
 	})
 
-	return infra.AppSet{
-		gaiaApp,
-		osmosisApp,
-		hermesApp,
-	}
+	return append(appSet, hermesApp)
 }
 
 // Monitoring returns set of applications required to run monitoring.
@@ -319,6 +367,7 @@ func (f *Factory) Monitoring(
 	faucet faucet.Faucet,
 	callisto callisto.Callisto,
 	hermesApps []hermes.Hermes,
+	extraApps []infra.MetricsCapable,
 ) infra.AppSet {
 	namePrometheus := BuildPrefixedAppName(prefix, string(prometheus.AppType))
 	nameGrafana := BuildPrefixedAppName(prefix, string(grafana.AppType))
@@ -332,6 +381,7 @@ func (f *Factory) Monitoring(
 		Faucet:     faucet,
 		Callisto:   callisto,
 		HermesApps: hermesApps,
+		ExtraApps:  extraApps,
 	})
 
 	grafanaApp := grafana.New(grafana.Config{
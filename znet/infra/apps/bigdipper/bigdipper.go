@@ -58,18 +58,24 @@ func (bd BigDipper) Deployment() infra.Deployment {
 	return infra.Deployment{
 		Image: "coreumfoundation/big-dipper-ui:2.19.3-64",
 		EnvVarsFunc: func() []infra.EnvVar {
+			// Those addresses are used by the browser, so ports published on the host must be used.
+			hasuraInfo := bd.config.Hasura.Info()
+			hasuraAddr := infra.JoinNetAddr("", hasuraInfo.HostFromHost, hasuraInfo.HostPort(bd.config.Hasura.Port()))
+			coredInfo := bd.config.Cored.Info()
+			coredAddr := infra.JoinNetAddr("", coredInfo.HostFromHost, coredInfo.HostPort(bd.config.Cored.Config().Ports.RPC))
+
 			return []infra.EnvVar{
 				{
 					Name:  "NEXT_PUBLIC_GRAPHQL_URL",
-					Value: "http://localhost:8080/v1/graphql",
+					Value: "http://" + hasuraAddr + "/v1/graphql",
 				},
 				{
 					Name:  "NEXT_PUBLIC_GRAPHQL_WS",
-					Value: "ws://localhost:8080/v1/graphql",
+					Value: "ws://" + hasuraAddr + "/v1/graphql",
 				},
 				{
 					Name:  "NEXT_PUBLIC_RPC_WEBSOCKET",
-					Value: "ws://localhost:26657/websocket",
+					Value: "ws://" + coredAddr + "/websocket",
 				},
 				{
 					Name:  "NEXT_PUBLIC_CHAIN_TYPE",
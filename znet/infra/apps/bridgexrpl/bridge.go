@@ -155,6 +155,7 @@ func (b Bridge) Deployment() infra.Deployment {
 				Destination: targets.AppHomeDir,
 			},
 		},
+		RestartPolicy: infra.RestartOnFailure,
 		ArgsFunc: func() []string {
 			return []string{
 				"start",
@@ -184,7 +185,7 @@ func (b Bridge) setupBridge(ctx context.Context) error {
 	//nolint:nestif // ifs are here to return errors
 	if b.config.Leader == nil {
 		xrplClient := xrplhelper.NewRPCClient(infra.JoinNetAddr("http", b.config.XRPL.Info().HostFromHost,
-			b.config.XRPL.Config().RPCPort))
+			b.config.XRPL.Info().HostPort(b.config.XRPL.Config().RPCPort)))
 
 		if err := fundXRPLAccounts(ctx, xrplClient); err != nil {
 			return err
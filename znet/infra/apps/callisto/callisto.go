@@ -136,7 +136,7 @@ func (j Callisto) HealthCheck(ctx context.Context) error {
 
 	statusURL := url.URL{
 		Scheme: "http",
-		Host:   infra.JoinNetAddr("", j.Info().HostFromHost, j.config.TelemetryPort),
+		Host:   infra.JoinNetAddr("", j.Info().HostFromHost, j.Info().HostPort(j.config.TelemetryPort)),
 		Path:   "/metrics",
 	}
 	req := must.HTTPRequest(http.NewRequestWithContext(ctx, http.MethodGet, statusURL.String(), nil))
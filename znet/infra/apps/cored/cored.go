@@ -16,22 +16,29 @@ import (
 	"time"
 
 	sdkmath "cosmossdk.io/math"
+	"cosmossdk.io/x/tx/signing"
 	cometbftcrypto "github.com/cometbft/cometbft/crypto"
 	cbfted25519 "github.com/cometbft/cometbft/crypto/ed25519"
 	cmtjson "github.com/cometbft/cometbft/libs/json"
 	cosmosclient "github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/codec"
+	addresscodec "github.com/cosmos/cosmos-sdk/codec/address"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	"github.com/cosmos/cosmos-sdk/crypto/hd"
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
 	srvconfig "github.com/cosmos/cosmos-sdk/server/config"
+	"github.com/cosmos/cosmos-sdk/std"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/module"
 	"github.com/cosmos/cosmos-sdk/x/auth"
+	authzmodule "github.com/cosmos/cosmos-sdk/x/authz/module"
 	"github.com/cosmos/cosmos-sdk/x/bank"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/cosmos/cosmos-sdk/x/distribution"
 	"github.com/cosmos/cosmos-sdk/x/staking"
+	gogoproto "github.com/cosmos/gogoproto/proto"
 	"github.com/pkg/errors"
-	"github.com/samber/lo"
 
 	"github.com/CoreumFoundation/coreum-tools/pkg/libexec"
 	"github.com/CoreumFoundation/coreum-tools/pkg/must"
@@ -39,13 +46,14 @@ import (
 	coreumconfig "github.com/CoreumFoundation/coreum/v6/pkg/config"
 	coreumconstant "github.com/CoreumFoundation/coreum/v6/pkg/config/constant"
 	assetft "github.com/CoreumFoundation/coreum/v6/x/asset/ft"
-	assetfttypes "github.com/CoreumFoundation/coreum/v6/x/asset/ft/types"
+	assetnft "github.com/CoreumFoundation/coreum/v6/x/asset/nft"
 	"github.com/CoreumFoundation/coreum/v6/x/dex"
-	dextypes "github.com/CoreumFoundation/coreum/v6/x/dex/types"
+	"github.com/CoreumFoundation/coreum/v6/x/wnft"
 	"github.com/CoreumFoundation/crust/build/tools"
 	"github.com/CoreumFoundation/crust/znet/infra"
 	"github.com/CoreumFoundation/crust/znet/infra/cosmoschain"
 	"github.com/CoreumFoundation/crust/znet/infra/targets"
+	"github.com/CoreumFoundation/crust/znet/pkg/jsonpatch"
 )
 
 const (
@@ -54,6 +62,12 @@ const (
 
 	// DockerImageStandard uses standard docker image of cored.
 	DockerImageStandard = "cored:znet"
+
+	// DefaultBinaryVersion is the version reported for nodes running the binary built by crust.
+	DefaultBinaryVersion = "default"
+
+	// defaultMaxValidators is the maximum number of bonded validators set by the genesis generator.
+	defaultMaxValidators = 32
 )
 
 var basicModuleList = []module.AppModuleBasic{
@@ -64,26 +78,28 @@ var basicModuleList = []module.AppModuleBasic{
 
 // Config stores cored app config.
 type Config struct {
-	Name              string
-	HomeDir           string
-	BinDir            string
-	WrapperDir        string
-	DockerImage       string
-	GenesisInitConfig *GenesisInitConfig
-	AppInfo           *infra.AppInfo
-	Ports             Ports
-	IsValidator       bool
-	StakerMnemonic    string
-	StakerBalance     int64
-	FundingMnemonic   string
-	FaucetMnemonic    string
-	GasPriceStr       string
-	ValidatorNodes    []Cored
-	SeedNodes         []Cored
-	ImportedMnemonics map[string]string
-	BinaryVersion     string
-	TimeoutCommit     time.Duration
-	Upgrades          map[string]string
+	Name                 string
+	HomeDir              string
+	BinDir               string
+	WrapperDir           string
+	DockerImage          string
+	GenesisInitConfig    *GenesisInitConfig
+	AppInfo              *infra.AppInfo
+	Ports                Ports
+	IsValidator          bool
+	StakerMnemonic       string
+	StakerBalance        int64
+	FundingMnemonic      string
+	FaucetMnemonic       string
+	GasPriceStr          string
+	ValidatorNodes       []Cored
+	SeedNodes            []Cored
+	ImportedMnemonics    map[string]string
+	BinaryVersion        string
+	GenesisBinaryVersion string
+	TimeoutCommit        time.Duration
+	Upgrades             map[string]string
+	GenesisPatch         json.RawMessage
 }
 
 // GenesisDEXConfig is the dex config of the GenesisInitConfig.
@@ -142,6 +158,11 @@ func New(cfg Config) Cored {
 	must.OK(err)
 
 	valPrivateKey := cbfted25519.GenPrivKey()
+	if cfg.BinaryVersion != "" {
+		cfg.AppInfo.SetVersion(cfg.BinaryVersion)
+	} else {
+		cfg.AppInfo.SetVersion(DefaultBinaryVersion)
+	}
 	if cfg.IsValidator {
 		cfg.GenesisInitConfig.Validators = append(cfg.GenesisInitConfig.Validators, GenesisValidator{
 			DelegatorMnemonic: cfg.StakerMnemonic,
@@ -198,11 +219,14 @@ func (c Cored) Config() Config {
 
 // ClientContext creates new cored ClientContext.
 func (c Cored) ClientContext() client.Context {
+	info := c.Info()
 	rpcClient, err := cosmosclient.
-		NewClientFromNode(infra.JoinNetAddr("http", c.Info().HostFromHost, c.Config().Ports.RPC))
+		NewClientFromNode(infra.JoinNetAddr("http", info.HostFromHost, info.HostPort(c.Config().Ports.RPC)))
 	must.OK(err)
 
-	grpcClient, err := cosmoschain.GRPCClient(infra.JoinNetAddr("", c.Info().HostFromHost, c.Config().Ports.GRPC))
+	grpcClient, err := cosmoschain.GRPCClient(
+		infra.JoinNetAddr("", info.HostFromHost, info.HostPort(c.Config().Ports.GRPC)),
+	)
 	must.OK(err)
 
 	return client.NewContext(client.DefaultContextConfig(), basicModuleList...).
@@ -249,6 +273,7 @@ func (c Cored) Deployment() infra.Deployment {
 				},
 			}
 		},
+		RestartPolicy: infra.RestartOnFailure,
 		Volumes: []infra.Volume{
 			{
 				Source:      filepath.Join(c.config.HomeDir, "config"),
@@ -322,9 +347,13 @@ func (c Cored) Deployment() infra.Deployment {
 			return args
 		},
 		Ports:       infra.PortsToMap(c.config.Ports),
+		Binary:      c.localBinaryPath(c.config.BinaryVersion),
 		PrepareFunc: c.prepare,
 		ConfigureFunc: func(ctx context.Context, deployment infra.DeploymentInfo) error {
-			return c.saveClientWrapper(c.config.WrapperDir, deployment.HostFromHost)
+			return c.saveClientWrapper(c.config.WrapperDir, deployment)
+		},
+		HealthCheckCmd: []string{
+			"wget", "-q", "-O", "/dev/null", fmt.Sprintf("http://127.0.0.1:%d/status", c.config.Ports.RPC),
 		},
 	}
 
@@ -374,68 +403,55 @@ func (c Cored) SaveGenesis(ctx context.Context, homeDir string) error {
 		"--chain-id", string(c.config.GenesisInitConfig.ChainID),
 	}
 
-	// get particular binary path from or run using the default(compiled) binary
-	var binaryPath string
-	if c.config.BinaryVersion != "" {
-		binaryPath = filepath.Join(
-			c.config.BinDir,
-			".cache",
-			"cored",
-			tools.TargetPlatformLocal.String(), "bin",
-			"cored"+"-"+c.Config().BinaryVersion,
-		)
-	} else {
-		binaryPath = filepath.Join(c.config.BinDir, "cored")
-	}
-
-	return libexec.Exec(
+	if err := libexec.Exec(
 		ctx,
-		exec.Command(binaryPath, fullArgs...),
-	)
-}
+		exec.Command(c.localBinaryPath(c.config.GenesisBinaryVersion), fullArgs...),
+	); err != nil {
+		return err
+	}
 
-// AddDEXGenesisConfig adds DEX related genesis config.
-func AddDEXGenesisConfig(ctx context.Context, genesisConfig GenesisInitConfig) (GenesisInitConfig, error) {
-	// issue an asset FT to place an order
-	issuer := FundingAddress
-	issuerMnemonic := FundingMnemonic
-	ordersCount := 2_000
-	issuerMsgs := make([]sdk.Msg, 0)
-
-	genesisConfig.DEXConfig.MaxOrdersPerDenom = uint64(ordersCount) // allow to place all orders
-
-	orderSeqQuantity := sdkmath.NewIntFromUint64(100_000)
-	issueMsg := &assetfttypes.MsgIssue{
-		Issuer:        issuer,
-		Symbol:        "DEXSU",
-		Subunit:       "dexsu",
-		Precision:     8,
-		InitialAmount: orderSeqQuantity.MulRaw(int64(ordersCount)),
+	// Genesis generator keeps default limit of bonded validators, it is raised if there are more validators.
+	if validatorCount := len(c.config.GenesisInitConfig.Validators); validatorCount > defaultMaxValidators {
+		if err := patchGenesis(genesisFile, []byte(fmt.Sprintf(
+			`{"app_state":{"staking":{"params":{"max_validators":%d}}}}`, validatorCount,
+		))); err != nil {
+			return err
+		}
 	}
-	issuerMsgs = append(issuerMsgs, issueMsg)
-
-	denom := issueMsg.Subunit + "-" + issueMsg.Issuer
-	for i := range ordersCount {
-		issuerMsgs = append(issuerMsgs, &dextypes.MsgPlaceOrder{
-			Sender:      issuer,
-			Type:        dextypes.ORDER_TYPE_LIMIT,
-			ID:          fmt.Sprintf("id-%d", i),
-			BaseDenom:   denom,
-			QuoteDenom:  genesisConfig.Denom,
-			Price:       lo.ToPtr(dextypes.MustNewPriceFromString("1")),
-			Quantity:    orderSeqQuantity,
-			Side:        dextypes.SIDE_SELL,
-			TimeInForce: dextypes.TIME_IN_FORCE_GTC,
-		})
+
+	if len(c.config.GenesisPatch) == 0 {
+		return nil
 	}
+	return patchGenesis(genesisFile, c.config.GenesisPatch)
+}
 
-	txData, err := signTxsWithMnemonic(ctx, string(genesisConfig.ChainID), issuerMnemonic, issuerMsgs...)
+// patchGenesis applies the patch to the genesis file. Patched genesis is formatted deterministically, so all the
+// nodes get identical file.
+func patchGenesis(genesisFile string, patch []byte) error {
+	genesis, err := os.ReadFile(genesisFile)
 	if err != nil {
-		return GenesisInitConfig{}, err
+		return errors.WithStack(err)
+	}
+	patched, err := jsonpatch.Apply(genesis, patch)
+	if err != nil {
+		return errors.Wrap(err, "applying genesis patch failed")
 	}
-	genesisConfig.GenTxs = append(genesisConfig.GenTxs, txData)
+	return errors.WithStack(os.WriteFile(genesisFile, patched, 0o600))
+}
 
-	return genesisConfig, nil
+// localBinaryPath returns path of the binary of the version built for the local platform.
+func (c Cored) localBinaryPath(version string) string {
+	// get particular binary path from or run using the default(compiled) binary
+	if version != "" {
+		return filepath.Join(
+			c.config.BinDir,
+			".cache",
+			"cored",
+			tools.TargetPlatformLocal.String(), "bin",
+			"cored"+"-"+version,
+		)
+	}
+	return filepath.Join(c.config.BinDir, "cored")
 }
 
 func (c Cored) dockerBinaryPath() string {
@@ -521,11 +537,12 @@ func (c Cored) prepare(ctx context.Context) error {
 	return nil
 }
 
-func (c Cored) saveClientWrapper(wrapperDir, hostname string) error {
+func (c Cored) saveClientWrapper(wrapperDir string, deployment infra.DeploymentInfo) error {
+	rpcAddr := infra.JoinNetAddr("tcp", deployment.HostFromHost, deployment.HostPort(c.config.Ports.RPC))
 	clientWrapper := `#!/bin/bash
 OPTS=""
 if [ "$1" == "tx" ] || [ "$1" == "q" ] || [ "$1" == "query" ]; then
-	OPTS="$OPTS --node ""` + infra.JoinNetAddr("tcp", hostname, c.config.Ports.RPC) + `"""
+	OPTS="$OPTS --node ""` + rpcAddr + `"""
 fi
 if [ "$1" == "tx" ] || [ "$1" == "keys" ]; then
 	OPTS="$OPTS --keyring-backend ""test"""
@@ -566,17 +583,51 @@ func copyFile(src, dst string, perm os.FileMode) error {
 	return nil
 }
 
+// genesisModules are the modules defining messages which may be included in genesis transactions.
+var genesisModules = []module.AppModuleBasic{
+	auth.AppModuleBasic{},
+	authzmodule.AppModuleBasic{},
+	bank.AppModuleBasic{},
+	distribution.AppModuleBasic{},
+	staking.AppModuleBasic{},
+	assetft.AppModuleBasic{},
+	assetnft.AppModuleBasic{},
+	dex.AppModuleBasic{},
+	wnft.AppModuleBasic{},
+}
+
+// newEncodingConfig returns encoding config of messages which may be included in genesis transactions.
+func newEncodingConfig() coreumconfig.EncodingConfig {
+	return coreumconfig.NewEncodingConfig(genesisModules...)
+}
+
+// newSignersCodec returns codec resolving signers of messages which may be included in genesis transactions.
+// Codec of encoding config can't do it, because it doesn't know the address prefix.
+func newSignersCodec(addressPrefix string) (codec.Codec, error) {
+	interfaceRegistry, err := codectypes.NewInterfaceRegistryWithOptions(codectypes.InterfaceRegistryOptions{
+		ProtoFiles: gogoproto.HybridResolver,
+		SigningOptions: signing.Options{
+			AddressCodec:          addresscodec.NewBech32Codec(addressPrefix),
+			ValidatorAddressCodec: addresscodec.NewBech32Codec(addressPrefix + "valoper"),
+		},
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	std.RegisterInterfaces(interfaceRegistry)
+	module.NewBasicManager(genesisModules...).RegisterInterfaces(interfaceRegistry)
+	return codec.NewProtoCodec(interfaceRegistry), nil
+}
+
 func signTxsWithMnemonic(
 	ctx context.Context,
 	chainID string,
 	mnemonic string,
+	sequence uint64,
 	msgs ...sdk.Msg,
 ) ([]byte, error) {
 	const signerKeyName = "signer"
-	encodingConfig := coreumconfig.NewEncodingConfig(
-		assetft.AppModuleBasic{},
-		dex.AppModuleBasic{},
-	)
+	encodingConfig := newEncodingConfig()
 	inMemKeyring := keyring.NewInMemory(encodingConfig.Codec)
 	_, err := inMemKeyring.NewAccount(
 		signerKeyName,
@@ -590,6 +641,7 @@ func signTxsWithMnemonic(
 	}
 	txf := tx.Factory{}.
 		WithChainID(chainID).
+		WithSequence(sequence).
 		WithKeybase(inMemKeyring).
 		WithTxConfig(encodingConfig.TxConfig)
 	txBuilder, err := txf.BuildUnsignedTx(msgs...)
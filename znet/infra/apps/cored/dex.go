@@ -0,0 +1,368 @@
+package cored
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	assetfttypes "github.com/CoreumFoundation/coreum/v6/x/asset/ft/types"
+	dexkeeper "github.com/CoreumFoundation/coreum/v6/x/dex/keeper"
+	dextypes "github.com/CoreumFoundation/coreum/v6/x/dex/types"
+	"github.com/CoreumFoundation/crust/znet/infra"
+)
+
+// dexTokenPrecision is the precision of tokens issued for DEX order books.
+const dexTokenPrecision = 8
+
+// defaultDEXBook is the order book generated if none is defined, it contains sell orders placed at price 1.
+var defaultDEXBook = infra.DEXBookDefinition{
+	Base:   "DEXSU",
+	Spread: "0",
+	Step:   "0",
+	Depth:  2_000,
+	Side:   infra.DEXSideSell,
+}
+
+// AddDEXGenesisConfig issues tokens and places orders of DEX order books in genesis. Tokens are issued and orders
+// resting in the books are placed by the funding account, it also sends the funds required by the IOC and FOK orders
+// to the taker account. Then the taker places the IOC and FOK ones crossing the books, so they are matched. Orders
+// of the same account are never matched, that's why the separate account is used. Max number of orders per denom is
+// set to the number of resting orders placed for the most used denom.
+func AddDEXGenesisConfig(
+	ctx context.Context,
+	genesisConfig GenesisInitConfig,
+	books []infra.DEXBookDefinition,
+) (GenesisInitConfig, error) {
+	if len(books) == 0 {
+		books = []infra.DEXBookDefinition{defaultDEXBook}
+	}
+
+	g := newDEXGenerator(genesisConfig.Denom)
+	for i, book := range books {
+		if err := g.generateBook(book); err != nil {
+			return GenesisInitConfig{}, errors.Wrapf(err, "generating DEX order book %d failed", i)
+		}
+	}
+
+	genesisConfig.DEXConfig.MaxOrdersPerDenom = max(genesisConfig.DEXConfig.MaxOrdersPerDenom, g.maxOrdersPerDenom())
+
+	txData, err := signTxsWithMnemonic(ctx, string(genesisConfig.ChainID), FundingMnemonic, 0, g.fundingMsgs()...)
+	if err != nil {
+		return GenesisInitConfig{}, err
+	}
+	genesisConfig.GenTxs = append(genesisConfig.GenTxs, txData)
+
+	if takerMsgs := g.takerMsgs(); len(takerMsgs) > 0 {
+		txData, err := signTxsWithMnemonic(ctx, string(genesisConfig.ChainID), DEXTakerMnemonic, 0, takerMsgs...)
+		if err != nil {
+			return GenesisInitConfig{}, err
+		}
+		genesisConfig.GenTxs = append(genesisConfig.GenTxs, txData)
+	}
+
+	return genesisConfig, nil
+}
+
+type dexGenerator struct {
+	chainDenom        string
+	priceTickExponent int64
+	quantityStep      sdkmath.Int
+
+	// issued are the subunits of issued tokens, in the order of issuance
+	issued []string
+	// symbols are the symbols of issued tokens indexed by subunit
+	symbols map[string]string
+	// locked are the amounts of issued tokens locked by resting orders indexed by subunit
+	locked map[string]sdkmath.Int
+	// takerLocked are the amounts locked by crossing orders indexed by subunit, empty subunit is the chain denom
+	takerLocked map[string]sdkmath.Int
+
+	restingOrders  []*dextypes.MsgPlaceOrder
+	crossingOrders []*dextypes.MsgPlaceOrder
+	// ordersPerDenom are the numbers of resting orders indexed by denom
+	ordersPerDenom map[string]uint64
+}
+
+func newDEXGenerator(chainDenom string) *dexGenerator {
+	// Tokens don't define unified ref amounts, so the default one is used to compute price tick and quantity step.
+	params := dextypes.DefaultParams()
+	uraBigInt := params.DefaultUnifiedRefAmount.BigInt()
+	_, priceTickExponent := dexkeeper.ComputePriceTick(uraBigInt, uraBigInt, params.PriceTickExponent)
+	// Since LegacyDec is multiplied by 10^LegacyPrecision when converting to BigInt,
+	// the same number must be subtracted from the exponent.
+	quantityStep, _ := dexkeeper.ComputeQuantityStep(uraBigInt, params.QuantityStepExponent-sdkmath.LegacyPrecision)
+
+	return &dexGenerator{
+		chainDenom:        chainDenom,
+		priceTickExponent: priceTickExponent,
+		quantityStep:      sdkmath.NewIntFromBigInt(quantityStep),
+		symbols:           map[string]string{},
+		locked:            map[string]sdkmath.Int{},
+		takerLocked:       map[string]sdkmath.Int{},
+		ordersPerDenom:    map[string]uint64{},
+	}
+}
+
+func (g *dexGenerator) generateBook(book infra.DEXBookDefinition) error {
+	if err := book.Validate(); err != nil {
+		return err
+	}
+
+	midPrice := ratOrDefault(book.Price, "1")
+	spread := ratOrDefault(book.Spread, "0.01")
+	step := ratOrDefault(book.Step, "0.001")
+	depth := lo.CoalesceOrEmpty(book.Depth, 10)
+	ordersPerLevel := lo.CoalesceOrEmpty(book.OrdersPerLevel, 1)
+	minQuantity := lo.CoalesceOrEmpty(book.MinQuantity, 100_000)
+	maxQuantity := max(book.MaxQuantity, minQuantity)
+	timeInForceValues := lo.CoalesceSliceOrEmpty(book.TimeInForce, []string{infra.DEXTimeInForceGTC})
+	sides := []dextypes.Side{dextypes.SIDE_SELL, dextypes.SIDE_BUY}
+	switch book.Side {
+	case infra.DEXSideSell:
+		sides = []dextypes.Side{dextypes.SIDE_SELL}
+	case infra.DEXSideBuy:
+		sides = []dextypes.Side{dextypes.SIDE_BUY}
+	}
+
+	baseSubunit := g.issue(book.Base)
+	quoteSubunit := ""
+	if book.Quote != "" {
+		quoteSubunit = g.issue(book.Quote)
+	}
+	baseDenom := g.denom(baseSubunit)
+	quoteDenom := g.denom(quoteSubunit)
+
+	// Random generator is seeded, so the same book is generated every time.
+	rnd := rand.New(rand.NewSource(book.Seed)) //nolint:gosec // cryptographically secure generator is not needed
+
+	// levelPrice returns the price of the level on the side of the book. Sell prices are rounded up and buy prices
+	// are rounded down to the price tick, so the spread is never narrowed.
+	levelPrice := func(side dextypes.Side, level int) (dextypes.Price, error) {
+		// offset = spread / 2 + level * step
+		offset := new(big.Rat).Add(
+			new(big.Rat).Quo(spread, big.NewRat(2, 1)),
+			new(big.Rat).Mul(step, big.NewRat(int64(level), 1)),
+		)
+		if side == dextypes.SIDE_BUY {
+			offset.Neg(offset)
+		}
+		price := new(big.Rat).Mul(midPrice, new(big.Rat).Add(big.NewRat(1, 1), offset))
+		return dexPrice(price, g.priceTickExponent, side == dextypes.SIDE_SELL)
+	}
+
+	for level := range depth {
+		for _, side := range sides {
+			for range ordersPerLevel {
+				timeInForce := timeInForceValues[rnd.Intn(len(timeInForceValues))]
+				quantity := sdkmath.NewInt(minQuantity + rnd.Int63n(maxQuantity-minQuantity+1)).
+					Quo(g.quantityStep).Mul(g.quantityStep)
+				if quantity.IsZero() {
+					quantity = g.quantityStep
+				}
+
+				// IOC and FOK orders don't rest in the book, so they are placed by the taker at the price of the
+				// opposite side to be matched with the resting orders.
+				resting := timeInForce == infra.DEXTimeInForceGTC
+				priceSide, sender := side, FundingAddress
+				if !resting {
+					priceSide, sender = oppositeSide(side), DEXTakerAddress
+				}
+				price, err := levelPrice(priceSide, level)
+				if err != nil {
+					return errors.Wrapf(err, "invalid price of level %d, decrease depth, spread or step", level)
+				}
+
+				order := &dextypes.MsgPlaceOrder{
+					Sender:      sender,
+					Type:        dextypes.ORDER_TYPE_LIMIT,
+					BaseDenom:   baseDenom,
+					QuoteDenom:  quoteDenom,
+					Price:       &price,
+					Quantity:    quantity,
+					Side:        side,
+					TimeInForce: dexTimeInForce(timeInForce),
+				}
+				lockedSubunit, lockedAmount := baseSubunit, quantity
+				if side == dextypes.SIDE_BUY {
+					lockedSubunit = quoteSubunit
+					lockedAmount = ceilInt(new(big.Rat).Mul(new(big.Rat).SetInt(quantity.BigInt()), price.Rat()))
+				}
+
+				if resting {
+					g.restingOrders = append(g.restingOrders, order)
+					g.ordersPerDenom[baseDenom]++
+					g.ordersPerDenom[quoteDenom]++
+					g.lock(lockedSubunit, lockedAmount)
+				} else {
+					g.crossingOrders = append(g.crossingOrders, order)
+					g.lockTaker(lockedSubunit, lockedAmount)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// issue registers the token to be issued and returns its subunit.
+func (g *dexGenerator) issue(symbol string) string {
+	subunit := strings.ToLower(symbol)
+	if _, exists := g.symbols[subunit]; !exists {
+		g.issued = append(g.issued, subunit)
+		g.symbols[subunit] = symbol
+		g.locked[subunit] = sdkmath.ZeroInt()
+	}
+	return subunit
+}
+
+// lock adds the amount of issued token locked by the resting order. Chain denom is funded by the genesis.
+func (g *dexGenerator) lock(subunit string, amount sdkmath.Int) {
+	if subunit != "" {
+		g.locked[subunit] = g.locked[subunit].Add(amount)
+	}
+}
+
+// lockTaker adds the amount locked by the crossing order, it is sent to the taker by the funding account.
+func (g *dexGenerator) lockTaker(subunit string, amount sdkmath.Int) {
+	if locked, exists := g.takerLocked[subunit]; exists {
+		amount = locked.Add(amount)
+	}
+	g.takerLocked[subunit] = amount
+}
+
+func (g *dexGenerator) denom(subunit string) string {
+	if subunit == "" {
+		return g.chainDenom
+	}
+	return subunit + "-" + FundingAddress
+}
+
+func (g *dexGenerator) maxOrdersPerDenom() uint64 {
+	return lo.Max(lo.Values(g.ordersPerDenom))
+}
+
+// fundingMsgs returns messages of the funding account issuing tokens with initial amounts required by the orders,
+// sending the funds required by the crossing orders to the taker and placing the resting orders.
+func (g *dexGenerator) fundingMsgs() []sdk.Msg {
+	msgs := make([]sdk.Msg, 0, len(g.issued)+len(g.restingOrders)+1)
+	for _, subunit := range g.issued {
+		initialAmount := g.locked[subunit]
+		if takerAmount, exists := g.takerLocked[subunit]; exists {
+			initialAmount = initialAmount.Add(takerAmount)
+		}
+		msgs = append(msgs, &assetfttypes.MsgIssue{
+			Issuer:        FundingAddress,
+			Symbol:        g.symbols[subunit],
+			Subunit:       subunit,
+			Precision:     dexTokenPrecision,
+			InitialAmount: initialAmount,
+		})
+	}
+
+	takerCoins := sdk.NewCoins()
+	for subunit, amount := range g.takerLocked {
+		takerCoins = takerCoins.Add(sdk.NewCoin(g.denom(subunit), amount))
+	}
+	if !takerCoins.IsZero() {
+		msgs = append(msgs, &banktypes.MsgSend{
+			FromAddress: FundingAddress,
+			ToAddress:   DEXTakerAddress,
+			Amount:      takerCoins,
+		})
+	}
+
+	for i, order := range g.restingOrders {
+		order.ID = fmt.Sprintf("id-%d", i)
+		msgs = append(msgs, order)
+	}
+	return msgs
+}
+
+// takerMsgs returns messages of the taker account placing the crossing orders.
+func (g *dexGenerator) takerMsgs() []sdk.Msg {
+	msgs := make([]sdk.Msg, 0, len(g.crossingOrders))
+	for i, order := range g.crossingOrders {
+		order.ID = fmt.Sprintf("id-%d", i)
+		msgs = append(msgs, order)
+	}
+	return msgs
+}
+
+// dexPrice converts the price to the DEX price being a multiple of the price tick.
+func dexPrice(price *big.Rat, tickExponent int64, roundUp bool) (dextypes.Price, error) {
+	// num = price / 10^tickExponent
+	tick := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(abs(tickExponent)), nil))
+	if tickExponent < 0 {
+		tick.Inv(tick)
+	}
+	scaled := new(big.Rat).Quo(price, tick)
+	var num *big.Int
+	if roundUp {
+		num = ceilInt(scaled).BigInt()
+	} else {
+		num = new(big.Int).Quo(scaled.Num(), scaled.Denom())
+	}
+	if num.Sign() <= 0 {
+		return dextypes.Price{}, errors.Errorf("price %s is not positive", price.FloatString(6))
+	}
+
+	exp := tickExponent
+	ten := big.NewInt(10)
+	for new(big.Int).Rem(num, ten).Sign() == 0 {
+		num.Quo(num, ten)
+		exp++
+	}
+	priceStr := num.String()
+	if exp != 0 {
+		priceStr += dextypes.ExponentSymbol + fmt.Sprint(exp)
+	}
+	return dextypes.NewPriceFromString(priceStr)
+}
+
+func dexTimeInForce(timeInForce string) dextypes.TimeInForce {
+	switch timeInForce {
+	case infra.DEXTimeInForceIOC:
+		return dextypes.TIME_IN_FORCE_IOC
+	case infra.DEXTimeInForceFOK:
+		return dextypes.TIME_IN_FORCE_FOK
+	default:
+		return dextypes.TIME_IN_FORCE_GTC
+	}
+}
+
+func oppositeSide(side dextypes.Side) dextypes.Side {
+	if side == dextypes.SIDE_BUY {
+		return dextypes.SIDE_SELL
+	}
+	return dextypes.SIDE_BUY
+}
+
+func ratOrDefault(value, defaultValue string) *big.Rat {
+	r, ok := new(big.Rat).SetString(lo.CoalesceOrEmpty(value, defaultValue))
+	if !ok {
+		panic(fmt.Sprintf("invalid number %q", value))
+	}
+	return r
+}
+
+func ceilInt(r *big.Rat) sdkmath.Int {
+	num := new(big.Int).Quo(r.Num(), r.Denom())
+	if new(big.Int).Rem(r.Num(), r.Denom()).Sign() > 0 {
+		num.Add(num, big.NewInt(1))
+	}
+	return sdkmath.NewIntFromBigInt(num)
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,172 @@
+package cored_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum/v6/pkg/config/constant"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/cored"
+)
+
+type genesisMsg struct {
+	Type          string `json:"@type"`
+	Symbol        string `json:"symbol"`
+	InitialAmount string `json:"initial_amount"`
+	ToAddress     string `json:"to_address"`
+	Amount        []struct {
+		Denom  string `json:"denom"`
+		Amount string `json:"amount"`
+	} `json:"amount"`
+	ID          string `json:"id"`
+	Sender      string `json:"sender"`
+	BaseDenom   string `json:"base_denom"`
+	QuoteDenom  string `json:"quote_denom"`
+	Price       string `json:"price"`
+	Quantity    string `json:"quantity"`
+	Side        string `json:"side"`
+	TimeInForce string `json:"time_in_force"`
+}
+
+func TestAddDEXGenesisConfig(t *testing.T) {
+	genesisConfig := cored.GenesisInitConfig{
+		ChainID: constant.ChainIDDev,
+		Denom:   constant.DenomDev,
+	}
+	// decodeMsgs returns messages of each genesis transaction.
+	decodeMsgs := func(t *testing.T, genesisConfig cored.GenesisInitConfig, txCount int) [][]genesisMsg {
+		require.Len(t, genesisConfig.GenTxs, txCount)
+		return lo.Map(genesisConfig.GenTxs, func(txData json.RawMessage, _ int) []genesisMsg {
+			var tx struct {
+				Body struct {
+					Messages []genesisMsg `json:"messages"`
+				} `json:"body"`
+			}
+			require.NoError(t, json.Unmarshal(txData, &tx))
+			return tx.Body.Messages
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		result, err := cored.AddDEXGenesisConfig(context.Background(), genesisConfig, nil)
+		require.NoError(t, err)
+
+		msgs := decodeMsgs(t, result, 1)[0]
+		require.Len(t, msgs, 2001)
+		assert.Equal(t, "DEXSU", msgs[0].Symbol)
+		assert.Equal(t, "200000000", msgs[0].InitialAmount)
+		assert.Equal(t, "1", msgs[1].Price)
+		assert.Equal(t, "1", msgs[2000].Price)
+		assert.Equal(t, "SIDE_SELL", msgs[2000].Side)
+		assert.EqualValues(t, 2000, result.DEXConfig.MaxOrdersPerDenom)
+	})
+
+	t.Run("books", func(t *testing.T) {
+		books := []infra.DEXBookDefinition{
+			{
+				Base:        "AAA",
+				Price:       "2",
+				Spread:      "0.1",
+				Step:        "0.05",
+				Depth:       2,
+				MinQuantity: 10_000,
+				MaxQuantity: 1_000_000,
+				TimeInForce: []string{infra.DEXTimeInForceGTC, infra.DEXTimeInForceIOC},
+				Seed:        3,
+			},
+			{
+				Base:           "BBB",
+				Quote:          "AAA",
+				Price:          "0.001",
+				Depth:          3,
+				OrdersPerLevel: 2,
+				Side:           infra.DEXSideBuy,
+			},
+		}
+		result, err := cored.AddDEXGenesisConfig(context.Background(), genesisConfig, books)
+		require.NoError(t, err)
+
+		txs := decodeMsgs(t, result, 2)
+		fundingMsgs, takerMsgs := txs[0], txs[1]
+		issues := lo.Filter(fundingMsgs, func(msg genesisMsg, _ int) bool {
+			return msg.Type == "/coreum.asset.ft.v1.MsgIssue"
+		})
+		sends := lo.Filter(fundingMsgs, func(msg genesisMsg, _ int) bool {
+			return msg.Type == "/cosmos.bank.v1beta1.MsgSend"
+		})
+		restingOrders := lo.Filter(fundingMsgs, func(msg genesisMsg, _ int) bool {
+			return msg.Type == "/coreum.dex.v1.MsgPlaceOrder"
+		})
+		require.Len(t, issues, 2)
+		assert.Equal(t, []string{"AAA", "BBB"}, lo.Map(issues, func(msg genesisMsg, _ int) string {
+			return msg.Symbol
+		}))
+
+		// Resting orders are placed by the funding account, IOC orders are placed by the taker, so they are matched.
+		for _, order := range restingOrders {
+			assert.Equal(t, cored.FundingAddress, order.Sender)
+			assert.Equal(t, "TIME_IN_FORCE_GTC", order.TimeInForce)
+		}
+		require.NotEmpty(t, takerMsgs)
+		for _, order := range takerMsgs {
+			assert.Equal(t, "/coreum.dex.v1.MsgPlaceOrder", order.Type)
+			assert.Equal(t, cored.DEXTakerAddress, order.Sender)
+			assert.Equal(t, "TIME_IN_FORCE_IOC", order.TimeInForce)
+		}
+		orders := append(append([]genesisMsg{}, restingOrders...), takerMsgs...)
+		require.Len(t, orders, 10)
+
+		// Funds locked by IOC orders are sent to the taker.
+		require.Len(t, sends, 1)
+		assert.Equal(t, cored.DEXTakerAddress, sends[0].ToAddress)
+		assert.NotEmpty(t, sends[0].Amount)
+
+		for _, order := range orders {
+			if order.BaseDenom != "aaa-"+cored.FundingAddress {
+				continue
+			}
+			assert.Equal(t, constant.DenomDev, order.QuoteDenom)
+			sellPrices := []string{"21e-1", "22e-1"}
+			buyPrices := []string{"19e-1", "18e-1"}
+			if (order.Side == "SIDE_SELL") == (order.TimeInForce == "TIME_IN_FORCE_GTC") {
+				assert.Contains(t, sellPrices, order.Price)
+			} else {
+				assert.Contains(t, buyPrices, order.Price)
+			}
+		}
+
+		bbbOrders := lo.Filter(orders, func(order genesisMsg, _ int) bool {
+			return order.BaseDenom == "bbb-"+cored.FundingAddress
+		})
+		require.Len(t, bbbOrders, 6)
+		for _, order := range bbbOrders {
+			assert.Equal(t, "aaa-"+cored.FundingAddress, order.QuoteDenom)
+			assert.Equal(t, "SIDE_BUY", order.Side)
+			assert.Equal(t, "100000", order.Quantity)
+		}
+		assert.Equal(t, []string{"995e-6", "995e-6", "994e-6", "994e-6", "993e-6", "993e-6"},
+			lo.Map(bbbOrders, func(order genesisMsg, _ int) string {
+				return order.Price
+			}))
+
+		// AAA is used by all the resting orders.
+		assert.EqualValues(t, len(restingOrders), result.DEXConfig.MaxOrdersPerDenom)
+	})
+
+	t.Run("invalid_book", func(t *testing.T) {
+		_, err := cored.AddDEXGenesisConfig(context.Background(), genesisConfig, []infra.DEXBookDefinition{
+			{Base: "AAA", Spread: "1", Step: "1", Depth: 2},
+		})
+		require.ErrorContains(t, err, "invalid price of level 1")
+
+		_, err = cored.AddDEXGenesisConfig(context.Background(), genesisConfig, []infra.DEXBookDefinition{
+			{Base: "AAA", Spread: "0"},
+		})
+		require.ErrorContains(t, err, "spread must be positive")
+	})
+}
@@ -0,0 +1,437 @@
+package cored
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/must"
+	coreumconstant "github.com/CoreumFoundation/coreum/v6/pkg/config/constant"
+	assetfttypes "github.com/CoreumFoundation/coreum/v6/x/asset/ft/types"
+	assetnfttypes "github.com/CoreumFoundation/coreum/v6/x/asset/nft/types"
+)
+
+// GenesisFixture declares the state included in genesis of cored network.
+type GenesisFixture struct {
+	// Accounts maps names of accounts to their mnemonics. Accounts sign the issuances and messages.
+	Accounts map[string]string `json:"accounts,omitempty"`
+
+	// Balances are the bank balances of accounts
+	Balances []FixtureBalance `json:"balances,omitempty"`
+
+	// FTs are the fungible tokens issued by accounts
+	FTs []FixtureFT `json:"fts,omitempty"`
+
+	// NFTClasses are the non-fungible token classes issued by accounts
+	NFTClasses []FixtureNFTClass `json:"nftClasses,omitempty"`
+
+	// Messages are the messages signed by accounts
+	Messages []FixtureMessage `json:"messages,omitempty"`
+}
+
+// FixtureBalance defines the bank balance of the address or account.
+type FixtureBalance struct {
+	// Address is the address of funded account, mutually exclusive with Account
+	Address string `json:"address,omitempty"`
+
+	// Account is the name of funded account, mutually exclusive with Address
+	Account string `json:"account,omitempty"`
+
+	// Coins is the list of coins, e.g. 1000000udevcore,100ibc/ABC
+	Coins string `json:"coins"`
+}
+
+// FixtureFT defines the fungible token issued by the account.
+type FixtureFT struct {
+	Issuer             string   `json:"issuer"`
+	Symbol             string   `json:"symbol"`
+	Subunit            string   `json:"subunit"`
+	Precision          uint32   `json:"precision"`
+	InitialAmount      string   `json:"initialAmount,omitempty"`
+	Description        string   `json:"description,omitempty"`
+	URI                string   `json:"uri,omitempty"`
+	Features           []string `json:"features,omitempty"`
+	BurnRate           string   `json:"burnRate,omitempty"`
+	SendCommissionRate string   `json:"sendCommissionRate,omitempty"`
+}
+
+// FixtureNFTClass defines the non-fungible token class issued by the account.
+type FixtureNFTClass struct {
+	Issuer      string       `json:"issuer"`
+	Symbol      string       `json:"symbol"`
+	Name        string       `json:"name,omitempty"`
+	Description string       `json:"description,omitempty"`
+	URI         string       `json:"uri,omitempty"`
+	Features    []string     `json:"features,omitempty"`
+	RoyaltyRate string       `json:"royaltyRate,omitempty"`
+	NFTs        []FixtureNFT `json:"nfts,omitempty"`
+}
+
+// FixtureNFT defines the non-fungible token minted by the issuer of the class.
+type FixtureNFT struct {
+	ID        string `json:"id"`
+	URI       string `json:"uri,omitempty"`
+	Recipient string `json:"recipient,omitempty"`
+}
+
+// FixtureMessage defines the message signed by the account.
+type FixtureMessage struct {
+	// Signer is the name of the account signing the message
+	Signer string `json:"signer"`
+
+	// Message is the message in proto JSON format, including its @type
+	Message json.RawMessage `json:"message"`
+}
+
+// ParseGenesisFixture parses and validates genesis fixture. Addresses are verified later, when the fixture is added
+// to genesis using the address prefix of the chain.
+func ParseGenesisFixture(data []byte) (GenesisFixture, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var fixture GenesisFixture
+	if err := decoder.Decode(&fixture); err != nil {
+		return GenesisFixture{}, errors.WithStack(err)
+	}
+	if _, err := fixture.signedMsgs(coreumconstant.AddressPrefixDev); err != nil {
+		return GenesisFixture{}, err
+	}
+	if _, err := fixture.balances(coreumconstant.AddressPrefixDev); err != nil {
+		return GenesisFixture{}, err
+	}
+	return fixture, nil
+}
+
+// AddGenesisFixture adds balances to genesis and signs transactions issuing assets and broadcasting messages of
+// the fixture. Each account signs single transaction containing all its messages, transactions are ordered by the
+// first appearance of the account in issuances and messages.
+//
+// Transactions are signed using sequence 0, so accounts can't sign any other genesis transaction. Signers maps
+// the descriptions of accounts signing other genesis transactions to their mnemonics, fixture using any of them
+// is rejected.
+func AddGenesisFixture(
+	ctx context.Context,
+	genesisConfig GenesisInitConfig,
+	fixture GenesisFixture,
+	signers map[string]string,
+) (GenesisInitConfig, error) {
+	balances, err := fixture.balances(genesisConfig.AddressPrefix)
+	if err != nil {
+		return GenesisInitConfig{}, err
+	}
+	for _, balance := range balances {
+		if _, err := sdk.GetFromBech32(balance.Address, genesisConfig.AddressPrefix); err != nil {
+			return GenesisInitConfig{}, errors.Wrapf(err, "invalid address %s", balance.Address)
+		}
+		genesisConfig.BankBalances = addBalance(genesisConfig.BankBalances, balance)
+	}
+
+	signedMsgs, err := fixture.signedMsgs(genesisConfig.AddressPrefix)
+	if err != nil {
+		return GenesisInitConfig{}, err
+	}
+	if err := checkSigners(signedMsgs, signers, genesisConfig.AddressPrefix); err != nil {
+		return GenesisInitConfig{}, err
+	}
+	signersCodec, err := newSignersCodec(genesisConfig.AddressPrefix)
+	if err != nil {
+		return GenesisInitConfig{}, err
+	}
+	for _, signed := range signedMsgs {
+		for _, msg := range signed.msgs {
+			if err := checkMsgSigners(signersCodec, msg, signed, genesisConfig.AddressPrefix); err != nil {
+				return GenesisInitConfig{}, err
+			}
+			if m, ok := msg.(sdk.HasValidateBasic); ok {
+				if err := m.ValidateBasic(); err != nil {
+					return GenesisInitConfig{}, errors.Wrapf(err, "invalid message %s signed by %s",
+						sdk.MsgTypeURL(msg), signed.account)
+				}
+			}
+		}
+		txData, err := signTxsWithMnemonic(ctx, string(genesisConfig.ChainID), signed.mnemonic, 0, signed.msgs...)
+		if err != nil {
+			return GenesisInitConfig{}, errors.Wrapf(err, "signing messages of %s failed", signed.account)
+		}
+		genesisConfig.GenTxs = append(genesisConfig.GenTxs, txData)
+	}
+
+	return genesisConfig, nil
+}
+
+type fixtureSignedMsgs struct {
+	account  string
+	address  string
+	mnemonic string
+	msgs     []sdk.Msg
+}
+
+// checkSigners verifies that each fixture account signs single genesis transaction. Account signing any
+// transaction outside the fixture, or defined twice under different names, would reuse the sequence.
+func checkSigners(signedMsgs []fixtureSignedMsgs, signers map[string]string, addressPrefix string) error {
+	signerNames := map[string]string{}
+	for name, mnemonic := range signers {
+		address, err := mnemonicAddress(name, mnemonic, addressPrefix)
+		if err != nil {
+			return errors.Wrapf(err, "invalid mnemonic of %s", name)
+		}
+		signerNames[address] = name
+	}
+
+	for _, signed := range signedMsgs {
+		if name, exists := signerNames[signed.address]; exists {
+			return errors.Errorf("account %q signs other genesis transactions as %s, use different mnemonic",
+				signed.account, name)
+		}
+		signerNames[signed.address] = "account " + strconv.Quote(signed.account)
+	}
+	return nil
+}
+
+// checkMsgSigners verifies that the message declares the account as its only signer. Otherwise, transaction signed
+// by the account would be rejected when genesis is validated.
+func checkMsgSigners(cdc codec.Codec, msg sdk.Msg, signed fixtureSignedMsgs, addressPrefix string) error {
+	signers, _, err := cdc.GetMsgV1Signers(msg)
+	if err != nil {
+		return errors.Wrapf(err, "resolving signers of message %s failed", sdk.MsgTypeURL(msg))
+	}
+	address, err := sdk.GetFromBech32(signed.address, addressPrefix)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, signer := range signers {
+		if !bytes.Equal(signer, address) {
+			return errors.Errorf("message %s must be signed by %s, not by account %q", sdk.MsgTypeURL(msg),
+				must.String(sdk.Bech32ifyAddressBytes(addressPrefix, signer)), signed.account)
+		}
+	}
+	return nil
+}
+
+func (f GenesisFixture) balances(addressPrefix string) ([]banktypes.Balance, error) {
+	balances := make([]banktypes.Balance, 0, len(f.Balances))
+	for i, balance := range f.Balances {
+		address := balance.Address
+		switch {
+		case address != "" && balance.Account != "":
+			return nil, errors.Errorf("balance %d must define either address or account", i)
+		case address == "":
+			var err error
+			if address, _, err = f.account(balance.Account, addressPrefix); err != nil {
+				return nil, errors.Wrapf(err, "invalid balance %d", i)
+			}
+		}
+		coins, err := sdk.ParseCoinsNormalized(balance.Coins)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid coins of balance %d", i)
+		}
+		balances = append(balances, banktypes.Balance{Address: address, Coins: coins})
+	}
+	return balances, nil
+}
+
+func (f GenesisFixture) signedMsgs(addressPrefix string) ([]fixtureSignedMsgs, error) {
+	var result []fixtureSignedMsgs
+	add := func(account string, msgs ...sdk.Msg) error {
+		for i := range result {
+			if result[i].account == account {
+				result[i].msgs = append(result[i].msgs, msgs...)
+				return nil
+			}
+		}
+		address, mnemonic, err := f.account(account, addressPrefix)
+		if err != nil {
+			return err
+		}
+		result = append(result, fixtureSignedMsgs{account: account, address: address, mnemonic: mnemonic, msgs: msgs})
+		return nil
+	}
+
+	for _, ft := range f.FTs {
+		msg, err := f.issueFTMsg(ft, addressPrefix)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid fungible token %s", ft.Symbol)
+		}
+		if err := add(ft.Issuer, msg); err != nil {
+			return nil, errors.Wrapf(err, "invalid fungible token %s", ft.Symbol)
+		}
+	}
+	for _, class := range f.NFTClasses {
+		msgs, err := f.issueNFTClassMsgs(class, addressPrefix)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid non-fungible token class %s", class.Symbol)
+		}
+		if err := add(class.Issuer, msgs...); err != nil {
+			return nil, errors.Wrapf(err, "invalid non-fungible token class %s", class.Symbol)
+		}
+	}
+
+	encodingConfig := newEncodingConfig()
+	for i, message := range f.Messages {
+		var msg sdk.Msg
+		if err := encodingConfig.Codec.UnmarshalInterfaceJSON(message.Message, &msg); err != nil {
+			return nil, errors.Wrapf(err, "decoding message %d failed", i)
+		}
+		if err := add(message.Signer, msg); err != nil {
+			return nil, errors.Wrapf(err, "invalid message %d", i)
+		}
+	}
+	return result, nil
+}
+
+func (f GenesisFixture) issueFTMsg(ft FixtureFT, addressPrefix string) (*assetfttypes.MsgIssue, error) {
+	issuer, _, err := f.account(ft.Issuer, addressPrefix)
+	if err != nil {
+		return nil, err
+	}
+	features, err := parseFeatures(ft.Features, assetfttypes.Feature_value)
+	if err != nil {
+		return nil, err
+	}
+	msg := &assetfttypes.MsgIssue{
+		Issuer:        issuer,
+		Symbol:        ft.Symbol,
+		Subunit:       ft.Subunit,
+		Precision:     ft.Precision,
+		InitialAmount: sdkmath.ZeroInt(),
+		Description:   ft.Description,
+		URI:           ft.URI,
+		Features: lo.Map(features, func(feature int32, _ int) assetfttypes.Feature {
+			return assetfttypes.Feature(feature)
+		}),
+		BurnRate:           sdkmath.LegacyZeroDec(),
+		SendCommissionRate: sdkmath.LegacyZeroDec(),
+	}
+	if ft.InitialAmount != "" {
+		var ok bool
+		if msg.InitialAmount, ok = sdkmath.NewIntFromString(ft.InitialAmount); !ok {
+			return nil, errors.Errorf("invalid initial amount %q", ft.InitialAmount)
+		}
+	}
+	if ft.BurnRate != "" {
+		if msg.BurnRate, err = sdkmath.LegacyNewDecFromStr(ft.BurnRate); err != nil {
+			return nil, errors.Wrapf(err, "invalid burn rate %q", ft.BurnRate)
+		}
+	}
+	if ft.SendCommissionRate != "" {
+		if msg.SendCommissionRate, err = sdkmath.LegacyNewDecFromStr(ft.SendCommissionRate); err != nil {
+			return nil, errors.Wrapf(err, "invalid send commission rate %q", ft.SendCommissionRate)
+		}
+	}
+	return msg, nil
+}
+
+func (f GenesisFixture) issueNFTClassMsgs(class FixtureNFTClass, addressPrefix string) ([]sdk.Msg, error) {
+	issuer, _, err := f.account(class.Issuer, addressPrefix)
+	if err != nil {
+		return nil, err
+	}
+	features, err := parseFeatures(class.Features, assetnfttypes.ClassFeature_value)
+	if err != nil {
+		return nil, err
+	}
+	msg := &assetnfttypes.MsgIssueClass{
+		Issuer:      issuer,
+		Symbol:      class.Symbol,
+		Name:        class.Name,
+		Description: class.Description,
+		URI:         class.URI,
+		Features: lo.Map(features, func(feature int32, _ int) assetnfttypes.ClassFeature {
+			return assetnfttypes.ClassFeature(feature)
+		}),
+		RoyaltyRate: sdkmath.LegacyZeroDec(),
+	}
+	if class.RoyaltyRate != "" {
+		if msg.RoyaltyRate, err = sdkmath.LegacyNewDecFromStr(class.RoyaltyRate); err != nil {
+			return nil, errors.Wrapf(err, "invalid royalty rate %q", class.RoyaltyRate)
+		}
+	}
+
+	msgs := []sdk.Msg{msg}
+	// Class ID is built the same way as assetnfttypes.BuildClassID does, but without using global address prefix.
+	classID := strings.ToLower(class.Symbol) + "-" + issuer
+	for _, nft := range class.NFTs {
+		msgs = append(msgs, &assetnfttypes.MsgMint{
+			Sender:    issuer,
+			ClassID:   classID,
+			ID:        nft.ID,
+			URI:       nft.URI,
+			Recipient: nft.Recipient,
+		})
+	}
+	return msgs, nil
+}
+
+// account returns address and mnemonic of the account.
+func (f GenesisFixture) account(name, addressPrefix string) (string, string, error) {
+	mnemonic, exists := f.Accounts[name]
+	if !exists {
+		return "", "", errors.Errorf("account %q is not defined", name)
+	}
+	address, err := mnemonicAddress(name, mnemonic, addressPrefix)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "invalid mnemonic of account %q", name)
+	}
+	return address, mnemonic, nil
+}
+
+// mnemonicAddress returns address of the account. Address is encoded explicitly using the prefix, because
+// encoding based on global config caches addresses encoded using previously configured prefix.
+func mnemonicAddress(name, mnemonic, addressPrefix string) (string, error) {
+	kr := keyring.NewInMemory(newEncodingConfig().Codec)
+	record, err := kr.NewAccount(
+		name,
+		mnemonic,
+		"",
+		hd.CreateHDPath(coreumconstant.CoinType, 0, 0).String(),
+		hd.Secp256k1,
+	)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	address, err := record.GetAddress()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	encoded, err := sdk.Bech32ifyAddressBytes(addressPrefix, address)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return encoded, nil
+}
+
+func parseFeatures(names []string, values map[string]int32) ([]int32, error) {
+	features := make([]int32, 0, len(names))
+	for _, name := range names {
+		feature, exists := values[name]
+		if !exists {
+			known := lo.Keys(values)
+			sort.Strings(known)
+			return nil, errors.Errorf("unknown feature %q, known features: %v", name, known)
+		}
+		features = append(features, feature)
+	}
+	return features, nil
+}
+
+// addBalance adds coins to the existing balance of the address, so each address is funded once in genesis.
+func addBalance(balances []banktypes.Balance, balance banktypes.Balance) []banktypes.Balance {
+	for i := range balances {
+		if balances[i].Address == balance.Address {
+			balances[i].Coins = balances[i].Coins.Add(balance.Coins...)
+			return balances
+		}
+	}
+	return append(balances, balance)
+}
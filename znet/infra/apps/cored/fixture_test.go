@@ -0,0 +1,188 @@
+package cored_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/must"
+	"github.com/CoreumFoundation/coreum/v6/pkg/config/constant"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/cored"
+)
+
+func TestParseGenesisFixture(t *testing.T) {
+	testCases := []struct {
+		name        string
+		fixture     string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			fixture: `{
+				"accounts": {"issuer": "` + cored.AliceMnemonic + `"},
+				"balances": [{"account": "issuer", "coins": "1000udevcore"}],
+				"fts": [{"issuer": "issuer", "symbol": "ABC", "subunit": "uabc", "features": ["minting"]}],
+				"nftClasses": [{"issuer": "issuer", "symbol": "NFTABC", "nfts": [{"id": "nft1"}]}]
+			}`,
+		},
+		{
+			name:        "unknown_field",
+			fixture:     `{"tokens": []}`,
+			expectedErr: `unknown field "tokens"`,
+		},
+		{
+			name:        "undefined_account",
+			fixture:     `{"balances": [{"account": "issuer", "coins": "1000udevcore"}]}`,
+			expectedErr: `account "issuer" is not defined`,
+		},
+		{
+			name:        "invalid_mnemonic",
+			fixture:     `{"accounts": {"issuer": "invalid"}, "fts": [{"issuer": "issuer", "symbol": "ABC"}]}`,
+			expectedErr: `invalid mnemonic of account "issuer"`,
+		},
+		{
+			name:        "invalid_coins",
+			fixture:     `{"balances": [{"address": "devcore1", "coins": "udevcore"}]}`,
+			expectedErr: "invalid coins of balance 0",
+		},
+		{
+			name: "unknown_feature",
+			fixture: `{
+				"accounts": {"issuer": "` + cored.AliceMnemonic + `"},
+				"fts": [{"issuer": "issuer", "symbol": "ABC", "features": ["teleporting"]}]
+			}`,
+			expectedErr: `unknown feature "teleporting"`,
+		},
+		{
+			name: "unknown_message",
+			fixture: `{
+				"accounts": {"issuer": "` + cored.AliceMnemonic + `"},
+				"messages": [{"signer": "issuer", "message": {"@type": "/unknown.MsgUnknown"}}]
+			}`,
+			expectedErr: "decoding message 0 failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := cored.ParseGenesisFixture([]byte(tc.fixture))
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAddGenesisFixture(t *testing.T) {
+	// Messages are validated using global address prefix.
+	sdk.GetConfig().SetBech32PrefixForAccount(constant.AddressPrefixDev, constant.AddressPrefixDev+"pub")
+	sdk.GetConfig().SetCoinType(constant.CoinType)
+
+	address := func(mnemonic string) string {
+		privKey, err := cored.PrivateKeyFromMnemonic(mnemonic)
+		require.NoError(t, err)
+		return must.String(sdk.Bech32ifyAddressBytes(constant.AddressPrefixDev, privKey.PubKey().Address()))
+	}
+	issuer := address(cored.AliceMnemonic)
+	sender := address(cored.BobMnemonic)
+
+	fixture, err := cored.ParseGenesisFixture([]byte(`{
+		"accounts": {"issuer": "` + cored.AliceMnemonic + `", "sender": "` + cored.BobMnemonic + `"},
+		"balances": [
+			{"account": "issuer", "coins": "1000udevcore"},
+			{"address": "` + cored.FaucetAddress + `", "coins": "5udevcore,7uatom"}
+		],
+		"fts": [{"issuer": "issuer", "symbol": "ABC", "subunit": "uabc", "initialAmount": "1000000"}],
+		"messages": [
+			{"signer": "sender", "message": {
+				"@type": "/cosmos.bank.v1beta1.MsgSend",
+				"from_address": "` + sender + `",
+				"to_address": "` + issuer + `",
+				"amount": [{"denom": "udevcore", "amount": "1"}]
+			}},
+			{"signer": "issuer", "message": {
+				"@type": "/cosmos.bank.v1beta1.MsgSend",
+				"from_address": "` + issuer + `",
+				"to_address": "` + sender + `",
+				"amount": [{"denom": "udevcore", "amount": "1"}]
+			}}
+		]
+	}`))
+	require.NoError(t, err)
+
+	genesisConfig, err := cored.AddGenesisFixture(context.Background(), cored.GenesisInitConfig{
+		ChainID:       constant.ChainIDDev,
+		Denom:         constant.DenomDev,
+		AddressPrefix: constant.AddressPrefixDev,
+		BankBalances: []banktypes.Balance{
+			{Address: cored.FaucetAddress, Coins: sdk.NewCoins(sdk.NewInt64Coin(constant.DenomDev, 10))},
+		},
+	}, fixture, map[string]string{"DEX funding account": cored.FundingMnemonic})
+	require.NoError(t, err)
+
+	require.Len(t, genesisConfig.BankBalances, 2)
+	assert.Equal(t, cored.FaucetAddress, genesisConfig.BankBalances[0].Address)
+	assert.Equal(t, "7uatom,15udevcore", genesisConfig.BankBalances[0].Coins.String())
+	assert.Equal(t, issuer, genesisConfig.BankBalances[1].Address)
+	assert.Equal(t, "1000udevcore", genesisConfig.BankBalances[1].Coins.String())
+
+	// Messages of each account are signed in single transaction, issuer appears first.
+	require.Len(t, genesisConfig.GenTxs, 2)
+	var tx struct {
+		Body struct {
+			Messages []struct {
+				Type string `json:"@type"`
+			} `json:"messages"`
+		} `json:"body"`
+	}
+	require.NoError(t, json.Unmarshal(genesisConfig.GenTxs[0], &tx))
+	assert.Len(t, tx.Body.Messages, 2)
+	assert.Equal(t, "/coreum.asset.ft.v1.MsgIssue", tx.Body.Messages[0].Type)
+	assert.Equal(t, "/cosmos.bank.v1beta1.MsgSend", tx.Body.Messages[1].Type)
+
+	// Each account may sign single genesis transaction.
+	_, err = cored.AddGenesisFixture(context.Background(), cored.GenesisInitConfig{
+		ChainID:       constant.ChainIDDev,
+		AddressPrefix: constant.AddressPrefixDev,
+	}, fixture, map[string]string{"staker 0": cored.BobMnemonic})
+	require.ErrorContains(t, err, `account "sender" signs other genesis transactions as staker 0`)
+
+	fixture.Accounts["other"] = cored.AliceMnemonic
+	fixture.Messages = append(fixture.Messages, cored.FixtureMessage{
+		Signer: "other",
+		Message: json.RawMessage(`{"@type": "/cosmos.bank.v1beta1.MsgSend", "from_address": "` + issuer + `", ` +
+			`"to_address": "` + sender + `", "amount": [{"denom": "udevcore", "amount": "1"}]}`),
+	})
+	_, err = cored.AddGenesisFixture(context.Background(), cored.GenesisInitConfig{
+		ChainID:       constant.ChainIDDev,
+		AddressPrefix: constant.AddressPrefixDev,
+	}, fixture, nil)
+	require.ErrorContains(t, err, `account "other" signs other genesis transactions as account "issuer"`)
+
+	// Message must be signed by the account declared as its signer.
+	fixture, err = cored.ParseGenesisFixture([]byte(`{
+		"accounts": {"issuer": "` + cored.AliceMnemonic + `", "sender": "` + cored.BobMnemonic + `"},
+		"messages": [
+			{"signer": "sender", "message": {
+				"@type": "/cosmos.bank.v1beta1.MsgSend",
+				"from_address": "` + issuer + `",
+				"to_address": "` + sender + `",
+				"amount": [{"denom": "udevcore", "amount": "1"}]
+			}}
+		]
+	}`))
+	require.NoError(t, err)
+	_, err = cored.AddGenesisFixture(context.Background(), cored.GenesisInitConfig{
+		ChainID:       constant.ChainIDDev,
+		AddressPrefix: constant.AddressPrefixDev,
+	}, fixture, nil)
+	require.ErrorContains(t, err,
+		`message /cosmos.bank.v1beta1.MsgSend must be signed by `+issuer+`, not by account "sender"`)
+}
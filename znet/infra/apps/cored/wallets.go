@@ -1,8 +1,16 @@
 package cored
 
 import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+
+	sdkmath "cosmossdk.io/math"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/cosmos/go-bip39"
 	"github.com/pkg/errors"
 
 	"github.com/CoreumFoundation/coreum-tools/pkg/must"
@@ -11,8 +19,14 @@ import (
 const (
 	desiredTotalSupply int64 = 500_000_000_000_000 // 500m core.
 
+	// 10m core delegated by the staker when validator is created in genesis.
+	validatorStake int64 = 10_000_000_000_000
+
 	// 10m core for staking + 100k core for integration test.
-	stakerBalance int64 = 10_000_000_000_000 + 100_000_000_000
+	stakerBalance = validatorStake + 100_000_000_000
+
+	// DefaultStakerSeed is the seed used to generate mnemonics of the stakers exceeding the predefined ones.
+	DefaultStakerSeed = "znet"
 )
 
 // mnemonics generating well-known keys to create predictable wallets so manual operation is easier.
@@ -34,6 +48,10 @@ const (
 	FundingAddress = "devcore1fnrehr95flfgnzjcatv7a8hpernwufpd5zjm2v"
 	// FundingMnemonic is mnemonic of used by integration testing framework to fund accounts required by integration tests.
 	FundingMnemonic = "sad hobby filter tray ordinary gap half web cat hard call mystery describe member round trend friend beyond such clap frozen segment fan mistake"
+	// DEXTakerAddress is the address of the account placing DEX orders crossing the order books in genesis.
+	DEXTakerAddress = "devcore149d9plta8fylza5x9g9hfszhckqkn5fz49tetn"
+	// DEXTakerMnemonic is mnemonic of the account placing DEX orders crossing the order books in genesis.
+	DEXTakerMnemonic = "process radar bracket cat exhaust flag field absent arrow begin damage float slot leisure trouble ship symbol main purpose six quick destroy crew sight"
 	// RelayerMnemonic is mnemonic used by the relayer.
 	RelayerMnemonic = "notable rate tribe effort deny void security page regular spice safe prize engage version hour bless normal mother exercise velvet load cry front ordinary"
 )
@@ -93,22 +111,37 @@ type Wallet struct {
 	namedMnemonics        []string
 }
 
-// NewFundedWallet creates wallet and funds all predefined accounts.
-func NewFundedWallet(genesisConfig GenesisInitConfig) (*Wallet, GenesisInitConfig) {
-	// distribute the remaining after stakers amount among Alice, Bob, Faucet, etc
-	namedMnemonicsBalance :=
-		(desiredTotalSupply - stakerBalance*int64(len(stakerMnemonics))) / int64(len(namedMnemonicsList))
-
+// NewFundedWallet creates wallet and funds all predefined accounts. If there are more validators than predefined
+// stakers, mnemonics of the missing stakers are generated deterministically from the seed. Total supply is kept
+// at the desired one, so error is returned if there are too many validators to fund the remaining accounts.
+func NewFundedWallet(genesisConfig GenesisInitConfig, validatorCount int, stakerSeed string) (
+	*Wallet, GenesisInitConfig, error,
+) {
 	w := &Wallet{
 		// We have integration tests adding new validators with min self delegation,
 		// and then we kill them when test completes.
 		// So if those tests run together and create validators having 33% of voting power,
 		// then killing them will halt the chain.
 		// That's why our main validators created here must have much higher stake.
-		stakerBalance:         stakerBalance,
-		namedMnemonicsBalance: namedMnemonicsBalance,
-		stakerMnemonics:       stakerMnemonics,
-		namedMnemonics:        namedMnemonicsList,
+		stakerBalance:   stakerBalance,
+		stakerMnemonics: slices.Clone(stakerMnemonics),
+		namedMnemonics:  namedMnemonicsList,
+	}
+	if stakerSeed == "" {
+		stakerSeed = DefaultStakerSeed
+	}
+	for i := len(stakerMnemonics); i < validatorCount; i++ {
+		w.stakerMnemonics = append(w.stakerMnemonics, GenerateStakerMnemonic(stakerSeed, i))
+	}
+
+	// distribute the remaining after stakers amount among Alice, Bob, Faucet, etc
+	w.namedMnemonicsBalance =
+		(desiredTotalSupply - w.stakerBalance*int64(len(w.stakerMnemonics))) / int64(len(w.namedMnemonics))
+	if w.namedMnemonicsBalance <= 0 {
+		return nil, GenesisInitConfig{}, errors.Errorf(
+			"total supply is not sufficient to fund %d stakers, decrease the number of validators",
+			len(w.stakerMnemonics),
+		)
 	}
 
 	for _, mnemonic := range w.namedMnemonics {
@@ -131,7 +164,94 @@ func NewFundedWallet(genesisConfig GenesisInitConfig) (*Wallet, GenesisInitConfi
 		})
 	}
 
-	return w, genesisConfig
+	return w, genesisConfig, nil
+}
+
+// DelegateStakes delegates additional stake to the validators, so the stake of each validator is its weight
+// multiplied by the stake delegated when validator is created. Validators without weight get weight 1.
+// Additional stake is deducted from the balances of named accounts, so total supply doesn't change.
+func (w Wallet) DelegateStakes(
+	ctx context.Context,
+	genesisConfig GenesisInitConfig,
+	weights []int,
+) (GenesisInitConfig, error) {
+	// Balances are cloned, so the genesis config passed by the caller is not modified when error is returned.
+	genesisConfig.BankBalances = slices.Clone(genesisConfig.BankBalances)
+
+	extraStake := sdkmath.ZeroInt()
+	for i, weight := range weights {
+		if weight <= 1 {
+			continue
+		}
+		if i >= len(w.stakerMnemonics) {
+			return GenesisInitConfig{}, errors.Errorf("there is no staker for validator %d", i)
+		}
+
+		privKey, err := PrivateKeyFromMnemonic(w.stakerMnemonics[i])
+		if err != nil {
+			return GenesisInitConfig{}, errors.Wrapf(err, "invalid mnemonic of staker %d", i)
+		}
+		addressBytes := privKey.PubKey().Address()
+		address := must.String(sdk.Bech32ifyAddressBytes(genesisConfig.AddressPrefix, addressBytes))
+		valAddress := must.String(sdk.Bech32ifyAddressBytes(genesisConfig.AddressPrefix+"valoper", addressBytes))
+		amount := sdk.NewCoin(genesisConfig.Denom, sdkmath.NewInt(validatorStake).MulRaw(int64(weight-1)))
+		genesisConfig.BankBalances = addBalance(genesisConfig.BankBalances, banktypes.Balance{
+			Address: address,
+			Coins:   sdk.NewCoins(amount),
+		})
+		extraStake = extraStake.Add(amount.Amount)
+
+		// Staker's first transaction creates the validator, so the delegation is signed with the next sequence.
+		txData, err := signTxsWithMnemonic(ctx, string(genesisConfig.ChainID), w.stakerMnemonics[i], 1,
+			&stakingtypes.MsgDelegate{
+				DelegatorAddress: address,
+				ValidatorAddress: valAddress,
+				Amount:           amount,
+			})
+		if err != nil {
+			return GenesisInitConfig{}, errors.Wrapf(err, "signing delegation of staker %d failed", i)
+		}
+		genesisConfig.GenTxs = append(genesisConfig.GenTxs, txData)
+	}
+
+	if extraStake.IsZero() {
+		return genesisConfig, nil
+	}
+	return w.deductNamedBalances(genesisConfig, extraStake)
+}
+
+// deductNamedBalances deducts the amount evenly from the balances of named accounts, the first one pays
+// the remainder of the division.
+func (w Wallet) deductNamedBalances(genesisConfig GenesisInitConfig, amount sdkmath.Int) (GenesisInitConfig, error) {
+	share := amount.QuoRaw(int64(len(w.namedMnemonics)))
+	remainder := amount.Sub(share.MulRaw(int64(len(w.namedMnemonics))))
+	for i, mnemonic := range w.namedMnemonics {
+		privKey, err := PrivateKeyFromMnemonic(mnemonic)
+		must.OK(err)
+		// Address is encoded the same way as in NewFundedWallet, so the balance funded there is found.
+		address := sdk.AccAddress(privKey.PubKey().Address()).String()
+
+		deducted := sdk.NewCoin(genesisConfig.Denom, share)
+		if i == 0 {
+			deducted.Amount = deducted.Amount.Add(remainder)
+		}
+		index := slices.IndexFunc(genesisConfig.BankBalances, func(balance banktypes.Balance) bool {
+			return balance.Address == address
+		})
+		if index < 0 || !genesisConfig.BankBalances[index].Coins.AmountOf(genesisConfig.Denom).GT(deducted.Amount) {
+			return GenesisInitConfig{}, errors.Errorf(
+				"total supply is not sufficient to fund stakes of %s, decrease weights of validators", amount,
+			)
+		}
+		genesisConfig.BankBalances[index].Coins = genesisConfig.BankBalances[index].Coins.Sub(deducted)
+	}
+	return genesisConfig, nil
+}
+
+// GenerateStakerMnemonic generates the mnemonic of the staker deterministically from the seed and staker's index.
+func GenerateStakerMnemonic(seed string, index int) string {
+	entropy := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", seed, index)))
+	return must.String(bip39.NewMnemonic(entropy[:]))
 }
 
 // GetStakersMnemonicsCount returns length of stakerMnemonics.
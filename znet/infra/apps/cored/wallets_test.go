@@ -0,0 +1,99 @@
+package cored_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/must"
+	"github.com/CoreumFoundation/coreum/v6/pkg/config/constant"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/cored"
+)
+
+const desiredTotalSupply = 500_000_000_000_000
+
+func TestNewFundedWallet(t *testing.T) {
+	sdk.GetConfig().SetBech32PrefixForAccount(constant.AddressPrefixDev, constant.AddressPrefixDev+"pub")
+	sdk.GetConfig().SetCoinType(constant.CoinType)
+
+	genesisConfig := cored.GenesisInitConfig{
+		ChainID:       constant.ChainIDDev,
+		Denom:         constant.DenomDev,
+		AddressPrefix: constant.AddressPrefixDev,
+	}
+
+	// totalSupply returns the sum of all the balances funded in genesis.
+	totalSupply := func(genesisConfig cored.GenesisInitConfig) sdkmath.Int {
+		total := sdkmath.ZeroInt()
+		for _, balance := range genesisConfig.BankBalances {
+			total = total.Add(balance.Coins.AmountOf(constant.DenomDev))
+		}
+		return total
+	}
+
+	wallet, result, err := cored.NewFundedWallet(genesisConfig, 3, "")
+	require.NoError(t, err)
+	assert.Equal(t, 32, wallet.GetStakersMnemonicsCount())
+	// Remainder of the division among named accounts is not funded.
+	assert.InDelta(t, desiredTotalSupply, totalSupply(result).Int64(), 5)
+
+	_, _, err = cored.NewFundedWallet(genesisConfig, 50, "")
+	require.ErrorContains(t, err, "total supply is not sufficient to fund 50 stakers")
+
+	wallet, result, err = cored.NewFundedWallet(genesisConfig, 40, "")
+	require.NoError(t, err)
+	require.Equal(t, 40, wallet.GetStakersMnemonicsCount())
+	assert.Equal(t, cored.GenerateStakerMnemonic(cored.DefaultStakerSeed, 39), wallet.GetStakersMnemonic(39))
+	assert.NotEqual(t, cored.GenerateStakerMnemonic("other", 39), wallet.GetStakersMnemonic(39))
+	assert.Len(t, result.BankBalances, 46)
+	assert.Equal(t, sdkmath.NewInt(desiredTotalSupply), totalSupply(result))
+
+	t.Run("delegate_stakes", func(t *testing.T) {
+		result, err := wallet.DelegateStakes(context.Background(), result, []int{1, 3})
+		require.NoError(t, err)
+		require.Len(t, result.GenTxs, 1)
+		// Additional stake is funded by named accounts.
+		assert.Equal(t, sdkmath.NewInt(desiredTotalSupply), totalSupply(result))
+
+		privKey, err := cored.PrivateKeyFromMnemonic(wallet.GetStakersMnemonic(1))
+		require.NoError(t, err)
+		address := must.String(sdk.Bech32ifyAddressBytes(constant.AddressPrefixDev, privKey.PubKey().Address()))
+		for _, balance := range result.BankBalances {
+			if balance.Address == address {
+				assert.Equal(t, "30100000000000udevcore", balance.Coins.String())
+			}
+		}
+
+		var tx struct {
+			Body struct {
+				Messages []struct {
+					Type             string `json:"@type"`
+					DelegatorAddress string `json:"delegator_address"`
+					Amount           struct {
+						Amount string `json:"amount"`
+					} `json:"amount"`
+				} `json:"messages"`
+			} `json:"body"`
+			AuthInfo struct {
+				SignerInfos []struct {
+					Sequence string `json:"sequence"`
+				} `json:"signer_infos"`
+			} `json:"auth_info"`
+		}
+		require.NoError(t, json.Unmarshal(result.GenTxs[0], &tx))
+		require.Len(t, tx.Body.Messages, 1)
+		assert.Equal(t, "/cosmos.staking.v1beta1.MsgDelegate", tx.Body.Messages[0].Type)
+		assert.Equal(t, address, tx.Body.Messages[0].DelegatorAddress)
+		assert.Equal(t, "20000000000000", tx.Body.Messages[0].Amount.Amount)
+		require.Len(t, tx.AuthInfo.SignerInfos, 1)
+		assert.Equal(t, "1", tx.AuthInfo.SignerInfos[0].Sequence)
+
+		_, err = wallet.DelegateStakes(context.Background(), result, []int{1, 11})
+		require.ErrorContains(t, err, "total supply is not sufficient to fund stakes")
+	})
+}
@@ -84,7 +84,7 @@ func (f Faucet) HealthCheck(ctx context.Context) error {
 
 	statusURL := url.URL{
 		Scheme: "http",
-		Host:   infra.JoinNetAddr("", f.Info().HostFromHost, f.config.Port),
+		Host:   infra.JoinNetAddr("", f.Info().HostFromHost, f.Info().HostPort(f.config.Port)),
 		Path:   "/api/faucet/v1/status",
 	}
 	req := must.HTTPRequest(http.NewRequestWithContext(ctx, http.MethodGet, statusURL.String(), nil))
@@ -105,6 +105,7 @@ func (f Faucet) Deployment() infra.Deployment {
 	return infra.Deployment{
 		RunAsUser: true,
 		Image:     "faucet:znet",
+		Binary:    "faucet",
 		Name:      f.Name(),
 		Info:      f.config.AppInfo,
 		Volumes: []infra.Volume{
@@ -113,6 +114,7 @@ func (f Faucet) Deployment() infra.Deployment {
 				Destination: targets.AppHomeDir,
 			},
 		},
+		RestartPolicy: infra.RestartOnFailure,
 		ArgsFunc: func() []string {
 			return []string{
 				"--address", infra.JoinNetAddrIP("", net.IPv4zero, f.config.Port),
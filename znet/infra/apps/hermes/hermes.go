@@ -99,7 +99,7 @@ func (h Hermes) HealthCheck(ctx context.Context) error {
 
 	statusURL := url.URL{
 		Scheme: "http",
-		Host:   infra.JoinNetAddr("", h.Info().HostFromHost, h.config.TelemetryPort),
+		Host:   infra.JoinNetAddr("", h.Info().HostFromHost, h.Info().HostPort(h.config.TelemetryPort)),
 		Path:   "/metrics",
 	}
 	req := must.HTTPRequest(http.NewRequestWithContext(ctx, http.MethodGet, statusURL.String(), nil))
@@ -275,8 +275,6 @@ func (h Hermes) saveRunScriptFile() error {
 	}
 
 	scriptArgs := struct {
-		HomePath string
-
 		CoreumChanID          string
 		CoreumRelayerMnemonic string
 		CoreumRPCURL          string
@@ -284,8 +282,6 @@ func (h Hermes) saveRunScriptFile() error {
 
 		Peers []peersConfig
 	}{
-		HomePath: targets.AppHomeDir,
-
 		CoreumChanID:          string(h.config.Cored.Config().GenesisInitConfig.ChainID),
 		CoreumRelayerMnemonic: h.config.CoreumRelayerMnemonic,
 		CoreumRPCURL: infra.JoinNetAddr(
@@ -81,7 +81,7 @@ func (p Postgres) HealthCheck(ctx context.Context) error {
 	connStr := "postgres://" +
 		User +
 		"@" +
-		infra.JoinNetAddr("", p.config.AppInfo.Info().HostFromHost, p.config.Port) +
+		infra.JoinNetAddr("", p.Info().HostFromHost, p.Info().HostPort(p.config.Port)) +
 		"/" +
 		DB
 	db, err := pgx.Connect(ctx, connStr)
@@ -132,5 +132,6 @@ func (p Postgres) Deployment() infra.Deployment {
 		Ports: map[string]int{
 			"sql": p.config.Port,
 		},
+		HealthCheckCmd: []string{"pg_isready", "-h", "127.0.0.1", "-p", strconv.Itoa(p.config.Port), "-U", User},
 	}
 }
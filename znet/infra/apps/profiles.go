@@ -112,18 +112,13 @@ func MergeProfiles(pMap map[string]bool) map[string]bool {
 	return pMap
 }
 
-// BuildAppSet builds the application set to deploy based on provided profiles.
-//
-//nolint:funlen
-func BuildAppSet(ctx context.Context, appF *Factory, profiles []string, coredVersion string) (
-	infra.AppSet, cored.Cored, error,
-) {
+// PresetDefinition returns the environment definition corresponding to the profiles.
+func PresetDefinition(profiles []string) infra.EnvDefinition {
 	pMap := lo.SliceToMap(profiles, func(profile string) (string, bool) {
 		return profile, true
 	})
 
-	if pMap[ProfileIBC] || pMap[ProfileFaucet] || pMap[ProfileXRPLBridge] ||
-		pMap[ProfileExplorer] || pMap[ProfileMonitoring] {
+	if !pMap[Profile1Cored] && !pMap[Profile3Cored] && !pMap[Profile5Cored] && !pMap[ProfileDevNet] {
 		pMap[Profile1Cored] = true
 	}
 
@@ -135,20 +130,58 @@ func BuildAppSet(ctx context.Context, appF *Factory, profiles []string, coredVer
 
 	validatorCount, sentryCount, seedCount, fullCount := decideNumOfCoredNodes(pMap)
 
-	var coredApp cored.Cored
-	var appSet infra.AppSet
+	definition := infra.EnvDefinition{
+		Cored: infra.CoredDefinition{
+			Validators: validatorCount,
+			Sentries:   sentryCount,
+			Seeds:      seedCount,
+			FullNodes:  fullCount,
+			DEX:        pMap[ProfileDEX],
+		},
+		Faucet:     pMap[ProfileFaucet],
+		Explorer:   pMap[ProfileExplorer],
+		Monitoring: pMap[ProfileMonitoring],
+		XRPL:       pMap[ProfileXRPL],
+		Extensions: lo.Filter(lo.Uniq(profiles), func(p string, _ int) bool {
+			return !isBuiltinProfile(p)
+		}),
+	}
+	if pMap[ProfileIBC] {
+		definition.IBC = &infra.IBCDefinition{
+			Chains: []string{infra.IBCChainGaia, infra.IBCChainOsmosis},
+		}
+	}
+	if pMap[ProfileXRPLBridge] {
+		definition.BridgeXRPL = &infra.BridgeXRPLDefinition{
+			Relayers: 3,
+		}
+	}
+	return definition
+}
 
-	var genDEX bool
-	if pMap[ProfileDEX] {
-		genDEX = true
+// BuildAppSet builds the application set to deploy based on provided environment definition.
+//
+//nolint:funlen
+func BuildAppSet(ctx context.Context, appF *Factory, definition infra.EnvDefinition, coredVersion string) (
+	infra.AppSet, cored.Cored, error,
+) {
+	if err := definition.Validate(); err != nil {
+		return nil, cored.Cored{}, err
+	}
+	registry, err := NewRegistry(appF.config.Extensions)
+	if err != nil {
+		return nil, cored.Cored{}, err
 	}
 
+	var coredApp cored.Cored
+	var appSet infra.AppSet
+
 	coredApp, coredNodes, err := appF.CoredNetwork(
 		ctx,
 		AppPrefixCored,
 		cored.DefaultPorts,
-		validatorCount, sentryCount, seedCount, fullCount,
-		coredVersion, genDEX,
+		definition.Cored,
+		coredVersion,
 	)
 	if err != nil {
 		return nil, cored.Cored{}, err
@@ -157,20 +190,44 @@ func BuildAppSet(ctx context.Context, appF *Factory, profiles []string, coredVer
 		appSet = append(appSet, coredNode)
 	}
 
-	if pMap[ProfileIBC] {
-		appSet = append(appSet, appF.IBC(AppPrefixIBC, coredApp)...)
+	if definition.IBC != nil {
+		appSet = append(appSet, appF.IBC(AppPrefixIBC, coredApp, definition.IBC.Chains)...)
 	}
 
 	var faucetApp faucet.Faucet
-	if pMap[ProfileFaucet] {
+	if definition.Faucet {
 		appSet = append(appSet, appF.Faucet(string(faucet.AppType), coredApp))
 	}
 
-	if pMap[ProfileExplorer] {
+	if definition.Explorer {
 		appSet = append(appSet, appF.BlockExplorer(AppPrefixExplorer, coredApp).ToAppSet()...)
 	}
 
-	if pMap[ProfileMonitoring] {
+	var xrplApp xrpl.XRPL
+	if definition.XRPL {
+		xrplApp = appF.XRPL(AppPrefixXRPL)
+		appSet = append(appSet, xrplApp)
+	}
+
+	if definition.BridgeXRPL != nil {
+		relayers, err := appF.BridgeXRPLRelayers(
+			AppPrefixBridgeXRPL,
+			coredApp,
+			xrplApp,
+			definition.BridgeXRPL.Relayers,
+		)
+		if err != nil {
+			return nil, cored.Cored{}, err
+		}
+		appSet = append(appSet, relayers...)
+	}
+
+	appSet, err = registry.build(ctx, appF, definition, appSet)
+	if err != nil {
+		return nil, cored.Cored{}, err
+	}
+
+	if definition.Monitoring {
 		var callistoApp callisto.Callisto
 		if callistoAppSetApp, ok := appSet.FindAppByName(
 			BuildPrefixedAppName(AppPrefixExplorer, string(callisto.AppType)),
@@ -197,26 +254,18 @@ func BuildAppSet(ctx context.Context, appF *Factory, profiles []string, coredVer
 			faucetApp,
 			callistoApp,
 			hermesApps,
+			lo.FilterMap(appSet, func(app infra.App, _ int) (infra.MetricsCapable, bool) {
+				metricsApp, ok := app.(infra.MetricsCapable)
+				return metricsApp, ok
+			}),
 		)...)
 	}
 
-	var xrplApp xrpl.XRPL
-	if pMap[ProfileXRPL] {
-		xrplApp = appF.XRPL(AppPrefixXRPL)
-		appSet = append(appSet, xrplApp)
-	}
-
-	if pMap[ProfileXRPLBridge] {
-		relayers, err := appF.BridgeXRPLRelayers(
-			AppPrefixBridgeXRPL,
-			coredApp,
-			xrplApp,
-			3,
-		)
-		if err != nil {
-			return nil, cored.Cored{}, err
+	for appName := range definition.Apps {
+		if appSet.FindAppByName(appName) == nil {
+			return nil, cored.Cored{}, errors.Errorf("override defined for app %s which is not a part of environment",
+				appName)
 		}
-		appSet = append(appSet, relayers...)
 	}
 
 	return appSet, coredApp, nil
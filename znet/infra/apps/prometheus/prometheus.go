@@ -54,6 +54,7 @@ type Config struct {
 	Faucet     faucet.Faucet
 	Callisto   callisto.Callisto
 	HermesApps []hermes.Hermes
+	ExtraApps  []infra.MetricsCapable
 }
 
 // New creates new prometheus app.
@@ -71,7 +72,7 @@ func (p Prometheus) HealthCheck(ctx context.Context) error {
 
 	statusURL := url.URL{
 		Scheme: "http",
-		Host:   infra.JoinNetAddr("", p.Info().HostFromHost, p.config.Port),
+		Host:   infra.JoinNetAddr("", p.Info().HostFromHost, p.Info().HostPort(p.config.Port)),
 		Path:   "/status",
 	}
 	req := must.HTTPRequest(http.NewRequestWithContext(ctx, http.MethodGet, statusURL.String(), nil))
@@ -174,6 +175,12 @@ func (p Prometheus) saveConfigFile(_ context.Context) error {
 		Port int
 	}
 
+	type appConfig struct {
+		Host string
+		Port int
+		Name string
+	}
+
 	if err := os.MkdirAll(filepath.Join(p.config.HomeDir, "data"), 0o700); err != nil {
 		return errors.WithStack(err)
 	}
@@ -192,6 +199,7 @@ func (p Prometheus) saveConfigFile(_ context.Context) error {
 		Faucet     hostPortConfig
 		Callisto   hostPortConfig
 		HermesApps []hostPortConfig
+		ExtraApps  []appConfig
 	}{
 		Nodes: nodesConfig,
 	}
@@ -219,6 +227,14 @@ func (p Prometheus) saveConfigFile(_ context.Context) error {
 		})
 	}
 
+	for _, app := range p.config.ExtraApps {
+		configArgs.ExtraApps = append(configArgs.ExtraApps, appConfig{
+			Host: app.Info().HostFromContainer,
+			Port: app.MetricsPort(),
+			Name: app.Name(),
+		})
+	}
+
 	buf := &bytes.Buffer{}
 	if err := configTemplate.Execute(buf, configArgs); err != nil {
 		return errors.WithStack(err)
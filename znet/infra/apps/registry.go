@@ -0,0 +1,90 @@
+package apps
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"github.com/CoreumFoundation/crust/znet/infra"
+)
+
+// Registry stores extensions adding custom applications to the environment.
+type Registry struct {
+	extensions map[string]infra.Extension
+	profiles   []string
+}
+
+// NewRegistry creates new registry of extensions.
+func NewRegistry(extensions []infra.Extension) (*Registry, error) {
+	r := &Registry{
+		extensions: map[string]infra.Extension{},
+		profiles:   make([]string, 0, len(extensions)),
+	}
+	for _, ext := range extensions {
+		switch {
+		case ext.Profile == "":
+			return nil, errors.New("extension profile must not be empty")
+		case ext.BuildFunc == nil:
+			return nil, errors.Errorf("build function of extension %s is not set", ext.Profile)
+		case isBuiltinProfile(ext.Profile):
+			return nil, errors.Errorf("extension %s collides with predefined profile", ext.Profile)
+		}
+		if _, exists := r.extensions[ext.Profile]; exists {
+			return nil, errors.Errorf("extension %s registered twice", ext.Profile)
+		}
+		r.extensions[ext.Profile] = ext
+		r.profiles = append(r.profiles, ext.Profile)
+	}
+	return r, nil
+}
+
+// Profiles returns the list of profiles provided by extensions.
+func (r *Registry) Profiles() []string {
+	return r.profiles
+}
+
+// Extension returns the extension enabled by profile.
+func (r *Registry) Extension(profile string) (infra.Extension, bool) {
+	ext, exists := r.extensions[profile]
+	return ext, exists
+}
+
+// ValidateProfiles verifies that profile set is correct, profiles provided by extensions are accepted.
+func (r *Registry) ValidateProfiles(profiles []string) error {
+	return ValidateProfiles(lo.Filter(profiles, func(p string, _ int) bool {
+		_, exists := r.extensions[p]
+		return !exists
+	}))
+}
+
+// build builds applications of enabled extensions.
+func (r *Registry) build(
+	ctx context.Context,
+	appF *Factory,
+	definition infra.EnvDefinition,
+	appSet infra.AppSet,
+) (infra.AppSet, error) {
+	if len(lo.Uniq(definition.Extensions)) != len(definition.Extensions) {
+		return nil, errors.New("extensions must be unique")
+	}
+	for _, profile := range definition.Extensions {
+		ext, exists := r.extensions[profile]
+		if !exists {
+			return nil, errors.Errorf("extension %s is not registered, available extensions: %v", profile,
+				r.profiles)
+		}
+
+		var err error
+		appSet, err = ext.BuildFunc(ctx, appF.config, appF.spec, appSet)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building apps of extension %s failed", profile)
+		}
+	}
+	return appSet, nil
+}
+
+func isBuiltinProfile(profile string) bool {
+	_, exists := availableProfiles[profile]
+	return exists
+}
@@ -0,0 +1,38 @@
+package apps
+
+import (
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/bigdipper"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/bridgexrpl"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/callisto"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/cored"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/faucet"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/gaiad"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/grafana"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/hasura"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/hermes"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/osmosis"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/postgres"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/prometheus"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/xrpl"
+)
+
+// DefaultResources returns default resource limits of applications indexed by app type.
+// Limits are chosen so a single misbehaving app can't take over the whole machine, not to reserve resources.
+func DefaultResources() map[infra.AppType]infra.Resources {
+	return map[infra.AppType]infra.Resources{
+		cored.AppType:      {CPUs: 2, Memory: "2g", Pids: 1024},
+		gaiad.AppType:      {CPUs: 1, Memory: "1g", Pids: 512},
+		osmosis.AppType:    {CPUs: 1, Memory: "1g", Pids: 512},
+		hermes.AppType:     {CPUs: 1, Memory: "512m", Pids: 256},
+		faucet.AppType:     {CPUs: 0.5, Memory: "256m", Pids: 256},
+		postgres.AppType:   {CPUs: 1, Memory: "1g", Pids: 512},
+		callisto.AppType:   {CPUs: 1, Memory: "1g", Pids: 512},
+		hasura.AppType:     {CPUs: 1, Memory: "512m", Pids: 256},
+		bigdipper.AppType:  {CPUs: 0.5, Memory: "512m", Pids: 256},
+		prometheus.AppType: {CPUs: 0.5, Memory: "512m", Pids: 256},
+		grafana.AppType:    {CPUs: 0.5, Memory: "512m", Pids: 256},
+		xrpl.AppType:       {CPUs: 2, Memory: "2g", Pids: 512},
+		bridgexrpl.AppType: {CPUs: 0.5, Memory: "256m", Pids: 256},
+	}
+}
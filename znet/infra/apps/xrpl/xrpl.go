@@ -99,7 +99,7 @@ func (x XRPL) HealthCheck(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	rpcURL := infra.JoinNetAddr("http", x.Info().HostFromHost, x.config.RPCPort)
+	rpcURL := infra.JoinNetAddr("http", x.Info().HostFromHost, x.Info().HostPort(x.config.RPCPort))
 	statusBody := `{"method":"server_info","params":[{"api_version": 1}]}`
 	req := must.HTTPRequest(
 		http.NewRequestWithContext(ctx, http.MethodPost, rpcURL, strings.NewReader(statusBody)),
@@ -1,6 +1,9 @@
 package infra
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Config stores configuration.
 type Config struct {
@@ -10,12 +13,18 @@ type Config struct {
 	// Profiles defines the list of application profiles to run
 	Profiles []string
 
+	// Definition describes the topology of the environment
+	Definition EnvDefinition
+
 	// TimeoutCommit allows to define custom timeout commit for all used chains.
 	TimeoutCommit time.Duration
 
 	// CoredVersion defines the version of the cored to be used on start
 	CoredVersion string
 
+	// Resources defines resource limits of applications, indexed by app type
+	Resources map[AppType]Resources
+
 	// HomeDir is the path where all the files are kept
 	HomeDir string
 
@@ -28,6 +37,15 @@ type Config struct {
 	// WrapperDir is the path where wrappers are stored
 	WrapperDir string
 
+	// Offline disables pulling of docker images, deployment fails if any of them is missing
+	Offline bool
+
+	// ImageLockFile is the path of the file pinning digests of docker images, images are not verified if it is empty
+	ImageLockFile string
+
+	// AllowUnpinnedImages allows using docker images which are not pinned in the lock file or don't match it
+	AllowUnpinnedImages bool
+
 	// VerboseLogging turns on verbose logging
 	VerboseLogging bool
 
@@ -37,6 +55,27 @@ type Config struct {
 	// CoverageOutputFile is the output path for coverage data in text format
 	CoverageOutputFile string
 
+	// GenesisPatch is the JSON patch or JSON merge patch applied to genesis of cored network
+	GenesisPatch json.RawMessage
+
+	// GenesisFixture is the fixture declaring balances, assets and messages included in genesis of cored network
+	GenesisFixture json.RawMessage
+
 	// CoredUpgrades is the map of cored upgrades to binary names
 	CoredUpgrades map[string]string
+
+	// Extensions is the list of extensions adding custom applications
+	Extensions []Extension
+
+	// TestGroups is the list of integration test groups to run
+	TestGroups []string
+
+	// TestFilter is the regular expression used to select tests to run
+	TestFilter string
+
+	// TestTimeout is the maximum time a single test group may run
+	TestTimeout time.Duration
+
+	// TestBinariesDir is the directory containing prebuilt test binaries, named after test groups
+	TestBinariesDir string
 }
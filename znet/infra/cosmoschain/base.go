@@ -110,12 +110,13 @@ func (ba BaseApp) Info() infra.DeploymentInfo {
 
 // ClientContext creates new cored ClientContext.
 func (ba BaseApp) ClientContext() client.Context {
+	info := ba.Info()
 	rpcClient, err := cosmosclient.NewClientFromNode(
-		infra.JoinNetAddr("http", ba.Info().HostFromHost, ba.appConfig.Ports.RPC),
+		infra.JoinNetAddr("http", info.HostFromHost, info.HostPort(ba.appConfig.Ports.RPC)),
 	)
 	must.OK(err)
 
-	grpcClient, err := GRPCClient(infra.JoinNetAddr("", ba.Info().HostFromHost, ba.appConfig.Ports.GRPC))
+	grpcClient, err := GRPCClient(infra.JoinNetAddr("", info.HostFromHost, info.HostPort(ba.appConfig.Ports.GRPC)))
 	must.OK(err)
 
 	return client.NewContext(client.DefaultContextConfig(), moduleBasicList...).
@@ -146,7 +147,7 @@ func (ba BaseApp) Deployment() infra.Deployment {
 		PrepareFunc: ba.prepare,
 		Entrypoint:  filepath.Join(targets.AppHomeDir, dockerEntrypoint),
 		ConfigureFunc: func(ctx context.Context, deployment infra.DeploymentInfo) error {
-			return ba.saveClientWrapper(deployment.HostFromHost)
+			return ba.saveClientWrapper(deployment)
 		},
 	}
 }
@@ -154,7 +155,6 @@ func (ba BaseApp) Deployment() infra.Deployment {
 func (ba BaseApp) prepare(_ context.Context) error {
 	args := struct {
 		ExecName        string
-		HomePath        string
 		HomeName        string
 		ChainID         string
 		RelayerMnemonic string
@@ -167,7 +167,6 @@ func (ba BaseApp) prepare(_ context.Context) error {
 		RPCPprofLaddr   string
 	}{
 		ExecName:        ba.appTypeConfig.ExecName,
-		HomePath:        targets.AppHomeDir,
 		HomeName:        ba.appConfig.HomeName,
 		ChainID:         ba.appConfig.ChainID,
 		RelayerMnemonic: ba.appConfig.RelayerMnemonic,
@@ -219,7 +218,8 @@ var moduleBasicList = []module.AppModuleBasic{
 	staking.AppModuleBasic{},
 }
 
-func (ba BaseApp) saveClientWrapper(hostname string) error {
+func (ba BaseApp) saveClientWrapper(deployment infra.DeploymentInfo) error {
+	rpcAddr := infra.JoinNetAddr("tcp", deployment.HostFromHost, deployment.HostPort(ba.appConfig.Ports.RPC))
 	baClient := fmt.Sprintf(`#!/bin/bash
 OPTS=""
 if [ "$1" == "tx" ] || [ "$1" == "keys" ]; then
@@ -232,9 +232,9 @@ fi
 
 exec %s --home %s "$@" $OPTS
 `,
-		infra.JoinNetAddr("tcp", hostname, ba.appConfig.Ports.RPC),                                   // rpc endpoint
+		rpcAddr, // rpc endpoint
 		filepath.Join(tools.BinariesRootPath(tools.PlatformLocal), "bin", ba.appTypeConfig.ExecName), // client's path
-		filepath.Dir(ba.appConfig.HomeDir),                                                           // home dir
+		filepath.Dir(ba.appConfig.HomeDir), // home dir
 	)
 
 	return errors.WithStack(
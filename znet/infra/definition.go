@@ -0,0 +1,357 @@
+package infra
+
+import (
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"sigs.k8s.io/yaml"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/must"
+)
+
+// Chain names which might be connected to coreum over IBC.
+const (
+	IBCChainGaia    = "gaiad"
+	IBCChainOsmosis = "osmosis"
+)
+
+var ibcChains = []string{IBCChainGaia, IBCChainOsmosis}
+
+// EnvDefinition describes the topology of the environment.
+type EnvDefinition struct {
+	// Cored defines the network of cored nodes
+	Cored CoredDefinition `json:"cored"`
+
+	// IBC defines chains connected to coreum over IBC
+	IBC *IBCDefinition `json:"ibc,omitempty"`
+
+	// Faucet enables faucet
+	Faucet bool `json:"faucet,omitempty"`
+
+	// Explorer enables block explorer
+	Explorer bool `json:"explorer,omitempty"`
+
+	// Monitoring enables prometheus and grafana
+	Monitoring bool `json:"monitoring,omitempty"`
+
+	// XRPL enables XRPL node
+	XRPL bool `json:"xrpl,omitempty"`
+
+	// BridgeXRPL defines relayers of the XRPL bridge
+	BridgeXRPL *BridgeXRPLDefinition `json:"bridgeXRPL,omitempty"`
+
+	// Extensions is the list of enabled profiles provided by extensions
+	Extensions []string `json:"extensions,omitempty"`
+
+	// Apps contains per-app overrides of deployment parameters indexed by app name
+	Apps map[string]AppOverride `json:"apps,omitempty"`
+}
+
+// CoredDefinition defines the network of cored nodes.
+type CoredDefinition struct {
+	// Validators is the number of validator nodes
+	Validators int `json:"validators"`
+
+	// Sentries is the number of sentry nodes
+	Sentries int `json:"sentries,omitempty"`
+
+	// Seeds is the number of seed nodes
+	Seeds int `json:"seeds,omitempty"`
+
+	// FullNodes is the number of full nodes
+	FullNodes int `json:"fullNodes,omitempty"`
+
+	// StakeWeights defines the stake of each validator as the multiple of the default stake. Validators without weight
+	// get weight 1.
+	StakeWeights []int `json:"stakeWeights,omitempty"`
+
+	// StakerSeed is the seed used to generate mnemonics of the stakers exceeding the predefined ones
+	StakerSeed string `json:"stakerSeed,omitempty"`
+
+	// Versions overrides the version of cored binary used by the nodes, indexed by node name. Nodes not listed here
+	// use the version passed to --cored-version.
+	Versions map[string]string `json:"versions,omitempty"`
+
+	// DEX enables generation of DEX orders in genesis
+	DEX bool `json:"dex,omitempty"`
+
+	// DEXBooks defines the order books generated in genesis if DEX is enabled. If none is defined, single book
+	// containing sell orders is generated.
+	DEXBooks []DEXBookDefinition `json:"dexBooks,omitempty"`
+}
+
+// MaxCoredNodes is the maximum number of nodes in cored network. Ports of consecutive nodes are shifted by 100,
+// so having more nodes causes port collisions.
+const MaxCoredNodes = 200
+
+// Sides of orders generated in DEX order book.
+const (
+	DEXSideBuy  = "buy"
+	DEXSideSell = "sell"
+)
+
+// Time in force values of orders generated in DEX order book.
+const (
+	DEXTimeInForceGTC = "gtc"
+	DEXTimeInForceIOC = "ioc"
+	DEXTimeInForceFOK = "fok"
+)
+
+// DEXBookDefinition defines the DEX order book generated in genesis.
+type DEXBookDefinition struct {
+	// Base is the symbol of the token issued in genesis, used as the base denom of the book
+	Base string `json:"base"`
+
+	// Quote is the symbol of the token issued in genesis, used as the quote denom of the book,
+	// chain denom is used if it is empty
+	Quote string `json:"quote,omitempty"`
+
+	// Price is the mid-price of the book, 1 by default
+	Price string `json:"price,omitempty"`
+
+	// Spread is the distance between the best buy and the best sell price, relative to the mid-price, 0.01 by default,
+	// it must be positive if both sides are generated
+	Spread string `json:"spread,omitempty"`
+
+	// Step is the distance between subsequent price levels, relative to the mid-price, 0.001 by default
+	Step string `json:"step,omitempty"`
+
+	// Depth is the number of price levels on each side of the book, 10 by default
+	Depth int `json:"depth,omitempty"`
+
+	// OrdersPerLevel is the number of orders placed on each price level, 1 by default
+	OrdersPerLevel int `json:"ordersPerLevel,omitempty"`
+
+	// Side limits generated orders to buy or sell side, both sides are generated if it is empty
+	Side string `json:"side,omitempty"`
+
+	// MinQuantity is the minimum quantity of order, 100000 by default
+	MinQuantity int64 `json:"minQuantity,omitempty"`
+
+	// MaxQuantity is the maximum quantity of order, equal to MinQuantity by default
+	MaxQuantity int64 `json:"maxQuantity,omitempty"`
+
+	// TimeInForce is the list of time in force values randomly assigned to orders, gtc by default
+	TimeInForce []string `json:"timeInForce,omitempty"`
+
+	// Seed is the seed of the random generator used to generate quantities and time in force values
+	Seed int64 `json:"seed,omitempty"`
+}
+
+// IBCDefinition defines chains connected to coreum over IBC.
+type IBCDefinition struct {
+	// Chains is the list of chains to run, relayer is started for them automatically
+	Chains []string `json:"chains"`
+}
+
+// BridgeXRPLDefinition defines XRPL bridge.
+type BridgeXRPLDefinition struct {
+	// Relayers is the number of bridge relayers
+	Relayers int `json:"relayers"`
+}
+
+// AppOverride overrides deployment parameters of an application.
+type AppOverride struct {
+	// Image replaces the docker image of the application
+	Image string `json:"image,omitempty"`
+
+	// Args are appended to the arguments passed to the application
+	Args []string `json:"args,omitempty"`
+
+	// Env defines additional environment variables
+	Env map[string]string `json:"env,omitempty"`
+
+	// DockerArgs are appended to the arguments passed to docker when creating the container
+	DockerArgs []string `json:"dockerArgs,omitempty"`
+
+	// Resources replaces resource limits of the application
+	Resources *Resources `json:"resources,omitempty"`
+
+	// RestartPolicy replaces the restart policy of the application
+	RestartPolicy RestartPolicy `json:"restartPolicy,omitempty"`
+}
+
+// LoadEnvDefinition reads and validates environment definition stored in YAML or JSON file.
+func LoadEnvDefinition(path string) (EnvDefinition, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return EnvDefinition{}, errors.WithStack(err)
+	}
+
+	var def EnvDefinition
+	if err := yaml.UnmarshalStrict(raw, &def); err != nil {
+		return EnvDefinition{}, errors.Wrapf(err, "parsing environment definition %s failed", path)
+	}
+	if err := def.Validate(); err != nil {
+		return EnvDefinition{}, errors.Wrapf(err, "invalid environment definition %s", path)
+	}
+	return def, nil
+}
+
+// Validate verifies that the definition is correct.
+func (d EnvDefinition) Validate() error {
+	if d.Cored.Validators < 1 {
+		return errors.New("at least one cored validator is required")
+	}
+	if d.Cored.Sentries < 0 || d.Cored.Seeds < 0 || d.Cored.FullNodes < 0 {
+		return errors.New("number of cored nodes can't be negative")
+	}
+	if nodes := d.Cored.Validators + d.Cored.Sentries + d.Cored.Seeds + d.Cored.FullNodes; nodes > MaxCoredNodes {
+		return errors.Errorf("number of cored nodes %d exceeds the maximum of %d", nodes, MaxCoredNodes)
+	}
+	if len(d.Cored.StakeWeights) > d.Cored.Validators {
+		return errors.Errorf("%d stake weights are defined for %d validators", len(d.Cored.StakeWeights),
+			d.Cored.Validators)
+	}
+	for i, weight := range d.Cored.StakeWeights {
+		if weight < 1 {
+			return errors.Errorf("stake weight of validator %d must be positive", i)
+		}
+	}
+	for node, version := range d.Cored.Versions {
+		if version == "" {
+			return errors.Errorf("version of cored node %s is empty", node)
+		}
+	}
+	if len(d.Cored.DEXBooks) > 0 && !d.Cored.DEX {
+		return errors.New("DEX order books require dex to be enabled")
+	}
+	for i, book := range d.Cored.DEXBooks {
+		if err := book.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid DEX order book %d", i)
+		}
+	}
+
+	if d.IBC != nil {
+		if len(d.IBC.Chains) == 0 {
+			return errors.New("no IBC chains specified")
+		}
+		if len(lo.Uniq(d.IBC.Chains)) != len(d.IBC.Chains) {
+			return errors.New("IBC chains must be unique")
+		}
+		for _, chain := range d.IBC.Chains {
+			if !lo.Contains(ibcChains, chain) {
+				return errors.Errorf("IBC chain %s is not supported, available chains: %v", chain, ibcChains)
+			}
+		}
+	}
+
+	for appName, override := range d.Apps {
+		if override.Resources != nil {
+			if err := override.Resources.Validate(); err != nil {
+				return errors.Wrapf(err, "invalid resources of app %s", appName)
+			}
+		}
+		if override.RestartPolicy != "" {
+			if err := override.RestartPolicy.Validate(); err != nil {
+				return errors.Wrapf(err, "invalid restart policy of app %s", appName)
+			}
+		}
+	}
+
+	if d.BridgeXRPL != nil {
+		if d.BridgeXRPL.Relayers < 1 {
+			return errors.New("at least one XRPL bridge relayer is required")
+		}
+		if !d.XRPL {
+			return errors.New("XRPL bridge requires xrpl to be enabled")
+		}
+	}
+
+	return nil
+}
+
+// Validate verifies that the order book definition is correct.
+func (b DEXBookDefinition) Validate() error {
+	if b.Base == "" {
+		return errors.New("base token is required")
+	}
+	if strings.EqualFold(b.Base, b.Quote) {
+		return errors.New("base and quote tokens must be different")
+	}
+	if b.Price != "" {
+		if price, ok := new(big.Rat).SetString(b.Price); !ok || price.Sign() <= 0 {
+			return errors.Errorf("invalid price %q", b.Price)
+		}
+	}
+	if b.Spread != "" {
+		spread, ok := new(big.Rat).SetString(b.Spread)
+		if !ok || spread.Sign() < 0 {
+			return errors.Errorf("invalid spread %q", b.Spread)
+		}
+		// Orders placed on both sides at the same price would be matched instead of resting in the book.
+		if spread.Sign() == 0 && b.Side == "" {
+			return errors.New("spread must be positive if both sides are generated")
+		}
+	}
+	if b.Step != "" {
+		if step, ok := new(big.Rat).SetString(b.Step); !ok || step.Sign() < 0 {
+			return errors.Errorf("invalid step %q", b.Step)
+		}
+	}
+	if b.Depth < 0 || b.OrdersPerLevel < 0 {
+		return errors.New("depth and number of orders per level can't be negative")
+	}
+	if b.Side != "" && b.Side != DEXSideBuy && b.Side != DEXSideSell {
+		return errors.Errorf("invalid side %q, must be either %s or %s", b.Side, DEXSideBuy, DEXSideSell)
+	}
+	if b.MinQuantity < 0 || (b.MaxQuantity != 0 && b.MaxQuantity < b.MinQuantity) {
+		return errors.New("invalid quantity range")
+	}
+	timeInForceValues := []string{DEXTimeInForceGTC, DEXTimeInForceIOC, DEXTimeInForceFOK}
+	for _, timeInForce := range b.TimeInForce {
+		if !lo.Contains(timeInForceValues, timeInForce) {
+			return errors.Errorf("invalid time in force %q, available values: %v", timeInForce, timeInForceValues)
+		}
+	}
+	return nil
+}
+
+// String converts definition to YAML string.
+func (d EnvDefinition) String() string {
+	return string(must.Bytes(yaml.Marshal(d)))
+}
+
+// Apply applies the override to the deployment.
+func (o AppOverride) Apply(deployment Deployment) Deployment {
+	if o.Image != "" {
+		deployment.Image = o.Image
+	}
+	if len(o.Args) > 0 {
+		argsFunc := deployment.ArgsFunc
+		deployment.ArgsFunc = func() []string {
+			var args []string
+			if argsFunc != nil {
+				args = argsFunc()
+			}
+			return append(args, o.Args...)
+		}
+	}
+	if len(o.Env) > 0 {
+		envVarsFunc := deployment.EnvVarsFunc
+		deployment.EnvVarsFunc = func() []EnvVar {
+			var envVars []EnvVar
+			if envVarsFunc != nil {
+				envVars = envVarsFunc()
+			}
+			names := lo.Keys(o.Env)
+			sort.Strings(names)
+			for _, name := range names {
+				envVars = append(envVars, EnvVar{Name: name, Value: o.Env[name]})
+			}
+			return envVars
+		}
+	}
+	deployment.DockerArgs = append(append([]string{}, deployment.DockerArgs...), o.DockerArgs...)
+	if o.Resources != nil {
+		deployment.Resources = *o.Resources
+	}
+	if o.RestartPolicy != "" {
+		deployment.RestartPolicy = o.RestartPolicy
+	}
+	return deployment
+}
@@ -0,0 +1,75 @@
+package infra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDEXBookDefinitionValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		book        DEXBookDefinition
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			book: DEXBookDefinition{Base: "AAA", Quote: "BBB", Price: "2", Spread: "0.1", Step: "0.01"},
+		},
+		{
+			name:        "missing_base",
+			book:        DEXBookDefinition{Quote: "BBB"},
+			expectedErr: "base token is required",
+		},
+		{
+			name:        "same_base_and_quote",
+			book:        DEXBookDefinition{Base: "AAA", Quote: "AAA"},
+			expectedErr: "base and quote tokens must be different",
+		},
+		{
+			name:        "same_base_and_quote_different_case",
+			book:        DEXBookDefinition{Base: "aaa", Quote: "AAA"},
+			expectedErr: "base and quote tokens must be different",
+		},
+		{
+			name:        "negative_spread",
+			book:        DEXBookDefinition{Base: "AAA", Spread: "-0.1"},
+			expectedErr: "invalid spread",
+		},
+		{
+			name:        "zero_spread",
+			book:        DEXBookDefinition{Base: "AAA", Spread: "0"},
+			expectedErr: "spread must be positive",
+		},
+		{
+			name: "zero_spread_one_side",
+			book: DEXBookDefinition{Base: "AAA", Spread: "0", Side: DEXSideSell},
+		},
+		{
+			name:        "invalid_side",
+			book:        DEXBookDefinition{Base: "AAA", Side: "both"},
+			expectedErr: "invalid side",
+		},
+		{
+			name:        "invalid_quantity_range",
+			book:        DEXBookDefinition{Base: "AAA", MinQuantity: 10, MaxQuantity: 5},
+			expectedErr: "invalid quantity range",
+		},
+		{
+			name:        "invalid_time_in_force",
+			book:        DEXBookDefinition{Base: "AAA", TimeInForce: []string{"gtd"}},
+			expectedErr: "invalid time in force",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.book.Validate()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package infra
+
+import (
+	"context"
+	"time"
+)
+
+// EventType is the type of deployment event.
+type EventType string
+
+// Types of deployment events.
+const (
+	// EventImagePullStarted is reported when pulling of the image starts.
+	EventImagePullStarted EventType = "imagePullStarted"
+
+	// EventImagePullFinished is reported when pulling of the image finishes, error is set if it failed.
+	EventImagePullFinished EventType = "imagePullFinished"
+
+	// EventWaitingForDependencies is reported when app starts waiting for its dependencies to be deployed.
+	EventWaitingForDependencies EventType = "waitingForDependencies"
+
+	// EventSlotAcquired is reported when app acquires the slot and its deployment starts.
+	EventSlotAcquired EventType = "slotAcquired"
+
+	// EventContainerStarted is reported when container or process of the app is started.
+	EventContainerStarted EventType = "containerStarted"
+
+	// EventDeploymentSucceeded is reported when app is deployed.
+	EventDeploymentSucceeded EventType = "deploymentSucceeded"
+
+	// EventDeploymentFailed is reported when deployment of the app fails.
+	EventDeploymentFailed EventType = "deploymentFailed"
+
+	// EventHealthCheckAttempt is reported before each health check of the app.
+	EventHealthCheckAttempt EventType = "healthCheckAttempt"
+
+	// EventHealthCheckFailed is reported when health check of the app fails.
+	EventHealthCheckFailed EventType = "healthCheckFailed"
+
+	// EventHealthCheckPassed is reported when app becomes healthy.
+	EventHealthCheckPassed EventType = "healthCheckPassed"
+)
+
+// Event describes the state transition of the deployment.
+type Event struct {
+	// Time is the time when event happened
+	Time time.Time `json:"time"`
+
+	// Type is the type of event
+	Type EventType `json:"type"`
+
+	// AppName is the name of the app event is related to
+	AppName string `json:"appName,omitempty"`
+
+	// Image is the docker image event is related to
+	Image string `json:"image,omitempty"`
+
+	// Dependencies are the names of apps the app waits for
+	Dependencies []string `json:"dependencies,omitempty"`
+
+	// Attempt is the number of the health check attempt
+	Attempt int `json:"attempt,omitempty"`
+
+	// Error is the reason of the failure
+	Error string `json:"error,omitempty"`
+}
+
+// EventHandler handles deployment events. It must be safe for concurrent use.
+type EventHandler func(event Event)
+
+type eventHandlerKey struct{}
+
+// WithEventHandler returns context passing deployment events to the handler, in addition to the handlers
+// already added to the context.
+func WithEventHandler(ctx context.Context, handler EventHandler) context.Context {
+	if parent := eventHandler(ctx); parent != nil {
+		next := handler
+		handler = func(event Event) {
+			parent(event)
+			next(event)
+		}
+	}
+	return context.WithValue(ctx, eventHandlerKey{}, handler)
+}
+
+// ReportEvent passes the event to the handlers added to the context.
+func ReportEvent(ctx context.Context, event Event) {
+	handler := eventHandler(ctx)
+	if handler == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	handler(event)
+}
+
+func eventHandler(ctx context.Context) EventHandler {
+	handler, _ := ctx.Value(eventHandlerKey{}).(EventHandler)
+	return handler
+}
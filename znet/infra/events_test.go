@@ -0,0 +1,86 @@
+package infra_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/infratest"
+)
+
+func TestWithEventHandler(t *testing.T) {
+	var first, second []infra.EventType
+	ctx := infra.WithEventHandler(context.Background(), func(event infra.Event) {
+		first = append(first, event.Type)
+	})
+	ctx = infra.WithEventHandler(ctx, func(event infra.Event) {
+		assert.False(t, event.Time.IsZero())
+		second = append(second, event.Type)
+	})
+
+	infra.ReportEvent(ctx, infra.Event{Type: infra.EventSlotAcquired})
+	infra.ReportEvent(context.Background(), infra.Event{Type: infra.EventContainerStarted})
+
+	assert.Equal(t, []infra.EventType{infra.EventSlotAcquired}, first)
+	assert.Equal(t, []infra.EventType{infra.EventSlotAcquired}, second)
+}
+
+func TestAppSetDeployReportsEvents(t *testing.T) {
+	errFailure := errors.New("failure")
+
+	ctx, config, spec := newTestEnv(t)
+	target := infratest.NewTarget(config, spec).Fail(infratest.MethodDeployContainer, "web", errFailure)
+
+	var mu sync.Mutex
+	var events []infra.Event
+	ctx = infra.WithEventHandler(ctx, func(event infra.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		events = append(events, event)
+	})
+
+	db := infratest.NewApp(spec, "db", "image")
+	api := infratest.NewApp(spec, "api", "image", db)
+	web := infratest.NewApp(spec, "web", "image", api)
+	require.ErrorIs(t, target.Deploy(ctx, infra.AppSet{db, api, web}), errFailure)
+
+	types := func(subject string) []infra.EventType {
+		return lo.FilterMap(events, func(event infra.Event, _ int) (infra.EventType, bool) {
+			return event.Type, event.AppName == subject || event.Image == subject
+		})
+	}
+	assert.Equal(t, []infra.EventType{
+		infra.EventImagePullStarted,
+		infra.EventImagePullFinished,
+	}, types("image"))
+
+	// Health of api is checked by web before it is deployed.
+	assert.Equal(t, []infra.EventType{
+		infra.EventWaitingForDependencies,
+		infra.EventSlotAcquired,
+		infra.EventContainerStarted,
+		infra.EventDeploymentSucceeded,
+		infra.EventHealthCheckAttempt,
+		infra.EventHealthCheckPassed,
+	}, types("api"))
+
+	waiting, ok := lo.Find(events, func(event infra.Event) bool {
+		return event.Type == infra.EventWaitingForDependencies && event.AppName == "web"
+	})
+	require.True(t, ok)
+	assert.Equal(t, []string{"api"}, waiting.Dependencies)
+
+	failed, ok := lo.Find(events, func(event infra.Event) bool {
+		return event.Type == infra.EventDeploymentFailed
+	})
+	require.True(t, ok)
+	assert.Equal(t, "web", failed.AppName)
+	assert.Equal(t, "failure", failed.Error)
+}
@@ -34,15 +34,45 @@ func WaitUntilHealthy(ctx context.Context, apps ...HealthCheckCapable) error {
 		//nolint:fatcontext // we are ok with this context
 		ctx = logger.With(ctx, zap.String("app", app.Name()))
 		log.Info(fmt.Sprintf("Waiting for %s start.", app.Name()))
+		var attempt int
 		if err := retry.Do(ctx, time.Second, func() error {
-			return app.HealthCheck(ctx)
+			attempt++
+			ReportEvent(ctx, Event{Type: EventHealthCheckAttempt, AppName: app.Name(), Attempt: attempt})
+			if err := app.HealthCheck(ctx); err != nil {
+				ReportEvent(ctx, Event{
+					Type:    EventHealthCheckFailed,
+					AppName: app.Name(),
+					Attempt: attempt,
+					Error:   err.Error(),
+				})
+				return err
+			}
+			return nil
 		}); err != nil {
 			return err
 		}
+		ReportEvent(ctx, Event{Type: EventHealthCheckPassed, AppName: app.Name(), Attempt: attempt})
 	}
 	return nil
 }
 
+// appSetHealthTimeout is the time deployed app set has to become healthy.
+const appSetHealthTimeout = 5 * time.Minute
+
+// WaitUntilAppSetHealthy waits until all the apps from the deployed app set are healthy.
+func WaitUntilAppSetHealthy(ctx context.Context, appSet AppSet) error {
+	log := logger.Get(ctx)
+	log.Info("Waiting until all applications start.")
+	waitCtx, waitCancel := context.WithTimeout(ctx, appSetHealthTimeout)
+	defer waitCancel()
+
+	if err := WaitUntilHealthy(waitCtx, BuildWaitForApps(appSet)...); err != nil {
+		return err
+	}
+	log.Info("All applications are healthy.")
+	return nil
+}
+
 // AppWithInfo represents application which is able to return information about its deployment.
 type AppWithInfo interface {
 	// Name returns name of app
@@ -127,6 +157,14 @@ func CheckCosmosNodeHealth(ctx context.Context, clientCtx client.Context, appInf
 	return nil
 }
 
+// MetricsCapable is implemented by apps exposing prometheus metrics.
+type MetricsCapable interface {
+	App
+
+	// MetricsPort returns the port metrics are exposed on
+	MetricsPort() int
+}
+
 // BuildWaitForApps builds a list of HealthCheckCapable objects from the given AppSet.
 func BuildWaitForApps(appSet AppSet) []HealthCheckCapable {
 	waitForApps := make([]HealthCheckCapable, 0, len(appSet))
@@ -0,0 +1,167 @@
+package infra
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+)
+
+// ImageLockFile is the name of the file pinning digests of docker images, stored in the root directory of crust.
+const ImageLockFile = "znet-images.lock"
+
+// localImageTags are the tags of images built locally. Those images are never pulled, so they are not pinned.
+var localImageTags = []string{"znet", "local"}
+
+// ImageLock maps references of docker images to their digests.
+type ImageLock map[string]string
+
+// LoadImageLock reads the lock file. Each line of the file contains image reference and its digest separated
+// by space, lines starting with # are ignored.
+func LoadImageLock(path string) (ImageLock, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	lock := ImageLock{}
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.Contains(fields[1], ":") {
+			return nil, errors.Errorf("invalid line %d of image lock file %s, expected <image> <digest>", lineNum, path)
+		}
+		if _, exists := lock[fields[0]]; exists {
+			return nil, errors.Errorf("image %s is pinned twice in image lock file %s", fields[0], path)
+		}
+		lock[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return lock, nil
+}
+
+// Save writes the lock file.
+func (l ImageLock) Save(path string) error {
+	images := lo.Keys(l)
+	sort.Strings(images)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("# Digests of docker images used by znet, generated by `znet images lock`.\n")
+	for _, image := range images {
+		buf.WriteString(image + " " + l[image] + "\n")
+	}
+	return errors.WithStack(os.WriteFile(path, buf.Bytes(), 0o600))
+}
+
+// PinnedImage returns the reference of the image pinned to the digest, in the form <image>@<digest>. If image
+// is not pinned in the lock file, it is returned unchanged.
+func (l ImageLock) PinnedImage(image string) string {
+	if digest, exists := l[image]; exists {
+		return image + "@" + digest
+	}
+	return image
+}
+
+// ImageRepository returns the reference of the image without tag and digest.
+func ImageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	// Colon before the last slash separates registry host and port.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
+
+// IsLocalImage returns true if image is built locally, so it is not pulled and pinned.
+func IsLocalImage(image string) bool {
+	// Colon before the last slash separates registry host and port.
+	i := strings.LastIndex(image, ":")
+	if i < strings.LastIndex(image, "/") {
+		return false
+	}
+	return lo.Contains(localImageTags, image[i+1:])
+}
+
+// loadImageLock reads the lock file defined by config. Nil is returned if images are not verified by the target
+// or if the file does not exist and unpinned images are allowed.
+func loadImageLock(ctx context.Context, config Config) (ImageLock, error) {
+	if config.ImageLockFile == "" {
+		return nil, nil
+	}
+	lock, err := LoadImageLock(config.ImageLockFile)
+	if !errors.Is(err, os.ErrNotExist) {
+		return lock, err
+	}
+	if !config.AllowUnpinnedImages {
+		return nil, errors.Errorf("docker images are not pinned because lock file %s does not exist, "+
+			"create it using `znet images lock` or use --allow-unpinned-images", config.ImageLockFile)
+	}
+	logger.Get(ctx).Warn("Docker images are not pinned, create the lock file using `znet images lock`",
+		zap.String("path", config.ImageLockFile))
+	return nil, nil
+}
+
+// checkPinned verifies that all the images pulled from registries are pinned in the lock file.
+func (l ImageLock) checkPinned(ctx context.Context, config Config, images []string) error {
+	unpinned := lo.Filter(images, func(image string, _ int) bool {
+		_, exists := l[image]
+		return !exists && !IsLocalImage(image)
+	})
+	if len(unpinned) == 0 {
+		return nil
+	}
+	if config.AllowUnpinnedImages {
+		logger.Get(ctx).Warn("Docker images are not pinned", zap.Strings("images", unpinned))
+		return nil
+	}
+	return errors.Errorf("docker images %s are not pinned in %s, pin them using `znet images lock` "+
+		"or use --allow-unpinned-images", strings.Join(unpinned, ", "), config.ImageLockFile)
+}
+
+// verify verifies that digests of the images match the ones pinned in the lock file. Image of unknown digest
+// can't be verified, so it is treated as mismatching.
+func (l ImageLock) verify(ctx context.Context, t AppTarget, config Config, images []string) error {
+	var mismatches []string
+	for _, image := range images {
+		pinned, exists := l[image]
+		if !exists {
+			continue
+		}
+		digests, err := t.ImageDigests(ctx, image)
+		if err != nil {
+			return err
+		}
+		if len(digests) == 0 {
+			mismatches = append(mismatches, image+" (pinned "+pinned+", digest unknown)")
+			continue
+		}
+		if !lo.Contains(digests, pinned) {
+			mismatches = append(mismatches, image+" (pinned "+pinned+", found "+strings.Join(digests, ", ")+")")
+		}
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	if config.AllowUnpinnedImages {
+		logger.Get(ctx).Warn("Digests of docker images don't match the pinned ones", zap.Strings("images", mismatches))
+		return nil
+	}
+	return errors.Errorf("digests of docker images don't match the ones pinned in %s: %s, "+
+		"update the lock file using `znet images lock` or use --allow-unpinned-images",
+		config.ImageLockFile, strings.Join(mismatches, "; "))
+}
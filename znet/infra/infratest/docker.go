@@ -0,0 +1,374 @@
+package infratest
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"github.com/CoreumFoundation/crust/znet/infra/targets"
+	"github.com/CoreumFoundation/crust/znet/pkg/dockerapi"
+)
+
+var _ targets.DockerEngine = &DockerEngine{}
+
+// NewDockerEngine creates new in-memory docker engine.
+func NewDockerEngine() *DockerEngine {
+	return &DockerEngine{
+		containers:  map[string]targets.DockerContainer{},
+		networks:    map[string]bool{},
+		images:      map[string]bool{},
+		repoDigests: map[string][]string{},
+		failures:    map[string][]error{},
+		delays:      map[string]time.Duration{},
+	}
+}
+
+// DockerEngine is the in-memory docker engine recording executed commands. Commands are recorded in the form
+// resembling docker CLI, e.g. "run test-app" or "network create test".
+type DockerEngine struct {
+	mu          sync.Mutex
+	commands    []string
+	containers  map[string]targets.DockerContainer
+	networks    map[string]bool
+	images      map[string]bool
+	repoDigests map[string][]string
+	failures    map[string][]error
+	delays      map[string]time.Duration
+	events      []dockerapi.Event
+}
+
+// WithContainer adds container of the app in the environment.
+func (e *DockerEngine) WithContainer(envName, appName string, running bool) *DockerEngine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	name := envName + "-" + appName
+	e.containers[name] = targets.DockerContainer{
+		ID:      name,
+		Name:    name,
+		AppName: appName,
+		Running: running,
+		State:   lo.Ternary(running, "running", "exited"),
+	}
+	return e
+}
+
+// WithNetworks marks networks as existing.
+func (e *DockerEngine) WithNetworks(networks ...string) *DockerEngine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, network := range networks {
+		e.networks[network] = true
+	}
+	return e
+}
+
+// WithImages marks images as existing locally, so they are not pulled.
+func (e *DockerEngine) WithImages(images ...string) *DockerEngine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, image := range images {
+		e.images[image] = true
+	}
+	return e
+}
+
+// WithRepoDigests sets repo digests reported for the image.
+func (e *DockerEngine) WithRepoDigests(image string, repoDigests ...string) *DockerEngine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.repoDigests[image] = repoDigests
+	return e
+}
+
+// WithEvents sets events reported by Events before it blocks until context is canceled.
+func (e *DockerEngine) WithEvents(events ...dockerapi.Event) *DockerEngine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.events = append(e.events, events...)
+	return e
+}
+
+// Fail causes subsequent executions of the command to return the errors, one per execution.
+func (e *DockerEngine) Fail(command string, errs ...error) *DockerEngine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.failures[command] = append(e.failures[command], errs...)
+	return e
+}
+
+// Delay causes the command to block for the duration before it returns.
+func (e *DockerEngine) Delay(command string, delay time.Duration) *DockerEngine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.delays[command] = delay
+	return e
+}
+
+// Executed returns all the commands executed so far, in the order they were executed.
+func (e *DockerEngine) Executed() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]string{}, e.commands...)
+}
+
+// ListContainers returns containers of the environment.
+func (e *DockerEngine) ListContainers(ctx context.Context, envName string) ([]targets.DockerContainer, error) {
+	if err := e.exec(ctx, "ps "+envName); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return lo.Values(e.containers), nil
+}
+
+// ContainerID returns ID of the container, empty string is returned if container does not exist.
+func (e *DockerEngine) ContainerID(ctx context.Context, name string) (string, error) {
+	if err := e.exec(ctx, "inspect "+name); err != nil {
+		return "", err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.containers[name].ID, nil
+}
+
+// RunContainer creates and starts the container, its ID is returned.
+func (e *DockerEngine) RunContainer(ctx context.Context, spec targets.DockerContainerSpec) (string, error) {
+	err := e.exec(ctx, "run "+spec.Name)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Like docker, container is created even if it can't be started.
+	e.containers[spec.Name] = targets.DockerContainer{
+		ID:      spec.Name,
+		Name:    spec.Name,
+		AppName: spec.Labels[targets.LabelApp],
+		Running: err == nil,
+		State:   lo.Ternary(err == nil, "running", "created"),
+	}
+	if err != nil {
+		return "", err
+	}
+	return spec.Name, nil
+}
+
+// StartContainer starts existing container.
+func (e *DockerEngine) StartContainer(ctx context.Context, id string) error {
+	return e.setRunning(ctx, "start", id, true)
+}
+
+// StopContainer stops running container.
+func (e *DockerEngine) StopContainer(ctx context.Context, id string) error {
+	return e.setRunning(ctx, "stop", id, false)
+}
+
+// RemoveContainer removes the container.
+func (e *DockerEngine) RemoveContainer(ctx context.Context, info targets.DockerContainer) error {
+	return e.remove(ctx, "rm "+info.ID, info.ID)
+}
+
+// ForceRemoveContainer removes the container even if it is running.
+func (e *DockerEngine) ForceRemoveContainer(ctx context.Context, name string) error {
+	return e.remove(ctx, "rm -f "+name, name)
+}
+
+// PublishedPorts returns no ports.
+func (e *DockerEngine) PublishedPorts(ctx context.Context, id string) (map[int]int, error) {
+	return map[int]int{}, e.exec(ctx, "port "+id)
+}
+
+// NetworkExists checks if network exists.
+func (e *DockerEngine) NetworkExists(ctx context.Context, network string) (bool, error) {
+	if err := e.exec(ctx, "network inspect "+network); err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.networks[network], nil
+}
+
+// CreateNetwork creates network.
+func (e *DockerEngine) CreateNetwork(ctx context.Context, network string) error {
+	if err := e.exec(ctx, "network create "+network); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.networks[network] = true
+	return nil
+}
+
+// RemoveNetwork removes network.
+func (e *DockerEngine) RemoveNetwork(ctx context.Context, network string) error {
+	if err := e.exec(ctx, "network rm "+network); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.networks, network)
+	return nil
+}
+
+// ImageExists checks if image exists locally.
+func (e *DockerEngine) ImageExists(ctx context.Context, image string) (bool, error) {
+	if err := e.exec(ctx, "images "+image); err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.images[image], nil
+}
+
+// PullImage marks image as existing locally.
+func (e *DockerEngine) PullImage(ctx context.Context, image string) error {
+	if err := e.exec(ctx, "pull "+image); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.images[image] = true
+	return nil
+}
+
+// TagImage marks the target reference of the image as existing locally.
+func (e *DockerEngine) TagImage(ctx context.Context, source, target string) error {
+	if err := e.exec(ctx, "tag "+source+" "+target); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.images[target] = true
+	return nil
+}
+
+// ImageDigests returns repo digests set for the image.
+func (e *DockerEngine) ImageDigests(ctx context.Context, image string) ([]string, error) {
+	if err := e.exec(ctx, "image inspect "+image); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.repoDigests[image], nil
+}
+
+// Stats returns empty stats.
+func (e *DockerEngine) Stats(ctx context.Context, id string) (targets.DockerContainerStats, error) {
+	return targets.DockerContainerStats{}, e.exec(ctx, "stats "+id)
+}
+
+// Logs returns without streaming anything.
+func (e *DockerEngine) Logs(ctx context.Context, id string, _, _ io.Writer) error {
+	return e.exec(ctx, "logs "+id)
+}
+
+// Events reports configured events and blocks until context is canceled.
+func (e *DockerEngine) Events(ctx context.Context, envName string, fn func(event dockerapi.Event) error) error {
+	if err := e.exec(ctx, "events "+envName); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	events := append([]dockerapi.Event{}, e.events...)
+	e.mu.Unlock()
+
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	<-ctx.Done()
+	return errors.WithStack(ctx.Err())
+}
+
+// SaveImages records saving of the images.
+func (e *DockerEngine) SaveImages(ctx context.Context, images []string, path string) error {
+	return e.exec(ctx, "save "+strings.Join(images, ","))
+}
+
+// LoadImages records loading of the images.
+func (e *DockerEngine) LoadImages(ctx context.Context, path string) error {
+	return e.exec(ctx, "load")
+}
+
+// exec records the command and simulates configured delay and failure.
+func (e *DockerEngine) exec(ctx context.Context, command string) error {
+	e.mu.Lock()
+	e.commands = append(e.commands, command)
+	delay := e.delays[command]
+	var err error
+	if errs := e.failures[command]; len(errs) > 0 {
+		err = errs[0]
+		e.failures[command] = errs[1:]
+	}
+	e.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+	return err
+}
+
+func (e *DockerEngine) setRunning(ctx context.Context, command, id string, running bool) error {
+	if err := e.exec(ctx, command+" "+id); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	info, exists := e.containers[id]
+	if !exists {
+		return errors.Errorf("container %s does not exist", id)
+	}
+	info.Running = running
+	info.State = lo.Ternary(running, "running", "exited")
+	e.containers[id] = info
+	return nil
+}
+
+func (e *DockerEngine) remove(ctx context.Context, command, id string) error {
+	if err := e.exec(ctx, command); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.containers, id)
+	return nil
+}
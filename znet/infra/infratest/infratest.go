@@ -0,0 +1,376 @@
+// Package infratest provides in-memory fakes of deployment targets and docker engine, so code deploying applications
+// may be tested without docker.
+package infratest
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/CoreumFoundation/crust/znet/infra"
+)
+
+// Methods of the target recorded by the fake.
+const (
+	MethodDeployContainer = "DeployContainer"
+	MethodImageExists     = "ImageExists"
+	MethodPullImage       = "PullImage"
+	MethodImageDigests    = "ImageDigests"
+	MethodStop            = "Stop"
+	MethodRemove          = "Remove"
+	MethodLogs            = "Logs"
+)
+
+// Call is the call recorded by the fake target.
+type Call struct {
+	// Method is the name of the called method
+	Method string
+
+	// Arg is the name of the app or image the method has been called for
+	Arg string
+}
+
+// NewTarget creates new fake target. Apps are deployed to it using the provided config and spec.
+func NewTarget(config infra.Config, spec *infra.Spec) *Target {
+	return &Target{
+		config:    config,
+		spec:      spec,
+		images:    map[string]bool{},
+		digests:   map[string][]string{},
+		failures:  map[Call]error{},
+		delays:    map[Call]time.Duration{},
+		states:    map[string]infra.RuntimeState{},
+		active:    map[string]int{},
+		maxActive: map[string]int{},
+		events:    make(chan infra.AppEvent, 100),
+	}
+}
+
+// Target is the fake target recording calls and simulating failures and delays.
+// It implements both infra.Target and infra.AppTarget.
+type Target struct {
+	config infra.Config
+	spec   *infra.Spec
+
+	mu        sync.Mutex
+	calls     []Call
+	images    map[string]bool
+	digests   map[string][]string
+	failures  map[Call]error
+	delays    map[Call]time.Duration
+	states    map[string]infra.RuntimeState
+	active    map[string]int
+	maxActive map[string]int
+
+	events chan infra.AppEvent
+}
+
+// WithImages marks images as existing in the target, so they are not pulled.
+func (t *Target) WithImages(images ...string) *Target {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, image := range images {
+		t.images[image] = true
+	}
+	return t
+}
+
+// WithDigests sets digests reported for the image.
+func (t *Target) WithDigests(image string, digests ...string) *Target {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.digests[image] = digests
+	return t
+}
+
+// Fail causes the method called for the app or image to return the error.
+func (t *Target) Fail(method, arg string, err error) *Target {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.failures[Call{Method: method, Arg: arg}] = err
+	return t
+}
+
+// Delay causes the method called for the app or image to block for the duration before it returns.
+func (t *Target) Delay(method, arg string, delay time.Duration) *Target {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.delays[Call{Method: method, Arg: arg}] = delay
+	return t
+}
+
+// Emit reports the event to the caller of Watch. State of the app is updated according to the action.
+func (t *Target) Emit(event infra.AppEvent) {
+	switch event.Action {
+	case infra.AppEventStart:
+		t.setState(event.AppName, "running")
+	case infra.AppEventDie:
+		t.setState(event.AppName, "exited")
+	}
+	t.events <- event
+}
+
+// Calls returns all the calls recorded so far, in the order they were made.
+func (t *Target) Calls() []Call {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]Call{}, t.calls...)
+}
+
+// CallArgs returns arguments of the recorded calls of the method, in the order they were made.
+func (t *Target) CallArgs(method string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var args []string
+	for _, call := range t.calls {
+		if call.Method == method {
+			args = append(args, call.Arg)
+		}
+	}
+	return args
+}
+
+// MaxConcurrency returns the maximum number of calls of the method executed at the same time.
+func (t *Target) MaxConcurrency(method string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.maxActive[method]
+}
+
+// Deploy deploys app set to the target.
+func (t *Target) Deploy(ctx context.Context, appSet infra.AppSet) error {
+	return appSet.Deploy(ctx, t, t.config, t.spec)
+}
+
+// Stop stops all the apps.
+func (t *Target) Stop(ctx context.Context) error {
+	return t.StopApps(ctx, t.deployedApps())
+}
+
+// StopApps stops selected apps.
+func (t *Target) StopApps(ctx context.Context, appNames []string) error {
+	for _, appName := range appNames {
+		if err := t.call(ctx, MethodStop, appName); err != nil {
+			return err
+		}
+		t.setState(appName, "exited")
+	}
+	return nil
+}
+
+// Remove removes all the apps.
+func (t *Target) Remove(ctx context.Context) error {
+	return t.RemoveApps(ctx, t.deployedApps())
+}
+
+// RemoveApps removes selected apps.
+func (t *Target) RemoveApps(ctx context.Context, appNames []string) error {
+	for _, appName := range appNames {
+		if err := t.call(ctx, MethodRemove, appName); err != nil {
+			return err
+		}
+
+		t.mu.Lock()
+		delete(t.states, appName)
+		t.mu.Unlock()
+	}
+	return nil
+}
+
+// Status returns runtime state of deployed apps.
+func (t *Target) Status(_ context.Context) (map[string]infra.RuntimeState, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	states := make(map[string]infra.RuntimeState, len(t.states))
+	for appName, state := range t.states {
+		states[appName] = state
+	}
+	return states, nil
+}
+
+// Logs returns immediately, fake apps don't produce any logs.
+func (t *Target) Logs(ctx context.Context, appName string, _, _ io.Writer) error {
+	if _, exists := t.spec.Apps[appName]; !exists {
+		return errors.Errorf("app %s does not exist in the environment", appName)
+	}
+	return t.call(ctx, MethodLogs, appName)
+}
+
+// Watch reports events passed to Emit until context is canceled.
+func (t *Target) Watch(ctx context.Context, fn func(event infra.AppEvent) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case event := <-t.events:
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// DeployContainer records deployment of the app.
+func (t *Target) DeployContainer(ctx context.Context, app infra.Deployment) (infra.DeploymentInfo, error) {
+	if err := t.call(ctx, MethodDeployContainer, app.Name); err != nil {
+		return infra.DeploymentInfo{}, err
+	}
+	t.setState(app.Name, "running")
+
+	return infra.DeploymentInfo{
+		Container:         t.config.EnvName + "-" + app.Name,
+		Status:            infra.AppStatusRunning,
+		HostFromHost:      "localhost",
+		HostFromContainer: t.config.EnvName + "-" + app.Name,
+		Ports:             app.Ports,
+	}, nil
+}
+
+// ImageExists checks if image has been marked as existing or pulled before.
+func (t *Target) ImageExists(ctx context.Context, image string) (bool, error) {
+	if err := t.call(ctx, MethodImageExists, image); err != nil {
+		return false, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.images[image], nil
+}
+
+// PullImage records pulling of the image. Image pinned to the digest is stored under the original reference.
+func (t *Target) PullImage(ctx context.Context, image string) error {
+	if err := t.call(ctx, MethodPullImage, image); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	image, _, _ = strings.Cut(image, "@")
+	t.images[image] = true
+	return nil
+}
+
+// ImageDigests returns digests set for the image.
+func (t *Target) ImageDigests(ctx context.Context, image string) ([]string, error) {
+	if err := t.call(ctx, MethodImageDigests, image); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.digests[image], nil
+}
+
+// call records the call and simulates configured delay and failure.
+func (t *Target) call(ctx context.Context, method, arg string) error {
+	key := Call{Method: method, Arg: arg}
+
+	t.mu.Lock()
+	t.calls = append(t.calls, key)
+	t.active[method]++
+	t.maxActive[method] = max(t.maxActive[method], t.active[method])
+	delay := t.delays[key]
+	err := t.failures[key]
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		t.active[method]--
+	}()
+
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+	return err
+}
+
+func (t *Target) setState(appName, state string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.states[appName] = infra.RuntimeState{State: state}
+}
+
+func (t *Target) deployedApps() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	appNames := make([]string, 0, len(t.states))
+	for appName := range t.states {
+		appNames = append(appNames, appName)
+	}
+	return appNames
+}
+
+// NewApp creates new fake app described in the spec. App depends on the provided apps.
+func NewApp(spec *infra.Spec, name, image string, dependencies ...infra.App) App {
+	app := App{
+		deployment: infra.Deployment{
+			Name:  name,
+			Info:  spec.DescribeApp(AppType, name),
+			Image: image,
+			Requires: infra.Prerequisites{
+				Timeout: 20 * time.Second,
+			},
+		},
+	}
+	for _, dep := range dependencies {
+		app.deployment.Requires.Dependencies = append(app.deployment.Requires.Dependencies, infra.IsRunning(dep))
+	}
+	return app
+}
+
+// WithRestartPolicy returns the copy of the app using the restart policy.
+func (a App) WithRestartPolicy(policy infra.RestartPolicy) App {
+	a.deployment.RestartPolicy = policy
+	return a
+}
+
+// AppType is the type of fake apps.
+const AppType infra.AppType = "fake"
+
+// App is the fake application.
+type App struct {
+	deployment infra.Deployment
+}
+
+// Type returns type of application.
+func (a App) Type() infra.AppType {
+	return AppType
+}
+
+// Name returns name of app.
+func (a App) Name() string {
+	return a.deployment.Name
+}
+
+// Info returns deployment info.
+func (a App) Info() infra.DeploymentInfo {
+	return a.deployment.Info.Info()
+}
+
+// Deployment returns deployment of the app.
+func (a App) Deployment() infra.Deployment {
+	return a.deployment
+}
@@ -0,0 +1,136 @@
+package infra
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Resources defines limits of the resources application may use. Zero value means no limit.
+type Resources struct {
+	// CPUs is the number of CPUs application may use, e.g. 0.5
+	CPUs float64 `json:"cpus,omitempty"`
+
+	// Memory is the maximum amount of memory in the format accepted by docker, e.g. 512m, 2g
+	Memory string `json:"memory,omitempty"`
+
+	// Pids is the maximum number of processes
+	Pids int64 `json:"pids,omitempty"`
+}
+
+// ParseResources parses resource limits in the format `cpus=<cpus>,memory=<memory>,pids=<pids>`.
+// Each limit is optional, `none` means no limits.
+func ParseResources(value string) (Resources, error) {
+	var resources Resources
+	if value == "" || value == "none" {
+		return resources, nil
+	}
+
+	for _, limit := range strings.Split(value, ",") {
+		name, limitValue, ok := strings.Cut(limit, "=")
+		if !ok {
+			return Resources{}, errors.Errorf("invalid resource limit %q, expected <name>=<value>", limit)
+		}
+
+		var err error
+		switch name {
+		case "cpus":
+			resources.CPUs, err = strconv.ParseFloat(limitValue, 64)
+		case "memory":
+			resources.Memory = limitValue
+		case "pids":
+			resources.Pids, err = strconv.ParseInt(limitValue, 10, 64)
+		default:
+			return Resources{}, errors.Errorf("unknown resource %s, available ones: cpus, memory, pids", name)
+		}
+		if err != nil {
+			return Resources{}, errors.Wrapf(err, "invalid value of resource limit %s", name)
+		}
+	}
+	return resources, resources.Validate()
+}
+
+// Validate verifies that limits are correct.
+func (r Resources) Validate() error {
+	if r.CPUs < 0 || r.Pids < 0 {
+		return errors.New("resource limits can't be negative")
+	}
+	if _, err := r.MemoryBytes(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MemoryBytes returns memory limit in bytes.
+func (r Resources) MemoryBytes() (int64, error) {
+	if r.Memory == "" {
+		return 0, nil
+	}
+	memory, err := ParseBytes(r.Memory)
+	if err != nil {
+		return 0, err
+	}
+	if memory < 0 {
+		return 0, errors.Errorf("invalid memory limit %s", r.Memory)
+	}
+	return memory, nil
+}
+
+// String returns limits in the format accepted by ParseResources.
+func (r Resources) String() string {
+	var limits []string
+	if r.CPUs > 0 {
+		limits = append(limits, "cpus="+strconv.FormatFloat(r.CPUs, 'f', -1, 64))
+	}
+	if r.Memory != "" {
+		limits = append(limits, "memory="+r.Memory)
+	}
+	if r.Pids > 0 {
+		limits = append(limits, "pids="+strconv.FormatInt(r.Pids, 10))
+	}
+	if len(limits) == 0 {
+		return "none"
+	}
+	return strings.Join(limits, ",")
+}
+
+// ResourceUsage reports resources used by running application together with its limits.
+type ResourceUsage struct {
+	// CPUPercent is the CPU usage, 100% means one fully used CPU
+	CPUPercent float64 `json:"cpuPercent"`
+
+	// CPULimit is the number of CPUs application may use, 0 means no limit
+	CPULimit float64 `json:"cpuLimit,omitempty"`
+
+	// Memory is the memory used by application, in bytes
+	Memory int64 `json:"memory"`
+
+	// MemoryLimit is the maximum memory application may use, in bytes
+	MemoryLimit int64 `json:"memoryLimit,omitempty"`
+
+	// Pids is the number of running processes
+	Pids int64 `json:"pids"`
+
+	// PidsLimit is the maximum number of processes, 0 means no limit
+	PidsLimit int64 `json:"pidsLimit,omitempty"`
+}
+
+// ParseBytes parses size in the format accepted by docker, e.g. 512m, 1g.
+func ParseBytes(value string) (int64, error) {
+	units := map[byte]int64{'b': 1, 'k': 1 << 10, 'm': 1 << 20, 'g': 1 << 30}
+
+	multiplier := int64(1)
+	number := strings.ToLower(value)
+	if number != "" {
+		if unit, exists := units[number[len(number)-1]]; exists {
+			multiplier = unit
+			number = number[:len(number)-1]
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid size %s", value)
+	}
+	return n * multiplier, nil
+}
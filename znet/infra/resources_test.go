@@ -0,0 +1,70 @@
+package infra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResources(t *testing.T) {
+	testCases := []struct {
+		name        string
+		value       string
+		expected    Resources
+		expectedErr bool
+	}{
+		{
+			name:     "all_limits",
+			value:    "cpus=1.5,memory=512m,pids=100",
+			expected: Resources{CPUs: 1.5, Memory: "512m", Pids: 100},
+		},
+		{
+			name:     "single_limit",
+			value:    "memory=2g",
+			expected: Resources{Memory: "2g"},
+		},
+		{
+			name:     "none",
+			value:    "none",
+			expected: Resources{},
+		},
+		{
+			name:        "unknown_resource",
+			value:       "disk=1g",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid_memory",
+			value:       "memory=lots",
+			expectedErr: true,
+		},
+		{
+			name:        "negative_cpus",
+			value:       "cpus=-1",
+			expectedErr: true,
+		},
+		{
+			name:        "missing_value",
+			value:       "cpus",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resources, err := ParseResources(tc.value)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, resources)
+
+			// String produces the value accepted by ParseResources.
+			parsed, err := ParseResources(resources.String())
+			require.NoError(t, err)
+			assert.Equal(t, resources, parsed)
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
+)
+
+const (
+	// maxRestarts is the number of restarts after which supervisor gives up restarting the crashing app.
+	maxRestarts = 5
+
+	// restartResetPeriod is the time app must run after the last restart before the counter of restarts is reset.
+	restartResetPeriod = 5 * time.Minute
+
+	// maxRestartBackoff is the maximum delay between subsequent restarts of the app.
+	maxRestartBackoff = time.Minute
+
+	// restartHealthTimeout is the time restarted app and its dependents have to become healthy.
+	restartHealthTimeout = 5 * time.Minute
+
+	// crashLogLines is the number of the last lines of logs stored together with the crash.
+	crashLogLines = 20
+
+	// crashLogsTimeout is the time after which collecting logs of the crashed app is abandoned.
+	crashLogsTimeout = 10 * time.Second
+)
+
+var errEnvironmentRemoved = errors.New("environment has been removed")
+
+// NewSupervisor creates supervisor of the apps deployed to the target.
+func NewSupervisor(config Config, spec *Spec, target Target, appSet AppSet) *Supervisor {
+	return &Supervisor{
+		config:   config,
+		spec:     spec,
+		target:   target,
+		appSet:   appSet,
+		killed:   map[string]bool{},
+		restarts: map[string]*restartState{},
+	}
+}
+
+// Supervisor detects crashes of the apps, records them in the spec and restarts apps according to their
+// restart policies.
+type Supervisor struct {
+	config Config
+	spec   *Spec
+	target Target
+	appSet AppSet
+
+	// mu protects the state below and serializes modifications of the spec
+	mu       sync.Mutex
+	killed   map[string]bool
+	restarts map[string]*restartState
+}
+
+type restartState struct {
+	count       int
+	lastRestart time.Time
+	restarting  bool
+}
+
+// Run supervises the apps until context is canceled or environment is removed.
+func (s *Supervisor) Run(ctx context.Context) error {
+	if _, ok := s.target.(AppTarget); !ok {
+		return errors.New("target does not support restarting applications")
+	}
+
+	log := logger.Get(ctx)
+	log.Info("Supervising applications")
+
+	err := parallel.Run(ctx, func(ctx context.Context, spawn parallel.SpawnFn) error {
+		spawn("watch", parallel.Fail, func(ctx context.Context) error {
+			return s.target.Watch(ctx, func(event AppEvent) error {
+				return s.handleEvent(ctx, spawn, event)
+			})
+		})
+		return nil
+	})
+	if errors.Is(err, errEnvironmentRemoved) {
+		log.Info("Environment has been removed, supervision finished")
+		return nil
+	}
+	return err
+}
+
+func (s *Supervisor) handleEvent(ctx context.Context, spawn parallel.SpawnFn, event AppEvent) error {
+	app := s.appSet.FindAppByName(event.AppName)
+	if app == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch event.Action {
+	case AppEventStart:
+		delete(s.killed, event.AppName)
+	case AppEventKill:
+		// Apps are killed when they are stopped on request, so the following exit is not a crash.
+		s.killed[event.AppName] = true
+	case AppEventDie:
+		if s.killed[event.AppName] {
+			delete(s.killed, event.AppName)
+			return nil
+		}
+		spawn("crash."+event.AppName, parallel.Continue, func(ctx context.Context) error {
+			return s.handleCrash(ctx, spawn, app, event)
+		})
+	}
+	return nil
+}
+
+func (s *Supervisor) handleCrash(ctx context.Context, spawn parallel.SpawnFn, app App, event AppEvent) error {
+	log := logger.Get(ctx).With(zap.String("appName", app.Name()), zap.Int("exitCode", event.ExitCode))
+
+	running, err := s.isRunning(app.Name())
+	if err != nil {
+		return err
+	}
+	if !running {
+		// App has been stopped by another znet process.
+		return nil
+	}
+
+	crash := Crash{
+		Time:     event.Time,
+		ExitCode: event.ExitCode,
+		Logs:     s.crashLogs(ctx, app.Name()),
+	}
+	log.Warn("Application crashed", zap.Strings("logs", crash.Logs))
+
+	policy := ConfiguredDeployment(app, s.config).RestartPolicy
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.spec.Apps[app.Name()].AddCrash(crash)
+	if err := s.spec.Save(); err != nil {
+		return err
+	}
+
+	if !policy.ShouldRestart(event.ExitCode) {
+		log.Info("Application is not restarted due to its restart policy", zap.String("policy", string(policy)))
+		return nil
+	}
+
+	state := s.restartState(app.Name())
+	if state.restarting {
+		return nil
+	}
+	state.restarting = true
+	spawn("restart."+app.Name(), parallel.Continue, func(ctx context.Context) error {
+		defer func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			state.restarting = false
+		}()
+
+		return s.restart(ctx, app)
+	})
+	return nil
+}
+
+// restart restarts the app until it succeeds or the limit of restarts is reached.
+func (s *Supervisor) restart(ctx context.Context, app App) error {
+	log := logger.Get(ctx).With(zap.String("appName", app.Name()))
+	for {
+		delay, ok := s.nextRestart(app.Name())
+		if !ok {
+			log.Error("Application keeps crashing, giving up restarting it", zap.Int("restarts", maxRestarts))
+			return nil
+		}
+		if delay > 0 {
+			log.Info("Waiting before restarting application", zap.Duration("delay", delay))
+			select {
+			case <-ctx.Done():
+				return errors.WithStack(ctx.Err())
+			case <-time.After(delay):
+			}
+		}
+
+		err := s.restartApp(ctx, app)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, errEnvironmentRemoved) || ctx.Err() != nil:
+			return err
+		}
+		log.Warn("Restarting application failed", zap.Error(err))
+	}
+}
+
+// nextRestart returns the delay before the next restart of the app, or false if app shouldn't be restarted anymore.
+func (s *Supervisor) nextRestart(appName string) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.restartState(appName)
+	if time.Since(state.lastRestart) > restartResetPeriod {
+		state.count = 0
+	}
+	if state.count >= maxRestarts {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if state.count > 0 {
+		delay = min(time.Second<<(state.count-1), maxRestartBackoff)
+	}
+	state.count++
+	state.lastRestart = time.Now().Add(delay)
+	return delay, true
+}
+
+func (s *Supervisor) restartApp(ctx context.Context, app App) error {
+	log := logger.Get(ctx).With(zap.String("appName", app.Name()))
+
+	running, err := s.isRunning(app.Name())
+	if err != nil {
+		return err
+	}
+	if !running {
+		log.Info("Application has been stopped, restart canceled")
+		return nil
+	}
+
+	// Deployment is marked as stopped, so the app is started again without being prepared and configured.
+	appInfo := s.spec.Apps[app.Name()]
+	info := appInfo.Info()
+	restartInfo := &AppInfo{}
+	restartInfo.SetInfo(DeploymentInfo{
+		Container: info.Container,
+		PID:       info.PID,
+		Status:    AppStatusStopped,
+	})
+	deployment := ConfiguredDeployment(app, s.config)
+	deployment.Info = restartInfo
+
+	log.Info("Restarting application")
+	newInfo, err := deployment.Deploy(ctx, s.target.(AppTarget), s.config)
+	if err != nil {
+		return err
+	}
+	newInfo.DependsOn = info.DependsOn
+
+	s.mu.Lock()
+	appInfo.SetInfo(newInfo)
+	err = s.spec.Save()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, restartHealthTimeout)
+	defer waitCancel()
+
+	if err := WaitUntilHealthy(waitCtx, BuildWaitForApps(AppSet{app})...); err != nil {
+		return errors.Wrap(err, "restarted application is not healthy")
+	}
+	log.Info("Application restarted")
+
+	var dependents AppSet
+	for _, appName := range s.spec.RunningDependents([]string{app.Name()}) {
+		if dependent := s.appSet.FindAppByName(appName); dependent != nil {
+			dependents = append(dependents, dependent)
+		}
+	}
+	if len(dependents) == 0 {
+		return nil
+	}
+	if err := WaitUntilHealthy(waitCtx, BuildWaitForApps(dependents)...); err != nil {
+		// Dependents are not restarted, because they might recover on their own and restart policy is defined
+		// for crashes only.
+		log.Warn("Applications depending on the restarted one are not healthy", zap.Error(err))
+		return nil
+	}
+	log.Info("Applications depending on the restarted one are healthy")
+	return nil
+}
+
+// isRunning reloads the spec and checks if the app is still expected to run.
+func (s *Supervisor) isRunning(appName string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.spec.Reload(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, errEnvironmentRemoved
+		}
+		return false, err
+	}
+	appInfo, exists := s.spec.Apps[appName]
+	return exists && appInfo.Info().Status == AppStatusRunning, nil
+}
+
+// crashLogs returns the last lines of logs produced by the app.
+func (s *Supervisor) crashLogs(ctx context.Context, appName string) []string {
+	ctx, cancel := context.WithTimeout(ctx, crashLogsTimeout)
+	defer cancel()
+
+	buf := &lineBuffer{size: crashLogLines}
+	if err := s.target.Logs(ctx, appName, buf, buf); err != nil && ctx.Err() == nil {
+		logger.Get(ctx).Warn("Collecting logs of crashed application failed", zap.String("appName", appName),
+			zap.Error(err))
+	}
+	return buf.Lines()
+}
+
+func (s *Supervisor) restartState(appName string) *restartState {
+	state, exists := s.restarts[appName]
+	if !exists {
+		state = &restartState{}
+		s.restarts[appName] = state
+	}
+	return state
+}
+
+// lineBuffer is the writer keeping the last lines written to it.
+type lineBuffer struct {
+	size int
+
+	mu      sync.Mutex
+	lines   []string
+	partial []byte
+}
+
+// Write stores complete lines of the data and keeps the incomplete one until the rest of it is written.
+func (b *lineBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.partial = append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i < 0 {
+			break
+		}
+		b.lines = append(b.lines, string(b.partial[:i]))
+		b.partial = b.partial[i+1:]
+	}
+	if len(b.lines) > b.size {
+		b.lines = b.lines[len(b.lines)-b.size:]
+	}
+	return len(p), nil
+}
+
+// Lines returns the stored lines, including the incomplete one.
+func (b *lineBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lines := append([]string{}, b.lines...)
+	if len(b.partial) > 0 {
+		lines = append(lines, string(b.partial))
+	}
+	if len(lines) > b.size {
+		lines = lines[len(lines)-b.size:]
+	}
+	return lines
+}
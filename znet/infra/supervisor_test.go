@@ -0,0 +1,148 @@
+package infra_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/infratest"
+)
+
+func TestSupervisor(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy infra.RestartPolicy
+		// stopped marks app as stopped in the spec before it exits
+		stopped           bool
+		events            []infra.AppEvent
+		expectedCrashes   []int
+		expectedRestarted bool
+	}{
+		{
+			name:   "failure_restarted",
+			policy: infra.RestartOnFailure,
+			events: []infra.AppEvent{
+				{AppName: "db", Action: infra.AppEventDie, ExitCode: 1},
+			},
+			expectedCrashes:   []int{1},
+			expectedRestarted: true,
+		},
+		{
+			name:   "success_not_restarted",
+			policy: infra.RestartOnFailure,
+			events: []infra.AppEvent{
+				{AppName: "db", Action: infra.AppEventDie, ExitCode: 0},
+			},
+			expectedCrashes: []int{0},
+		},
+		{
+			name:   "always_restarted",
+			policy: infra.RestartAlways,
+			events: []infra.AppEvent{
+				{AppName: "db", Action: infra.AppEventDie, ExitCode: 0},
+			},
+			expectedCrashes:   []int{0},
+			expectedRestarted: true,
+		},
+		{
+			name:   "never_restarted",
+			policy: infra.RestartNever,
+			events: []infra.AppEvent{
+				{AppName: "db", Action: infra.AppEventDie, ExitCode: 137},
+			},
+			expectedCrashes: []int{137},
+		},
+		{
+			name:   "killed_ignored",
+			policy: infra.RestartAlways,
+			events: []infra.AppEvent{
+				{AppName: "db", Action: infra.AppEventKill, ExitCode: -1},
+				{AppName: "db", Action: infra.AppEventDie, ExitCode: 143},
+			},
+		},
+		{
+			name:    "stopped_ignored",
+			policy:  infra.RestartAlways,
+			stopped: true,
+			events: []infra.AppEvent{
+				{AppName: "db", Action: infra.AppEventDie, ExitCode: 1},
+			},
+		},
+		{
+			name:   "unknown_app_ignored",
+			policy: infra.RestartAlways,
+			events: []infra.AppEvent{
+				{AppName: "other", Action: infra.AppEventDie, ExitCode: 1},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, config, spec := newTestEnv(t)
+			target := infratest.NewTarget(config, spec).WithImages("image")
+
+			db := infratest.NewApp(spec, "db", "image").WithRestartPolicy(tc.policy)
+			api := infratest.NewApp(spec, "api", "image", db)
+			appSet := infra.AppSet{db, api}
+			require.NoError(t, target.Deploy(ctx, appSet))
+
+			if tc.stopped {
+				info := db.Info()
+				info.Status = infra.AppStatusStopped
+				spec.Apps["db"].SetInfo(info)
+				require.NoError(t, spec.Save())
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- infra.NewSupervisor(config, spec, target, appSet).Run(ctx)
+			}()
+			for _, event := range tc.events {
+				target.Emit(event)
+			}
+
+			if tc.expectedRestarted {
+				require.Eventually(t, func() bool {
+					return len(target.CallArgs(infratest.MethodDeployContainer)) == 3
+				}, 5*time.Second, 10*time.Millisecond)
+			}
+			if len(tc.expectedCrashes) > 0 {
+				require.Eventually(t, func() bool {
+					return len(spec.Apps["db"].Crashes()) == len(tc.expectedCrashes)
+				}, 5*time.Second, 10*time.Millisecond)
+			}
+			// Give supervisor a chance to do something unexpected.
+			time.Sleep(100 * time.Millisecond)
+
+			cancel()
+			require.ErrorIs(t, <-errCh, context.Canceled)
+
+			var exitCodes []int
+			for _, crash := range spec.Apps["db"].Crashes() {
+				exitCodes = append(exitCodes, crash.ExitCode)
+			}
+			assert.Equal(t, tc.expectedCrashes, exitCodes)
+			if len(tc.expectedCrashes) > 0 {
+				assert.Contains(t, target.CallArgs(infratest.MethodLogs), "db")
+			}
+
+			deployed := target.CallArgs(infratest.MethodDeployContainer)
+			if tc.expectedRestarted {
+				assert.ElementsMatch(t, []string{"db", "api", "db"}, deployed)
+				assert.Equal(t, infra.AppStatusRunning, db.Info().Status)
+			} else {
+				assert.ElementsMatch(t, []string{"db", "api"}, deployed)
+			}
+
+			saved := infra.NewSpec(&infra.ConfigFactory{EnvName: config.EnvName, HomeDir: filepath.Dir(config.HomeDir)})
+			assert.Len(t, saved.Apps["db"].Crashes(), len(tc.expectedCrashes))
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package targets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/CoreumFoundation/crust/znet/infra"
+)
+
+// ComposeFileName is the name of the docker compose file produced by the compose exporter.
+const ComposeFileName = "docker-compose.yaml"
+
+// NewCompose creates new exporter producing docker compose file.
+func NewCompose(config infra.Config) *Compose {
+	return &Compose{
+		config: config,
+	}
+}
+
+// Compose exports the environment to docker compose file, so it might be started without znet.
+type Compose struct {
+	config infra.Config
+}
+
+// Export prepares files of all the apps and writes docker compose file next to the app directories.
+// Path to the compose file is returned.
+func (c *Compose) Export(ctx context.Context, appSet infra.AppSet) (string, error) {
+	deployments, err := prepareExport(ctx, c.config, appSet)
+	if err != nil {
+		return "", err
+	}
+
+	file := composeFile{
+		Services: map[string]composeService{},
+		Networks: map[string]composeNetwork{
+			"default": {Name: c.config.EnvName},
+		},
+	}
+	for _, deployment := range deployments {
+		service, err := c.service(deployment, deployments)
+		if err != nil {
+			return "", err
+		}
+		file.Services[deployment.Name] = service
+	}
+
+	raw, err := yaml.Marshal(file)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	composePath := filepath.Join(c.config.HomeDir, ComposeFileName)
+	if err := os.WriteFile(composePath, raw, 0o600); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return composePath, nil
+}
+
+func (c *Compose) service(app infra.Deployment, deployments []infra.Deployment) (composeService, error) {
+	hostPorts := map[int]int{}
+	for _, port := range app.Ports {
+		hostPorts[port] = port
+	}
+
+	spec := newContainerSpec(c.config.EnvName, c.config.EnvName+"-"+app.Name, app, hostPorts)
+	config, err := containerConfig(spec)
+	if err != nil {
+		return composeService{}, errors.Wrapf(err, "app %s can't be exported", app.Name)
+	}
+
+	service := composeService{
+		Image:         config.Image,
+		ContainerName: spec.Name,
+		User:          config.User,
+		Entrypoint:    escapeCompose(config.Entrypoint),
+		Command:       escapeCompose(config.Cmd),
+		Environment:   escapeCompose(config.Env),
+		Restart:       config.HostConfig.RestartPolicy.Name,
+		MemLimit:      config.HostConfig.Memory,
+		CPUs:          float64(config.HostConfig.NanoCPUs) / 1e9,
+		PidsLimit:     config.HostConfig.PidsLimit,
+	}
+	if service.Restart == "on-failure" && config.HostConfig.RestartPolicy.MaximumRetryCount > 0 {
+		service.Restart = fmt.Sprintf("on-failure:%d", config.HostConfig.RestartPolicy.MaximumRetryCount)
+	}
+	if service.Restart == "" && app.RestartPolicy != "" && app.RestartPolicy != infra.RestartNever {
+		// There is no supervisor in compose, so docker restarts the app instead.
+		service.Restart = string(app.RestartPolicy)
+	}
+
+	for _, port := range sortedPorts(hostPorts) {
+		service.Ports = append(service.Ports, fmt.Sprintf("127.0.0.1:%d:%d", port, port))
+	}
+
+	for _, v := range spec.Volumes {
+		source, err := filepath.Rel(c.config.HomeDir, v.Source)
+		if err != nil || strings.HasPrefix(source, "..") {
+			// Files outside the environment's home directory are mounted using absolute path.
+			source = v.Source
+		} else {
+			source = "./" + source
+		}
+		service.Volumes = append(service.Volumes, source+":"+v.Destination)
+	}
+
+	if len(app.Requires.Dependencies) > 0 {
+		service.DependsOn = map[string]composeDependency{}
+		for _, dep := range app.Requires.Dependencies {
+			condition := "service_started"
+			if hasHealthCheck(dep.Name(), deployments) {
+				condition = "service_healthy"
+			}
+			service.DependsOn[dep.Name()] = composeDependency{Condition: condition}
+		}
+	}
+
+	if len(app.HealthCheckCmd) > 0 {
+		service.HealthCheck = &composeHealthCheck{
+			Test:     append([]string{"CMD"}, escapeCompose(app.HealthCheckCmd)...),
+			Interval: "5s",
+			Timeout:  "5s",
+			Retries:  60,
+		}
+	}
+
+	return service, nil
+}
+
+// escapeCompose escapes `$` characters, otherwise docker compose would interpret them as variable references.
+func escapeCompose(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	escaped := make([]string, 0, len(values))
+	for _, v := range values {
+		escaped = append(escaped, strings.ReplaceAll(v, "$", "$$"))
+	}
+	return escaped
+}
+
+type composeFile struct {
+	Services map[string]composeService `json:"services"`
+	Networks map[string]composeNetwork `json:"networks"`
+}
+
+type composeNetwork struct {
+	Name string `json:"name"`
+}
+
+type composeService struct {
+	Image         string                       `json:"image"`
+	ContainerName string                       `json:"container_name"` //nolint:tagliatelle // defined by compose
+	User          string                       `json:"user,omitempty"`
+	Entrypoint    []string                     `json:"entrypoint,omitempty"`
+	Command       []string                     `json:"command,omitempty"`
+	Environment   []string                     `json:"environment,omitempty"`
+	Ports         []string                     `json:"ports,omitempty"`
+	Volumes       []string                     `json:"volumes,omitempty"`
+	DependsOn     map[string]composeDependency `json:"depends_on,omitempty"` //nolint:tagliatelle // defined by compose
+	HealthCheck   *composeHealthCheck          `json:"healthcheck,omitempty"`
+	Restart       string                       `json:"restart,omitempty"`
+	MemLimit      int64                        `json:"mem_limit,omitempty"` //nolint:tagliatelle // defined by compose
+	CPUs          float64                      `json:"cpus,omitempty"`
+	PidsLimit     int64                        `json:"pids_limit,omitempty"` //nolint:tagliatelle // defined by compose
+}
+
+type composeDependency struct {
+	Condition string `json:"condition"`
+}
+
+type composeHealthCheck struct {
+	Test     []string `json:"test"`
+	Interval string   `json:"interval"`
+	Timeout  string   `json:"timeout"`
+	Retries  int      `json:"retries"`
+}
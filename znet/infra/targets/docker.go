@@ -1,37 +1,43 @@
 package targets
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
-	osexec "os/exec"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 
-	"github.com/CoreumFoundation/coreum-tools/pkg/libexec"
 	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
 	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
-	"github.com/CoreumFoundation/crust/exec"
 	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/pkg/dockerapi"
 )
 
 const (
 	// AppHomeDir is the path inside container where application's home directory is mounted.
 	AppHomeDir = "/app"
 
-	labelEnv = "com.coreum.crust.znet.env"
-	labelApp = "com.coreum.crust.znet.app"
+	// LabelEnv is the label of docker container storing the name of the environment.
+	LabelEnv = "com.coreum.crust.znet.env"
+
+	// LabelApp is the label of docker container storing the name of the app.
+	LabelApp = "com.coreum.crust.znet.app"
+
+	// stopTimeout is the time given to the container to stop gracefully before it is killed.
+	stopTimeout = 60 * time.Second
 )
 
-// FIXME (wojciech): Entire logic here could be easily implemented by using docker API instead of binary execution
+// ErrPortConflict is returned by docker engine if host port the container is published on is already taken.
+var ErrPortConflict = errors.New("host port is already allocated")
 
 // NewDocker creates new docker target.
 func NewDocker(config infra.Config, spec *infra.Spec) infra.Target {
@@ -41,36 +47,179 @@ func NewDocker(config infra.Config, spec *infra.Spec) infra.Target {
 	}
 }
 
+// NewDockerWithEngine creates new docker target executing operations using the provided engine.
+func NewDockerWithEngine(config infra.Config, spec *infra.Spec, engine DockerEngine) infra.Target {
+	d := &Docker{
+		config: config,
+		spec:   spec,
+	}
+	d.engineOnce.Do(func() {
+		d.dEngine = engine
+	})
+	return d
+}
+
 // Docker is the target deploying apps to docker.
 type Docker struct {
 	config infra.Config
 	spec   *infra.Spec
 
+	engineOnce sync.Once
+	dEngine    DockerEngine
+
 	mu            sync.Mutex
 	networkExists bool
+	reservedPorts map[int]bool
+}
+
+// DockerEngine executes operations on docker daemon.
+type DockerEngine interface {
+	// ListContainers returns containers of the environment.
+	ListContainers(ctx context.Context, envName string) ([]DockerContainer, error)
+
+	// ContainerID returns ID of the container, empty string is returned if container does not exist.
+	ContainerID(ctx context.Context, name string) (string, error)
+
+	// RunContainer creates and starts the container, its ID is returned.
+	RunContainer(ctx context.Context, spec DockerContainerSpec) (string, error)
+
+	// StartContainer starts existing container.
+	StartContainer(ctx context.Context, id string) error
+
+	// StopContainer stops running container.
+	StopContainer(ctx context.Context, id string) error
+
+	// RemoveContainer removes the container.
+	RemoveContainer(ctx context.Context, info DockerContainer) error
+
+	// ForceRemoveContainer removes the container even if it is running.
+	ForceRemoveContainer(ctx context.Context, name string) error
+
+	// PublishedPorts returns host ports indexed by the container ports they are published for.
+	PublishedPorts(ctx context.Context, id string) (map[int]int, error)
+
+	// NetworkExists checks if network exists.
+	NetworkExists(ctx context.Context, network string) (bool, error)
+
+	// CreateNetwork creates network.
+	CreateNetwork(ctx context.Context, network string) error
+
+	// RemoveNetwork removes network.
+	RemoveNetwork(ctx context.Context, network string) error
+
+	// ImageExists checks if image exists locally.
+	ImageExists(ctx context.Context, image string) (bool, error)
+
+	// PullImage pulls image from the registry.
+	PullImage(ctx context.Context, image string) error
+
+	// TagImage creates the target reference of the source image.
+	TagImage(ctx context.Context, source, target string) error
+
+	// ImageDigests returns repo digests of the local image.
+	ImageDigests(ctx context.Context, image string) ([]string, error)
+
+	// Stats returns resources used by the running container.
+	Stats(ctx context.Context, id string) (DockerContainerStats, error)
+
+	// Logs streams logs of the container until it stops or context is canceled.
+	Logs(ctx context.Context, id string, stdout, stderr io.Writer) error
+
+	// Events reports events of the environment's containers until context is canceled.
+	Events(ctx context.Context, envName string, fn func(event dockerapi.Event) error) error
+
+	// SaveImages saves images to the tar archive.
+	SaveImages(ctx context.Context, images []string, path string) error
+
+	// LoadImages loads images from the tar archive.
+	LoadImages(ctx context.Context, path string) error
+}
+
+// watchedEvents are the container events reported by Watch.
+var watchedEvents = []string{infra.AppEventStart, infra.AppEventKill, infra.AppEventDie}
+
+// engine returns the engine used to talk to docker daemon. Docker Engine API is used if daemon is reachable,
+// otherwise docker CLI is executed.
+func (d *Docker) engine(ctx context.Context) DockerEngine {
+	d.engineOnce.Do(func() {
+		log := logger.Get(ctx)
+
+		client, err := dockerapi.New()
+		if err == nil {
+			pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			err = client.Ping(pingCtx)
+		}
+		if err != nil {
+			log.Warn("Docker Engine API is not available, falling back to docker CLI", zap.Error(err))
+			d.dEngine = cliEngine{}
+			return
+		}
+		d.dEngine = apiEngine{client: client}
+	})
+	return d.dEngine
 }
 
 // Stop stops running applications.
 func (d *Docker) Stop(ctx context.Context) error {
+	return d.stop(ctx, func(string) bool { return true })
+}
+
+// StopApps stops selected applications.
+func (d *Docker) StopApps(ctx context.Context, appNames []string) error {
+	selected := lo.SliceToMap(appNames, func(appName string) (string, bool) {
+		return appName, true
+	})
+	for appName := range selected {
+		if _, exists := d.spec.Apps[appName]; !exists {
+			return errors.Errorf("app %s does not exist in the environment", appName)
+		}
+	}
+
+	return d.stop(ctx, func(appName string) bool {
+		return selected[appName]
+	})
+}
+
+func (d *Docker) stop(ctx context.Context, selectFn func(appName string) bool) error {
+	containers, err := d.engine(ctx).ListContainers(ctx, d.config.EnvName)
+	if err != nil {
+		return err
+	}
+	withContainer := lo.SliceToMap(containers, func(info DockerContainer) (string, bool) {
+		return info.AppName, true
+	})
+
 	dependencies := map[string][]chan struct{}{}
 	readyChs := map[string]chan struct{}{}
 	for appName, app := range d.spec.Apps {
+		if !selectFn(appName) {
+			continue
+		}
 		readyCh := make(chan struct{})
 		readyChs[appName] = readyCh
+		if !withContainer[appName] {
+			// There is nothing to stop, so apps it depends on must not wait for it.
+			close(readyCh)
+		}
 
 		for _, depName := range app.Info().DependsOn {
 			dependencies[depName] = append(dependencies[depName], readyCh)
 		}
 	}
 
-	return forContainer(ctx, d.config.EnvName, func(ctx context.Context, info container) error {
+	return forEachContainer(ctx, containers, func(ctx context.Context, info DockerContainer) error {
+		if !selectFn(info.AppName) {
+			return nil
+		}
+
 		log := logger.Get(ctx).With(zap.String("id", info.ID), zap.String("name", info.Name),
 			zap.String("appName", info.AppName))
 
 		if _, exists := d.spec.Apps[info.AppName]; !exists {
 			log.Info("Unexpected container found, deleting it")
 
-			if err := removeContainer(ctx, info); err != nil {
+			if err := d.removeContainer(ctx, info); err != nil {
 				return err
 			}
 
@@ -91,7 +240,7 @@ func (d *Docker) Stop(ctx context.Context) error {
 
 		log.Info("Stopping container")
 
-		if err := libexec.Exec(ctx, noStdout(exec.Docker("stop", "--time", "60", info.ID))); err != nil {
+		if err := d.engine(ctx).StopContainer(ctx, info.ID); err != nil {
 			return errors.Wrapf(err, "stopping container `%s` failed", info.Name)
 		}
 
@@ -103,12 +252,12 @@ func (d *Docker) Stop(ctx context.Context) error {
 
 // Remove removes running applications.
 func (d *Docker) Remove(ctx context.Context) error {
-	err := forContainer(ctx, d.config.EnvName, func(ctx context.Context, info container) error {
+	err := d.forContainer(ctx, func(ctx context.Context, info DockerContainer) error {
 		log := logger.Get(ctx).With(zap.String("id", info.ID), zap.String("name", info.Name),
 			zap.String("appName", info.AppName))
 		log.Info("Deleting container")
 
-		if err := removeContainer(ctx, info); err != nil {
+		if err := d.removeContainer(ctx, info); err != nil {
 			return err
 		}
 
@@ -121,6 +270,68 @@ func (d *Docker) Remove(ctx context.Context) error {
 	return d.deleteNetwork(ctx, d.config.EnvName)
 }
 
+// RemoveApps removes containers of selected applications.
+func (d *Docker) RemoveApps(ctx context.Context, appNames []string) error {
+	selected := lo.SliceToMap(appNames, func(appName string) (string, bool) {
+		return appName, true
+	})
+	return d.forContainer(ctx, func(ctx context.Context, info DockerContainer) error {
+		if !selected[info.AppName] {
+			return nil
+		}
+
+		log := logger.Get(ctx).With(zap.String("id", info.ID), zap.String("name", info.Name),
+			zap.String("appName", info.AppName))
+		log.Info("Deleting container")
+
+		if err := d.removeContainer(ctx, info); err != nil {
+			return err
+		}
+
+		log.Info("Container deleted")
+		return nil
+	})
+}
+
+// Status returns state of containers existing in the environment.
+func (d *Docker) Status(ctx context.Context) (map[string]infra.RuntimeState, error) {
+	var mu sync.Mutex
+	states := map[string]infra.RuntimeState{}
+	err := d.forContainer(ctx, func(ctx context.Context, info DockerContainer) error {
+		state := infra.RuntimeState{
+			State:    info.State,
+			ExitCode: info.ExitCode,
+		}
+		if info.Running {
+			stats, err := d.engine(ctx).Stats(ctx, info.ID)
+			if err != nil {
+				// Container might stop in the meantime, usage is just not reported then.
+				logger.Get(ctx).Debug("Retrieving container stats failed", zap.String("name", info.Name),
+					zap.Error(err))
+			} else {
+				state.Usage = &infra.ResourceUsage{
+					CPUPercent:  stats.CPUPercent,
+					CPULimit:    info.CPULimit,
+					Memory:      stats.Memory,
+					MemoryLimit: lo.Ternary(info.MemoryLimit > 0, info.MemoryLimit, stats.MemoryLimit),
+					Pids:        stats.Pids,
+					PidsLimit:   info.PidsLimit,
+				}
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		states[info.AppName] = state
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
 // Deploy deploys environment to docker target.
 func (d *Docker) Deploy(ctx context.Context, appSet infra.AppSet) error {
 	err := appSet.Deploy(ctx, d, d.config, d.spec)
@@ -128,13 +339,97 @@ func (d *Docker) Deploy(ctx context.Context, appSet infra.AppSet) error {
 		return err
 	}
 
-	log := logger.Get(ctx)
-	log.Info("Waiting until all applications start.")
-	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Minute)
-	defer waitCancel()
-	defer log.Info("All applications are healthy.")
+	return infra.WaitUntilAppSetHealthy(ctx, appSet)
+}
 
-	return infra.WaitUntilHealthy(waitCtx, infra.BuildWaitForApps(appSet)...)
+// Logs streams logs of the application until it stops or context is canceled.
+func (d *Docker) Logs(ctx context.Context, appName string, stdout, stderr io.Writer) error {
+	app, exists := d.spec.Apps[appName]
+	if !exists {
+		return errors.Errorf("app %s does not exist in the environment", appName)
+	}
+	if app.Info().Container == "" {
+		return errors.Errorf("app %s hasn't been deployed", appName)
+	}
+	return d.engine(ctx).Logs(ctx, app.Info().Container, stdout, stderr)
+}
+
+// Watch reports start and exit of containers existing in the environment until context is canceled.
+func (d *Docker) Watch(ctx context.Context, fn func(event infra.AppEvent) error) error {
+	return d.engine(ctx).Events(ctx, d.config.EnvName, func(event dockerapi.Event) error {
+		appName := event.Actor.Attributes[LabelApp]
+		if appName == "" {
+			return nil
+		}
+
+		appEvent := infra.AppEvent{
+			AppName:  appName,
+			Action:   event.Action,
+			ExitCode: -1,
+			Time:     time.Unix(0, event.TimeNano),
+		}
+		if exitCode, exists := event.Actor.Attributes["exitCode"]; exists {
+			if code, err := strconv.Atoi(exitCode); err == nil {
+				appEvent.ExitCode = code
+			}
+		}
+		return fn(appEvent)
+	})
+}
+
+// ImageExists checks if docker image exists locally.
+func (d *Docker) ImageExists(ctx context.Context, image string) (bool, error) {
+	exists, err := d.engine(ctx).ImageExists(ctx, image)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list image '%s'", image)
+	}
+	return exists, nil
+}
+
+// PullImage pulls docker image. Image pinned to the digest is pulled by the digest and tagged with the original
+// reference.
+func (d *Docker) PullImage(ctx context.Context, image string) error {
+	image, digest, pinned := strings.Cut(image, "@")
+	if !pinned {
+		if err := d.engine(ctx).PullImage(ctx, image); err != nil {
+			return errors.Wrapf(err, "failed to pull docker image '%s'", image)
+		}
+		return nil
+	}
+
+	// Image pulled by digest is not tagged, so containers wouldn't find it by the original reference.
+	pinnedImage := infra.ImageRepository(image) + "@" + digest
+	if err := d.engine(ctx).PullImage(ctx, pinnedImage); err != nil {
+		return errors.Wrapf(err, "failed to pull docker image '%s'", pinnedImage)
+	}
+	if err := d.engine(ctx).TagImage(ctx, pinnedImage, image); err != nil {
+		return errors.Wrapf(err, "failed to tag docker image '%s' as '%s'", pinnedImage, image)
+	}
+	return nil
+}
+
+// ImageDigests returns digests of the docker image existing locally.
+func (d *Docker) ImageDigests(ctx context.Context, image string) ([]string, error) {
+	repoDigests, err := d.engine(ctx).ImageDigests(ctx, image)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to inspect image '%s'", image)
+	}
+	return lo.Map(repoDigests, func(repoDigest string, _ int) string {
+		_, digest, _ := strings.Cut(repoDigest, "@")
+		return digest
+	}), nil
+}
+
+// SaveImages saves docker images to the tarball.
+func (d *Docker) SaveImages(ctx context.Context, images []string, path string) error {
+	logger.Get(ctx).Info("Saving docker images", zap.Strings("images", images), zap.String("path", path))
+	return d.engine(ctx).SaveImages(ctx, images, path)
+}
+
+// LoadImages loads docker images from the tarball.
+func (d *Docker) LoadImages(ctx context.Context, path string) error {
+	logger.Get(ctx).Info("Loading docker images", zap.String("path", path))
+	return d.engine(ctx).LoadImages(ctx, path)
 }
 
 // DeployContainer starts container in docker.
@@ -148,26 +443,40 @@ func (d *Docker) DeployContainer(ctx context.Context, app infra.Deployment) (inf
 	log := logger.Get(ctx).With(zap.String("name", name), zap.String("appName", app.Name))
 	log.Info("Starting container")
 
-	id, err := containerExists(ctx, name)
+	id, err := d.engine(ctx).ContainerID(ctx, name)
 	if err != nil {
 		return infra.DeploymentInfo{}, err
 	}
 
-	var startCmd *osexec.Cmd
 	if id != "" {
-		startCmd = exec.Docker("start", id)
-	} else {
-		runArgs := d.prepareRunArgs(name, app)
-		startCmd = exec.Docker(runArgs...)
+		// Ports published by existing container are kept by docker, so they don't need to be allocated again.
+		err := d.engine(ctx).StartContainer(ctx, id)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrPortConflict):
+			// Another process took the host port since the container was stopped. Data is stored in bind mounts,
+			// so container may be recreated using new ports.
+			log.Warn("Host port of stopped container is already taken, recreating container")
+			if err := d.engine(ctx).ForceRemoveContainer(ctx, name); err != nil {
+				return infra.DeploymentInfo{}, errors.Wrapf(err, "deleting container `%s` failed", name)
+			}
+			id = ""
+		default:
+			return infra.DeploymentInfo{}, errors.Wrapf(err, "starting container `%s` failed", name)
+		}
+	}
+	if id == "" {
+		if id, err = d.runContainer(ctx, name, app); err != nil {
+			return infra.DeploymentInfo{}, err
+		}
 	}
-	idBuf := &bytes.Buffer{}
-	startCmd.Stdout = idBuf
 
-	if err := libexec.Exec(ctx, startCmd); err != nil {
+	hostPorts, err := d.engine(ctx).PublishedPorts(ctx, id)
+	if err != nil {
 		return infra.DeploymentInfo{}, err
 	}
 
-	log.Info("Container started", zap.String("id", strings.TrimSuffix(idBuf.String(), "\n")))
+	log.Info("Container started", zap.String("id", id), zap.Any("hostPorts", hostPorts))
 
 	// FromHostIP = ipLocalhost here means that application is available on host's localhost, not container's localhost
 	return infra.DeploymentInfo{
@@ -176,42 +485,104 @@ func (d *Docker) DeployContainer(ctx context.Context, app infra.Deployment) (inf
 		HostFromHost:      "localhost",
 		HostFromContainer: name,
 		Ports:             app.Ports,
+		HostPorts:         hostPorts,
 	}, nil
 }
 
-func (d *Docker) prepareRunArgs(name string, app infra.Deployment) []string {
-	runArgs := []string{
-		"run", "--name", name, "-d", "--label", labelEnv + "=" + d.config.EnvName,
-		"--label", labelApp + "=" + app.Name, "--network", d.config.EnvName,
-	}
-	if app.RunAsUser {
-		runArgs = append(runArgs, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
-	}
-	for _, port := range app.Ports {
-		portStr := strconv.Itoa(port)
-		runArgs = append(runArgs, "-p", "127.0.0.1:"+portStr+":"+portStr+"/tcp")
+// runContainer creates and starts new container. Host ports are allocated dynamically, so if another process takes
+// the port in the meantime, container is recreated using another one.
+func (d *Docker) runContainer(ctx context.Context, name string, app infra.Deployment) (string, error) {
+	const attempts = 3
+
+	var err error
+	for range attempts {
+		var hostPorts map[int]int
+		hostPorts, err = d.allocatePorts(app.Ports)
+		if err != nil {
+			return "", err
+		}
+
+		var id string
+		id, err = d.engine(ctx).RunContainer(ctx, newContainerSpec(d.config.EnvName, name, app, hostPorts))
+		d.releasePorts(hostPorts)
+		if err == nil {
+			return id, nil
+		}
+		if !errors.Is(err, ErrPortConflict) {
+			return "", errors.Wrapf(err, "starting container `%s` failed", name)
+		}
+
+		logger.Get(ctx).Warn("Host port is already taken, recreating container", zap.String("name", name))
+
+		// Container has been created even if it couldn't start.
+		if err := d.engine(ctx).ForceRemoveContainer(ctx, name); err != nil {
+			return "", errors.Wrapf(err, "deleting container `%s` failed", name)
+		}
 	}
-	for _, v := range app.Volumes {
-		runArgs = append(runArgs, "-v", v.Source+":"+v.Destination)
+	return "", errors.Wrapf(err, "starting container `%s` failed", name)
+}
+
+// allocatePorts chooses host ports for the application's ports. Port is published on the same port number on the host
+// if it is free there, otherwise random free port is used. This way many environments may run side by side.
+func (d *Docker) allocatePorts(ports map[string]int) (map[int]int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.reservedPorts == nil {
+		d.reservedPorts = map[int]bool{}
 	}
-	if app.EnvVarsFunc != nil {
-		for _, env := range app.EnvVarsFunc() {
-			runArgs = append(runArgs, "-e", env.Name+"="+env.Value)
+
+	hostPorts := map[int]int{}
+	for _, port := range ports {
+		hostPort := port
+		if d.reservedPorts[hostPort] || !isPortFree(hostPort) {
+			var err error
+			if hostPort, err = freePort(); err != nil {
+				return nil, err
+			}
 		}
+		d.reservedPorts[hostPort] = true
+		hostPorts[port] = hostPort
 	}
+	return hostPorts, nil
+}
 
-	runArgs = append(runArgs, app.DockerArgs...)
+// releasePorts releases ports reserved for the container once docker has published them.
+func (d *Docker) releasePorts(hostPorts map[int]int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-	if app.Entrypoint != "" {
-		runArgs = append(runArgs, "--entrypoint", app.Entrypoint)
+	for _, hostPort := range hostPorts {
+		delete(d.reservedPorts, hostPort)
 	}
+}
 
-	runArgs = append(runArgs, app.Image)
+// newContainerSpec describes the container running the application.
+func newContainerSpec(envName, name string, app infra.Deployment, hostPorts map[int]int) DockerContainerSpec {
+	spec := DockerContainerSpec{
+		Name: name,
+		Labels: map[string]string{
+			LabelEnv: envName,
+			LabelApp: app.Name,
+		},
+		Network:    envName,
+		HostPorts:  hostPorts,
+		Volumes:    app.Volumes,
+		Entrypoint: app.Entrypoint,
+		Image:      app.Image,
+		DockerArgs: app.DockerArgs,
+		Resources:  app.Resources,
+	}
+	if app.RunAsUser {
+		spec.User = fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	}
+	if app.EnvVarsFunc != nil {
+		spec.EnvVars = app.EnvVarsFunc()
+	}
 	if app.ArgsFunc != nil {
-		runArgs = append(runArgs, app.ArgsFunc()...)
+		spec.Args = app.ArgsFunc()
 	}
-
-	return runArgs
+	return spec
 }
 
 func (d *Docker) ensureNetwork(ctx context.Context, network string) error {
@@ -225,7 +596,7 @@ func (d *Docker) ensureNetwork(ctx context.Context, network string) error {
 	log := logger.Get(ctx).With(zap.String("network", network))
 
 	var err error
-	d.networkExists, err = networkExists(ctx, network)
+	d.networkExists, err = d.engine(ctx).NetworkExists(ctx, network)
 	if err != nil {
 		return err
 	}
@@ -236,7 +607,7 @@ func (d *Docker) ensureNetwork(ctx context.Context, network string) error {
 
 	log.Info("Creating docker network")
 
-	if err := libexec.Exec(ctx, noStdout(exec.Docker("network", "create", network))); err != nil {
+	if err := d.engine(ctx).CreateNetwork(ctx, network); err != nil {
 		return errors.Wrapf(err, "creating network '%s' failed", network)
 	}
 
@@ -246,7 +617,7 @@ func (d *Docker) ensureNetwork(ctx context.Context, network string) error {
 }
 
 func (d *Docker) deleteNetwork(ctx context.Context, network string) error {
-	exists, err := networkExists(ctx, network)
+	exists, err := d.engine(ctx).NetworkExists(ctx, network)
 	if err != nil {
 		return err
 	}
@@ -257,7 +628,7 @@ func (d *Docker) deleteNetwork(ctx context.Context, network string) error {
 	log := logger.Get(ctx).With(zap.String("network", network))
 	log.Info("Deleting docker network")
 
-	if err := libexec.Exec(ctx, noStdout(exec.Docker("network", "rm", network))); err != nil {
+	if err := d.engine(ctx).RemoveNetwork(ctx, network); err != nil {
 		return errors.Wrapf(err, "deleting network '%s' failed", network)
 	}
 
@@ -265,97 +636,140 @@ func (d *Docker) deleteNetwork(ctx context.Context, network string) error {
 	return nil
 }
 
-func containerExists(ctx context.Context, name string) (string, error) {
-	idBuf := &bytes.Buffer{}
-	existsCmd := exec.Docker("ps", "-aq", "--no-trunc", "--filter", "name="+name)
-	existsCmd.Stdout = idBuf
-	if err := libexec.Exec(ctx, existsCmd); err != nil {
-		return "", err
+// hostPorts converts port bindings reported by docker to the mapping between container ports and host ports
+// they are published on.
+func hostPorts(bindings map[string][]dockerapi.PortBinding) (map[int]int, error) {
+	hostPorts := map[int]int{}
+	for containerPort, portBindings := range bindings {
+		if len(portBindings) == 0 {
+			continue
+		}
+		// Format of container port is <port>/<protocol>
+		port, err := strconv.Atoi(strings.Split(containerPort, "/")[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid container port %s", containerPort)
+		}
+		hostPort, err := strconv.Atoi(portBindings[0].HostPort)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid host port %s", portBindings[0].HostPort)
+		}
+		hostPorts[port] = hostPort
 	}
-	return strings.TrimSuffix(idBuf.String(), "\n"), nil
+	return hostPorts, nil
 }
 
-type container struct {
-	ID      string
-	Name    string
-	AppName string
-	Running bool
+func sortedPorts(hostPorts map[int]int) []int {
+	ports := lo.Keys(hostPorts)
+	sort.Ints(ports)
+	return ports
 }
 
-func forContainer(ctx context.Context, envName string, fn func(ctx context.Context, info container) error) error {
-	listBuf := &bytes.Buffer{}
-	listCmd := exec.Docker("ps", "-aq", "--no-trunc", "--filter", "label="+labelEnv+"="+envName)
-	listCmd.Stdout = listBuf
-	if err := libexec.Exec(ctx, listCmd); err != nil {
-		return err
+func isPortFree(port int) bool {
+	l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return false
 	}
+	_ = l.Close()
+	return true
+}
 
-	listStr := strings.TrimSuffix(listBuf.String(), "\n")
-	if listStr == "" {
-		return nil
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, errors.Wrap(err, "finding free port failed")
 	}
+	defer l.Close()
 
-	inspectBuf := &bytes.Buffer{}
-	inspectCmd := exec.Docker(append([]string{"inspect"}, strings.Split(listStr, "\n")...)...)
-	inspectCmd.Stdout = inspectBuf
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
 
-	if err := libexec.Exec(ctx, inspectCmd); err != nil {
-		return err
-	}
+func isPortConflict(stderr string) bool {
+	return strings.Contains(stderr, "port is already allocated") || strings.Contains(stderr, "address already in use")
+}
 
-	var info []struct {
-		ID    string `json:"Id"` //nolint:tagliatelle // `Id` is defined by docker
-		Name  string
-		State struct {
-			Running bool
-		}
-		Config struct {
-			Labels map[string]string
-		}
+// DockerContainerSpec defines container to run.
+type DockerContainerSpec struct {
+	Name    string
+	Labels  map[string]string
+	Network string
+	User    string
+
+	// HostPorts maps container ports to host ports they are published on
+	HostPorts map[int]int
+
+	Volumes    []infra.Volume
+	EnvVars    []infra.EnvVar
+	Entrypoint string
+	Image      string
+	Args       []string
+	DockerArgs []string
+	Resources  infra.Resources
+}
+
+// DockerContainer describes existing container.
+type DockerContainer struct {
+	ID       string
+	Name     string
+	AppName  string
+	Running  bool
+	State    string
+	ExitCode int
+
+	// Limits of resources applied to the container, 0 means no limit
+	CPULimit    float64
+	MemoryLimit int64
+	PidsLimit   int64
+}
+
+// DockerContainerStats describes resources used by the running container.
+type DockerContainerStats struct {
+	CPUPercent  float64
+	Memory      int64
+	MemoryLimit int64
+	Pids        int64
+}
+
+func newContainer(details dockerapi.ContainerDetails) DockerContainer {
+	return DockerContainer{
+		ID:       details.ID,
+		Name:     strings.TrimPrefix(details.Name, "/"),
+		AppName:  details.Config.Labels[LabelApp],
+		Running:  details.State.Running,
+		State:    details.State.Status,
+		ExitCode: details.State.ExitCode,
+
+		CPULimit:    float64(details.HostConfig.NanoCPUs) / 1e9,
+		MemoryLimit: details.HostConfig.Memory,
+		PidsLimit:   details.HostConfig.PidsLimit,
 	}
+}
 
-	if err := json.Unmarshal(inspectBuf.Bytes(), &info); err != nil {
-		return errors.Wrap(err, "unmarshalling container properties failed")
+func (d *Docker) forContainer(ctx context.Context, fn func(ctx context.Context, info DockerContainer) error) error {
+	containers, err := d.engine(ctx).ListContainers(ctx, d.config.EnvName)
+	if err != nil {
+		return err
 	}
+	return forEachContainer(ctx, containers, fn)
+}
 
+func forEachContainer(
+	ctx context.Context,
+	containers []DockerContainer,
+	fn func(ctx context.Context, info DockerContainer) error,
+) error {
 	return parallel.Run(ctx, func(ctx context.Context, spawn parallel.SpawnFn) error {
-		for _, cInfo := range info {
-			spawn("container."+cInfo.ID, parallel.Continue, func(ctx context.Context) error {
-				return fn(ctx, container{
-					ID:      cInfo.ID,
-					Name:    strings.TrimPrefix(cInfo.Name, "/"),
-					AppName: cInfo.Config.Labels[labelApp],
-					Running: cInfo.State.Running,
-				})
+		for _, info := range containers {
+			spawn("container."+info.ID, parallel.Continue, func(ctx context.Context) error {
+				return fn(ctx, info)
 			})
 		}
 		return nil
 	})
 }
 
-func noStdout(cmd *osexec.Cmd) *osexec.Cmd {
-	cmd.Stdout = io.Discard
-	return cmd
-}
-
-func removeContainer(ctx context.Context, info container) error {
-	cmds := []*osexec.Cmd{}
-	if info.Running {
-		// Everything will be removed, so we don't care about graceful shutdown
-		cmds = append(cmds, noStdout(exec.Docker("kill", info.ID)))
-	}
-	if err := libexec.Exec(ctx, append(cmds, noStdout(exec.Docker("rm", info.ID)))...); err != nil {
+func (d *Docker) removeContainer(ctx context.Context, info DockerContainer) error {
+	if err := d.engine(ctx).RemoveContainer(ctx, info); err != nil {
 		return errors.Wrapf(err, "deleting container `%s` failed", info.Name)
 	}
 	return nil
 }
-
-func networkExists(ctx context.Context, network string) (bool, error) {
-	buf := &bytes.Buffer{}
-	cmd := exec.Docker("network", "ls", "-q", "--no-trunc", "--filter", "name="+network)
-	cmd.Stdout = buf
-	if err := libexec.Exec(ctx, cmd); err != nil {
-		return false, err
-	}
-	return strings.TrimSuffix(buf.String(), "\n") != "", nil
-}
@@ -0,0 +1,339 @@
+package targets
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/pkg/dockerapi"
+)
+
+// pullProgressInterval is the minimal interval between logs reporting progress of image pull.
+const pullProgressInterval = 5 * time.Second
+
+// apiEngine executes operations using Docker Engine API.
+type apiEngine struct {
+	client *dockerapi.Client
+
+	// cli is used to run containers requiring docker arguments which are not supported by apiEngine
+	cli cliEngine
+}
+
+func (e apiEngine) ListContainers(ctx context.Context, envName string) ([]DockerContainer, error) {
+	summaries, err := e.client.ContainerList(ctx, map[string][]string{"label": {LabelEnv + "=" + envName}})
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]DockerContainer, 0, len(summaries))
+	for _, summary := range summaries {
+		details, err := e.client.ContainerInspect(ctx, summary.ID)
+		if err != nil {
+			if dockerapi.IsNotFound(err) {
+				// Container has been deleted in the meantime.
+				continue
+			}
+			return nil, err
+		}
+		containers = append(containers, newContainer(details))
+	}
+	return containers, nil
+}
+
+func (e apiEngine) ContainerID(ctx context.Context, name string) (string, error) {
+	details, err := e.client.ContainerInspect(ctx, name)
+	switch {
+	case err == nil:
+		return details.ID, nil
+	case dockerapi.IsNotFound(err):
+		return "", nil
+	default:
+		return "", err
+	}
+}
+
+func (e apiEngine) RunContainer(ctx context.Context, spec DockerContainerSpec) (string, error) {
+	config, err := containerConfig(spec)
+	if err != nil {
+		logger.Get(ctx).Debug("Docker arguments are not supported by API, running container using CLI",
+			zap.String("name", spec.Name), zap.Error(err))
+		return e.cli.RunContainer(ctx, spec)
+	}
+
+	id, err := e.client.ContainerCreate(ctx, spec.Name, config)
+	if err != nil {
+		return "", err
+	}
+	if err := e.client.ContainerStart(ctx, id); err != nil {
+		if isPortConflict(err.Error()) {
+			return "", errors.Wrap(ErrPortConflict, err.Error())
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+func (e apiEngine) StartContainer(ctx context.Context, id string) error {
+	return e.client.ContainerStart(ctx, id)
+}
+
+func (e apiEngine) StopContainer(ctx context.Context, id string) error {
+	return e.client.ContainerStop(ctx, id, stopTimeout)
+}
+
+func (e apiEngine) RemoveContainer(ctx context.Context, info DockerContainer) error {
+	// Everything will be removed, so we don't care about graceful shutdown
+	return e.client.ContainerRemove(ctx, info.ID, true)
+}
+
+func (e apiEngine) ForceRemoveContainer(ctx context.Context, name string) error {
+	return e.client.ContainerRemove(ctx, name, true)
+}
+
+func (e apiEngine) PublishedPorts(ctx context.Context, id string) (map[int]int, error) {
+	details, err := e.client.ContainerInspect(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return hostPorts(details.NetworkSettings.Ports)
+}
+
+func (e apiEngine) NetworkExists(ctx context.Context, network string) (bool, error) {
+	return e.client.NetworkExists(ctx, network)
+}
+
+func (e apiEngine) CreateNetwork(ctx context.Context, network string) error {
+	return e.client.NetworkCreate(ctx, network)
+}
+
+func (e apiEngine) RemoveNetwork(ctx context.Context, network string) error {
+	return e.client.NetworkRemove(ctx, network)
+}
+
+func (e apiEngine) ImageExists(ctx context.Context, image string) (bool, error) {
+	return e.client.ImageExists(ctx, image)
+}
+
+func (e apiEngine) ImageDigests(ctx context.Context, image string) ([]string, error) {
+	details, err := e.client.ImageInspect(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	return details.RepoDigests, nil
+}
+
+func (e apiEngine) PullImage(ctx context.Context, image string) error {
+	log := logger.Get(ctx).With(zap.String("image", image))
+
+	type layerProgress struct {
+		Current int64
+		Total   int64
+	}
+	layers := map[string]layerProgress{}
+	lastReport := time.Now()
+	return e.client.ImagePull(ctx, image, func(progress dockerapi.PullProgress) {
+		switch progress.Status {
+		case "Downloading":
+			layers[progress.ID] = layerProgress{
+				Current: progress.ProgressDetail.Current,
+				Total:   progress.ProgressDetail.Total,
+			}
+		case "Download complete":
+			layer := layers[progress.ID]
+			layer.Current = layer.Total
+			layers[progress.ID] = layer
+		default:
+			return
+		}
+
+		if time.Since(lastReport) < pullProgressInterval {
+			return
+		}
+		lastReport = time.Now()
+
+		var current, total int64
+		for _, layer := range layers {
+			current += layer.Current
+			total += layer.Total
+		}
+		log.Info("Pulling docker image", zap.String("progress",
+			fmt.Sprintf("%.1f/%.1f MB", float64(current)/1e6, float64(total)/1e6)))
+	})
+}
+
+func (e apiEngine) TagImage(ctx context.Context, source, target string) error {
+	return e.client.ImageTag(ctx, source, target)
+}
+
+func (e apiEngine) Stats(ctx context.Context, id string) (DockerContainerStats, error) {
+	stats, err := e.client.ContainerStats(ctx, id)
+	if err != nil {
+		return DockerContainerStats{}, err
+	}
+
+	// The same formula is used by `docker stats`.
+	var cpuPercent float64
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpuPercent = cpuDelta / systemDelta * float64(stats.CPUStats.OnlineCPUs) * 100
+	}
+
+	// Page cache is not counted as used memory, key depends on the cgroup version.
+	memory := stats.MemoryStats.Usage
+	for _, key := range []string{"inactive_file", "total_inactive_file"} {
+		if inactive, exists := stats.MemoryStats.Stats[key]; exists && inactive < memory {
+			memory -= inactive
+			break
+		}
+	}
+
+	return DockerContainerStats{
+		CPUPercent:  cpuPercent,
+		Memory:      int64(memory),
+		MemoryLimit: int64(stats.MemoryStats.Limit),
+		Pids:        int64(stats.PidsStats.Current),
+	}, nil
+}
+
+func (e apiEngine) Logs(ctx context.Context, id string, stdout, stderr io.Writer) error {
+	return e.client.ContainerLogs(ctx, id, true, stdout, stderr)
+}
+
+func (e apiEngine) Events(ctx context.Context, envName string, fn func(event dockerapi.Event) error) error {
+	return e.client.Events(ctx, map[string][]string{
+		"type":  {"container"},
+		"label": {LabelEnv + "=" + envName},
+		"event": watchedEvents,
+	}, fn)
+}
+
+func (e apiEngine) SaveImages(ctx context.Context, images []string, path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	if err := e.client.ImagesSave(ctx, images, f); err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+	return errors.WithStack(f.Close())
+}
+
+func (e apiEngine) LoadImages(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	return e.client.ImagesLoad(ctx, f)
+}
+
+// containerConfig converts container spec to the config accepted by Docker Engine API.
+func containerConfig(spec DockerContainerSpec) (dockerapi.ContainerConfig, error) {
+	config := dockerapi.ContainerConfig{
+		Image:        spec.Image,
+		Cmd:          spec.Args,
+		User:         spec.User,
+		Labels:       spec.Labels,
+		ExposedPorts: map[string]struct{}{},
+		HostConfig: dockerapi.HostConfig{
+			PortBindings: map[string][]dockerapi.PortBinding{},
+			NetworkMode:  spec.Network,
+		},
+	}
+	if spec.Entrypoint != "" {
+		config.Entrypoint = []string{spec.Entrypoint}
+	}
+	for port, hostPort := range spec.HostPorts {
+		portKey := strconv.Itoa(port) + "/tcp"
+		config.ExposedPorts[portKey] = struct{}{}
+		config.HostConfig.PortBindings[portKey] = []dockerapi.PortBinding{
+			{HostIP: "127.0.0.1", HostPort: strconv.Itoa(hostPort)},
+		}
+	}
+	for _, v := range spec.Volumes {
+		config.HostConfig.Binds = append(config.HostConfig.Binds, v.Source+":"+v.Destination)
+	}
+	for _, env := range spec.EnvVars {
+		config.Env = append(config.Env, env.Name+"="+env.Value)
+	}
+
+	memory, err := spec.Resources.MemoryBytes()
+	if err != nil {
+		return dockerapi.ContainerConfig{}, err
+	}
+	config.HostConfig.Memory = memory
+	config.HostConfig.NanoCPUs = int64(spec.Resources.CPUs * 1e9)
+	config.HostConfig.PidsLimit = spec.Resources.Pids
+
+	// Limits defined by docker arguments take precedence.
+	if err := applyDockerArgs(&config, spec.DockerArgs); err != nil {
+		return dockerapi.ContainerConfig{}, err
+	}
+	return config, nil
+}
+
+// applyDockerArgs applies the subset of `docker run` arguments used by apps to the container config.
+func applyDockerArgs(config *dockerapi.ContainerConfig, args []string) error {
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return errors.Errorf("value of docker argument %s is missing", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "--user", "-u":
+			config.User = value
+		case "--env", "-e":
+			config.Env = append(config.Env, value)
+		case "--restart":
+			policy, maxRetries, _ := strings.Cut(value, ":")
+			config.HostConfig.RestartPolicy = dockerapi.RestartPolicy{Name: policy}
+			if maxRetries != "" {
+				count, err := strconv.Atoi(maxRetries)
+				if err != nil {
+					return errors.Wrapf(err, "invalid restart policy %s", value)
+				}
+				config.HostConfig.RestartPolicy.MaximumRetryCount = count
+			}
+		case "--memory", "-m":
+			memory, err := infra.ParseBytes(value)
+			if err != nil {
+				return err
+			}
+			config.HostConfig.Memory = memory
+		case "--cpus":
+			cpus, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return errors.Wrapf(err, "invalid number of cpus %s", value)
+			}
+			config.HostConfig.NanoCPUs = int64(cpus * 1e9)
+		case "--pids-limit":
+			pids, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return errors.Wrapf(err, "invalid pids limit %s", value)
+			}
+			config.HostConfig.PidsLimit = pids
+		default:
+			return errors.Errorf("docker argument %s is not supported", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+package targets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/libexec"
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
+	"github.com/CoreumFoundation/crust/exec"
+	"github.com/CoreumFoundation/crust/znet/pkg/dockerapi"
+)
+
+// cliEngine executes operations by running docker CLI. It is used if Docker Engine API is not available.
+type cliEngine struct{}
+
+func (e cliEngine) ListContainers(ctx context.Context, envName string) ([]DockerContainer, error) {
+	listBuf := &bytes.Buffer{}
+	listCmd := exec.Docker("ps", "-aq", "--no-trunc", "--filter", "label="+LabelEnv+"="+envName)
+	listCmd.Stdout = listBuf
+	if err := libexec.Exec(ctx, listCmd); err != nil {
+		return nil, err
+	}
+
+	listStr := strings.TrimSuffix(listBuf.String(), "\n")
+	if listStr == "" {
+		return nil, nil
+	}
+
+	inspectBuf := &bytes.Buffer{}
+	inspectCmd := exec.Docker(append([]string{"inspect"}, strings.Split(listStr, "\n")...)...)
+	inspectCmd.Stdout = inspectBuf
+
+	if err := libexec.Exec(ctx, inspectCmd); err != nil {
+		return nil, err
+	}
+
+	var info []dockerapi.ContainerDetails
+	if err := json.Unmarshal(inspectBuf.Bytes(), &info); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling container properties failed")
+	}
+
+	containers := make([]DockerContainer, 0, len(info))
+	for _, cInfo := range info {
+		containers = append(containers, newContainer(cInfo))
+	}
+	return containers, nil
+}
+
+func (e cliEngine) ContainerID(ctx context.Context, name string) (string, error) {
+	idBuf := &bytes.Buffer{}
+	existsCmd := exec.Docker("ps", "-aq", "--no-trunc", "--filter", "name="+name)
+	existsCmd.Stdout = idBuf
+	if err := libexec.Exec(ctx, existsCmd); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(idBuf.String(), "\n"), nil
+}
+
+func (e cliEngine) RunContainer(ctx context.Context, spec DockerContainerSpec) (string, error) {
+	idBuf := &bytes.Buffer{}
+	errBuf := &bytes.Buffer{}
+	runCmd := exec.Docker(runArgs(spec)...)
+	runCmd.Stdout = idBuf
+	runCmd.Stderr = io.MultiWriter(os.Stderr, errBuf)
+
+	if err := libexec.Exec(ctx, runCmd); err != nil {
+		if isPortConflict(errBuf.String()) {
+			return "", errors.Wrap(ErrPortConflict, err.Error())
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(idBuf.String(), "\n"), nil
+}
+
+func (e cliEngine) StartContainer(ctx context.Context, id string) error {
+	return libexec.Exec(ctx, noStdout(exec.Docker("start", id)))
+}
+
+func (e cliEngine) StopContainer(ctx context.Context, id string) error {
+	return libexec.Exec(ctx, noStdout(exec.Docker("stop", "--time", strconv.Itoa(int(stopTimeout.Seconds())), id)))
+}
+
+func (e cliEngine) RemoveContainer(ctx context.Context, info DockerContainer) error {
+	cmds := []*osexec.Cmd{}
+	if info.Running {
+		// Everything will be removed, so we don't care about graceful shutdown
+		cmds = append(cmds, noStdout(exec.Docker("kill", info.ID)))
+	}
+	return libexec.Exec(ctx, append(cmds, noStdout(exec.Docker("rm", info.ID)))...)
+}
+
+func (e cliEngine) ForceRemoveContainer(ctx context.Context, name string) error {
+	return libexec.Exec(ctx, noStdout(exec.Docker("rm", "-f", name)))
+}
+
+func (e cliEngine) PublishedPorts(ctx context.Context, id string) (map[int]int, error) {
+	portsBuf := &bytes.Buffer{}
+	portsCmd := exec.Docker("inspect", "--format", "{{json .NetworkSettings.Ports}}", id)
+	portsCmd.Stdout = portsBuf
+	if err := libexec.Exec(ctx, portsCmd); err != nil {
+		return nil, err
+	}
+
+	var bindings map[string][]dockerapi.PortBinding
+	if err := json.Unmarshal(portsBuf.Bytes(), &bindings); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling container ports failed")
+	}
+	return hostPorts(bindings)
+}
+
+func (e cliEngine) NetworkExists(ctx context.Context, network string) (bool, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Docker("network", "ls", "-q", "--no-trunc", "--filter", "name="+network)
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return false, err
+	}
+	return strings.TrimSuffix(buf.String(), "\n") != "", nil
+}
+
+func (e cliEngine) CreateNetwork(ctx context.Context, network string) error {
+	return libexec.Exec(ctx, noStdout(exec.Docker("network", "create", network)))
+}
+
+func (e cliEngine) RemoveNetwork(ctx context.Context, network string) error {
+	return libexec.Exec(ctx, noStdout(exec.Docker("network", "rm", network)))
+}
+
+func (e cliEngine) ImageExists(ctx context.Context, image string) (bool, error) {
+	imageBuf := &bytes.Buffer{}
+	imageCmd := exec.Docker("images", "-q", image)
+	imageCmd.Stdout = imageBuf
+	if err := libexec.Exec(ctx, imageCmd); err != nil {
+		return false, err
+	}
+	return imageBuf.Len() > 0, nil
+}
+
+func (e cliEngine) ImageDigests(ctx context.Context, image string) ([]string, error) {
+	digestsBuf := &bytes.Buffer{}
+	inspectCmd := exec.Docker("image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	inspectCmd.Stdout = digestsBuf
+	if err := libexec.Exec(ctx, inspectCmd); err != nil {
+		return nil, err
+	}
+
+	var repoDigests []string
+	if err := json.Unmarshal(digestsBuf.Bytes(), &repoDigests); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling image digests failed")
+	}
+	return repoDigests, nil
+}
+
+func (e cliEngine) PullImage(ctx context.Context, image string) error {
+	return libexec.Exec(ctx, exec.Docker("pull", image))
+}
+
+func (e cliEngine) TagImage(ctx context.Context, source, target string) error {
+	return libexec.Exec(ctx, exec.Docker("tag", source, target))
+}
+
+func (e cliEngine) Stats(ctx context.Context, id string) (DockerContainerStats, error) {
+	statsBuf := &bytes.Buffer{}
+	statsCmd := exec.Docker("stats", "--no-stream", "--format", "{{json .}}", id)
+	statsCmd.Stdout = statsBuf
+	if err := libexec.Exec(ctx, statsCmd); err != nil {
+		return DockerContainerStats{}, err
+	}
+
+	var stats struct {
+		CPUPerc  string
+		MemUsage string
+		PIDs     string //nolint:tagliatelle // defined by docker
+	}
+	if err := json.Unmarshal(statsBuf.Bytes(), &stats); err != nil {
+		return DockerContainerStats{}, errors.Wrap(err, "unmarshalling container stats failed")
+	}
+
+	cpuPercent, err := strconv.ParseFloat(strings.TrimSuffix(stats.CPUPerc, "%"), 64)
+	if err != nil {
+		return DockerContainerStats{}, errors.Wrapf(err, "invalid cpu usage %s", stats.CPUPerc)
+	}
+	// Format of memory usage is <usage> / <limit>
+	usage, limit, _ := strings.Cut(stats.MemUsage, " / ")
+	memory, err := parseHumanSize(usage)
+	if err != nil {
+		return DockerContainerStats{}, err
+	}
+	memoryLimit, err := parseHumanSize(limit)
+	if err != nil {
+		return DockerContainerStats{}, err
+	}
+	pids, err := strconv.ParseInt(stats.PIDs, 10, 64)
+	if err != nil {
+		return DockerContainerStats{}, errors.Wrapf(err, "invalid number of processes %s", stats.PIDs)
+	}
+
+	return DockerContainerStats{
+		CPUPercent:  cpuPercent,
+		Memory:      memory,
+		MemoryLimit: memoryLimit,
+		Pids:        pids,
+	}, nil
+}
+
+func (e cliEngine) Logs(ctx context.Context, id string, stdout, stderr io.Writer) error {
+	cmd := exec.Docker("logs", "-f", id)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return libexec.Exec(ctx, cmd)
+}
+
+func (e cliEngine) Events(ctx context.Context, envName string, fn func(event dockerapi.Event) error) error {
+	args := []string{
+		"events", "--format", "{{json .}}",
+		"--filter", "type=container",
+		"--filter", "label=" + LabelEnv + "=" + envName,
+	}
+	for _, event := range watchedEvents {
+		args = append(args, "--filter", "event="+event)
+	}
+
+	return parallel.Run(ctx, func(ctx context.Context, spawn parallel.SpawnFn) error {
+		pipeReader, pipeWriter := io.Pipe()
+		spawn("docker", parallel.Fail, func(ctx context.Context) error {
+			defer pipeWriter.Close()
+
+			cmd := exec.Docker(args...)
+			cmd.Stdout = pipeWriter
+			return libexec.Exec(ctx, cmd)
+		})
+		spawn("decoder", parallel.Fail, func(ctx context.Context) error {
+			// Pipe must be closed on error, otherwise docker blocks on writing its output forever.
+			defer pipeReader.Close()
+
+			decoder := json.NewDecoder(pipeReader)
+			for {
+				var event dockerapi.Event
+				if err := decoder.Decode(&event); err != nil {
+					if ctx.Err() != nil {
+						return errors.WithStack(ctx.Err())
+					}
+					return errors.Wrap(err, "receiving docker event failed")
+				}
+				if err := fn(event); err != nil {
+					return err
+				}
+			}
+		})
+		return nil
+	})
+}
+
+func (e cliEngine) SaveImages(ctx context.Context, images []string, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return errors.Errorf("file %s already exists", path)
+	}
+	return libexec.Exec(ctx, exec.Docker(append([]string{"save", "-o", path}, images...)...))
+}
+
+func (e cliEngine) LoadImages(ctx context.Context, path string) error {
+	return libexec.Exec(ctx, noStdout(exec.Docker("load", "-i", path)))
+}
+
+// runArgs converts container spec to arguments of `docker run`.
+func runArgs(spec DockerContainerSpec) []string {
+	args := []string{"run", "--name", spec.Name, "-d"}
+	labels := make([]string, 0, len(spec.Labels))
+	for label, value := range spec.Labels {
+		labels = append(labels, label+"="+value)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		args = append(args, "--label", label)
+	}
+	args = append(args, "--network", spec.Network)
+	if spec.User != "" {
+		args = append(args, "--user", spec.User)
+	}
+	for _, port := range sortedPorts(spec.HostPorts) {
+		args = append(args, "-p", fmt.Sprintf("127.0.0.1:%d:%d/tcp", spec.HostPorts[port], port))
+	}
+	for _, v := range spec.Volumes {
+		args = append(args, "-v", v.Source+":"+v.Destination)
+	}
+	for _, env := range spec.EnvVars {
+		args = append(args, "-e", env.Name+"="+env.Value)
+	}
+	if spec.Resources.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(spec.Resources.CPUs, 'f', -1, 64))
+	}
+	if spec.Resources.Memory != "" {
+		args = append(args, "--memory", spec.Resources.Memory)
+	}
+	if spec.Resources.Pids > 0 {
+		args = append(args, "--pids-limit", strconv.FormatInt(spec.Resources.Pids, 10))
+	}
+
+	args = append(args, spec.DockerArgs...)
+
+	if spec.Entrypoint != "" {
+		args = append(args, "--entrypoint", spec.Entrypoint)
+	}
+
+	args = append(args, spec.Image)
+	return append(args, spec.Args...)
+}
+
+// parseHumanSize parses size printed by docker CLI, e.g. 12.5MiB, 1.2GB.
+func parseHumanSize(value string) (int64, error) {
+	units := []struct {
+		Suffix     string
+		Multiplier float64
+	}{
+		{Suffix: "KiB", Multiplier: 1 << 10},
+		{Suffix: "MiB", Multiplier: 1 << 20},
+		{Suffix: "GiB", Multiplier: 1 << 30},
+		{Suffix: "TiB", Multiplier: 1 << 40},
+		{Suffix: "kB", Multiplier: 1e3},
+		{Suffix: "MB", Multiplier: 1e6},
+		{Suffix: "GB", Multiplier: 1e9},
+		{Suffix: "TB", Multiplier: 1e12},
+		{Suffix: "B", Multiplier: 1},
+	}
+
+	value = strings.TrimSpace(value)
+	for _, unit := range units {
+		if number, ok := strings.CutSuffix(value, unit.Suffix); ok {
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, errors.Wrapf(err, "invalid size %s", value)
+			}
+			return int64(n * unit.Multiplier), nil
+		}
+	}
+	return 0, errors.Errorf("invalid size %s", value)
+}
+
+func noStdout(cmd *osexec.Cmd) *osexec.Cmd {
+	cmd.Stdout = io.Discard
+	return cmd
+}
@@ -0,0 +1,336 @@
+package targets_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/infratest"
+	"github.com/CoreumFoundation/crust/znet/infra/targets"
+	"github.com/CoreumFoundation/crust/znet/pkg/dockerapi"
+)
+
+func TestDockerStop(t *testing.T) {
+	errFailure := errors.New("failure")
+
+	// Apps in the spec, web depends on api which depends on db, worker is independent.
+	dependsOn := map[string][]string{
+		"db":     nil,
+		"api":    {"db"},
+		"web":    {"api", "db"},
+		"worker": nil,
+	}
+
+	testCases := []struct {
+		name string
+		// containers lists apps having a container
+		containers []string
+		// selected lists apps to stop, all apps are stopped if it is empty
+		selected      []string
+		engineSetup   func(engine *infratest.DockerEngine)
+		expectedErr   error
+		expectedOrder [][]string
+		stopped       []string
+		removed       []string
+	}{
+		{
+			name:          "dependents_first",
+			containers:    []string{"db", "api", "web", "worker"},
+			engineSetup:   func(engine *infratest.DockerEngine) { engine.Delay("stop test-web", 50*time.Millisecond) },
+			expectedOrder: [][]string{{"web", "api"}, {"api", "db"}},
+			stopped:       []string{"db", "api", "web", "worker"},
+		},
+		{
+			name:          "missing_container",
+			containers:    []string{"db", "web"},
+			expectedOrder: [][]string{{"web", "db"}},
+			stopped:       []string{"db", "web"},
+		},
+		{
+			name:       "unexpected_container",
+			containers: []string{"db", "api", "web", "worker", "unknown"},
+			stopped:    []string{"db", "api", "web", "worker"},
+			removed:    []string{"unknown"},
+		},
+		{
+			name:          "selected_apps",
+			containers:    []string{"db", "api", "web", "worker"},
+			selected:      []string{"api", "web"},
+			expectedOrder: [][]string{{"web", "api"}},
+			stopped:       []string{"api", "web"},
+		},
+		{
+			name:        "failure",
+			containers:  []string{"db", "api", "web", "worker"},
+			engineSetup: func(engine *infratest.DockerEngine) { engine.Fail("stop test-api", errFailure) },
+			expectedErr: errFailure,
+			stopped:     []string{"web"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := infratest.NewDockerEngine()
+			for _, appName := range tc.containers {
+				engine.WithContainer("test", appName, true)
+			}
+			if tc.engineSetup != nil {
+				tc.engineSetup(engine)
+			}
+
+			ctx, docker, spec := newTestDocker(t, engine)
+			for appName, deps := range dependsOn {
+				spec.DescribeApp("test", appName).SetInfo(infra.DeploymentInfo{
+					Container: "test-" + appName,
+					Status:    infra.AppStatusRunning,
+					DependsOn: deps,
+				})
+			}
+
+			var err error
+			if len(tc.selected) > 0 {
+				err = docker.StopApps(ctx, tc.selected)
+			} else {
+				err = docker.Stop(ctx)
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			stopped := commandArgs(engine.Executed(), "stop test-")
+			for _, pair := range tc.expectedOrder {
+				assert.Less(t, lo.IndexOf(stopped, pair[0]), lo.IndexOf(stopped, pair[1]),
+					"%s must be stopped before %s", pair[0], pair[1])
+			}
+			if tc.expectedErr == nil {
+				assert.ElementsMatch(t, tc.stopped, stopped)
+			} else {
+				// Apps depending on the failed one must not be stopped.
+				assert.Subset(t, stopped, tc.stopped)
+				assert.NotContains(t, stopped, "db")
+			}
+			assert.ElementsMatch(t, tc.removed, commandArgs(engine.Executed(), "rm test-"))
+		})
+	}
+}
+
+func TestDockerDeploy(t *testing.T) {
+	errFailure := errors.New("failure")
+
+	testCases := []struct {
+		name             string
+		engineSetup      func(engine *infratest.DockerEngine)
+		expectedCommands []string
+		expectedErr      error
+	}{
+		{
+			name: "new_container",
+			engineSetup: func(engine *infratest.DockerEngine) {
+				engine.WithImages("image")
+			},
+			expectedCommands: []string{
+				"images image", "network inspect test", "network create test", "inspect test-app",
+				"run test-app", "port test-app",
+			},
+		},
+		{
+			name: "existing_container",
+			engineSetup: func(engine *infratest.DockerEngine) {
+				engine.WithImages("image")
+				engine.WithNetworks("test")
+				engine.WithContainer("test", "app", false)
+			},
+			expectedCommands: []string{
+				"images image", "network inspect test", "inspect test-app", "start test-app", "port test-app",
+			},
+		},
+		{
+			name: "existing_container_port_conflict",
+			engineSetup: func(engine *infratest.DockerEngine) {
+				engine.WithImages("image")
+				engine.WithNetworks("test")
+				engine.WithContainer("test", "app", false)
+				engine.Fail("start test-app", targets.ErrPortConflict)
+			},
+			expectedCommands: []string{
+				"images image", "network inspect test", "inspect test-app", "start test-app", "rm -f test-app",
+				"run test-app", "port test-app",
+			},
+		},
+		{
+			name: "existing_container_failure",
+			engineSetup: func(engine *infratest.DockerEngine) {
+				engine.WithImages("image")
+				engine.WithNetworks("test")
+				engine.WithContainer("test", "app", false)
+				engine.Fail("start test-app", errFailure)
+			},
+			expectedCommands: []string{"images image", "network inspect test", "inspect test-app", "start test-app"},
+			expectedErr:      errFailure,
+		},
+		{
+			name: "missing_image",
+			expectedCommands: []string{
+				"images image", "pull image", "network inspect test", "network create test", "inspect test-app",
+				"run test-app", "port test-app",
+			},
+		},
+		{
+			name: "port_conflict",
+			engineSetup: func(engine *infratest.DockerEngine) {
+				engine.WithImages("image")
+				engine.WithNetworks("test")
+				engine.Fail("run test-app", targets.ErrPortConflict)
+			},
+			expectedCommands: []string{
+				"images image", "network inspect test", "inspect test-app", "run test-app", "rm -f test-app",
+				"run test-app", "port test-app",
+			},
+		},
+		{
+			name: "pull_failure",
+			engineSetup: func(engine *infratest.DockerEngine) {
+				engine.Fail("pull image", errFailure)
+			},
+			expectedCommands: []string{"images image", "pull image"},
+			expectedErr:      errFailure,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := infratest.NewDockerEngine()
+			if tc.engineSetup != nil {
+				tc.engineSetup(engine)
+			}
+
+			ctx, docker, spec := newTestDocker(t, engine)
+			app := infratest.NewApp(spec, "app", "image")
+
+			err := docker.Deploy(ctx, infra.AppSet{app})
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "test-app", app.Info().Container)
+				assert.Equal(t, infra.AppStatusRunning, app.Info().Status)
+			}
+			assert.Equal(t, tc.expectedCommands, engine.Executed())
+		})
+	}
+}
+
+func TestDockerWatch(t *testing.T) {
+	event := func(action string, attributes map[string]string) dockerapi.Event {
+		event := dockerapi.Event{Type: "container", Action: action, TimeNano: time.Unix(10, 0).UnixNano()}
+		event.Actor.ID = "id"
+		event.Actor.Attributes = attributes
+		return event
+	}
+
+	engine := infratest.NewDockerEngine().WithEvents(
+		event("start", map[string]string{targets.LabelApp: "db"}),
+		event("die", map[string]string{targets.LabelApp: "db", "exitCode": "137"}),
+		event("die", map[string]string{"name": "other"}),
+		event("kill", map[string]string{targets.LabelApp: "api", "signal": "15"}),
+		event("die", map[string]string{targets.LabelApp: "api", "exitCode": "invalid"}),
+	)
+	ctx, docker, _ := newTestDocker(t, engine)
+
+	var events []infra.AppEvent
+	errStop := errors.New("stop")
+	err := docker.Watch(ctx, func(event infra.AppEvent) error {
+		events = append(events, event)
+		if len(events) == 4 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+
+	assert.Equal(t, []string{"events test"}, engine.Executed())
+	assert.Equal(t, []infra.AppEvent{
+		{AppName: "db", Action: infra.AppEventStart, ExitCode: -1, Time: time.Unix(10, 0)},
+		{AppName: "db", Action: infra.AppEventDie, ExitCode: 137, Time: time.Unix(10, 0)},
+		{AppName: "api", Action: infra.AppEventKill, ExitCode: -1, Time: time.Unix(10, 0)},
+		{AppName: "api", Action: infra.AppEventDie, ExitCode: -1, Time: time.Unix(10, 0)},
+	}, events)
+}
+
+func TestDockerImageDigests(t *testing.T) {
+	engine := infratest.NewDockerEngine()
+	engine.WithRepoDigests("postgres:14", "postgres@sha256:aaa", "mirror.io/postgres@sha256:bbb")
+	ctx, docker, _ := newTestDocker(t, engine)
+
+	digests, err := docker.ImageDigests(ctx, "postgres:14")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sha256:aaa", "sha256:bbb"}, digests)
+
+	digests, err = docker.ImageDigests(ctx, "cored:znet")
+	require.NoError(t, err)
+	assert.Empty(t, digests)
+
+	assert.Equal(t, []string{"image inspect postgres:14", "image inspect cored:znet"}, engine.Executed())
+}
+
+func TestDockerPullImage(t *testing.T) {
+	engine := infratest.NewDockerEngine()
+	ctx, docker, _ := newTestDocker(t, engine)
+
+	require.NoError(t, docker.PullImage(ctx, "postgres:14"))
+	require.NoError(t, docker.PullImage(ctx, "localhost:5000/postgres:14@sha256:aaa"))
+
+	assert.Equal(t, []string{
+		"pull postgres:14",
+		"pull localhost:5000/postgres@sha256:aaa",
+		"tag localhost:5000/postgres@sha256:aaa localhost:5000/postgres:14",
+	}, engine.Executed())
+}
+
+func newTestDocker(t *testing.T, engine targets.DockerEngine) (context.Context, *targets.Docker, *infra.Spec) {
+	t.Helper()
+
+	configF := &infra.ConfigFactory{
+		EnvName: "test",
+		HomeDir: t.TempDir(),
+	}
+	homeDir := filepath.Join(configF.HomeDir, configF.EnvName)
+	config := infra.Config{
+		EnvName: configF.EnvName,
+		HomeDir: homeDir,
+		AppDir:  filepath.Join(homeDir, "app"),
+	}
+	require.NoError(t, os.MkdirAll(homeDir, 0o700))
+
+	spec := infra.NewSpec(configF)
+	docker := targets.NewDockerWithEngine(config, spec, engine).(*targets.Docker)
+
+	ctx := logger.WithLogger(t.Context(), logger.New(logger.Config{
+		Format:  logger.FormatJSON,
+		Verbose: true,
+	}))
+	return ctx, docker, spec
+}
+
+// commandArgs returns the remainders of the commands starting with the prefix.
+func commandArgs(commands []string, prefix string) []string {
+	var args []string
+	for _, command := range commands {
+		if arg, ok := strings.CutPrefix(command, prefix); ok {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
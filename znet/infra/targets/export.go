@@ -0,0 +1,61 @@
+package targets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/crust/znet/infra"
+)
+
+// prepareExport runs prepare functions of all the apps, so their files are generated inside app directories.
+// Deployments with overrides applied are returned.
+func prepareExport(ctx context.Context, config infra.Config, appSet infra.AppSet) ([]infra.Deployment, error) {
+	log := logger.Get(ctx)
+
+	deployments := make([]infra.Deployment, 0, len(appSet))
+	for _, app := range appSet {
+		deployments = append(deployments, infra.ConfiguredDeployment(app, config))
+	}
+
+	// Apps are configured to talk to each other using container names, so their addresses must be known before
+	// any config file is generated.
+	for _, deployment := range deployments {
+		name := config.EnvName + "-" + deployment.Name
+		deployment.Info.SetInfo(infra.DeploymentInfo{
+			Container:         name,
+			Status:            infra.AppStatusRunning,
+			HostFromHost:      "localhost",
+			HostFromContainer: name,
+			Ports:             deployment.Ports,
+		})
+	}
+
+	for _, deployment := range deployments {
+		log.Info("Preparing app", zap.String("appName", deployment.Name))
+
+		if err := os.MkdirAll(filepath.Join(config.AppDir, deployment.Name), 0o700); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if deployment.PrepareFunc != nil {
+			if err := deployment.PrepareFunc(ctx); err != nil {
+				return nil, errors.Wrapf(err, "preparing app %s failed", deployment.Name)
+			}
+		}
+	}
+	return deployments, nil
+}
+
+// hasHealthCheck returns true if the app provides a command checking its health.
+func hasHealthCheck(appName string, deployments []infra.Deployment) bool {
+	for _, d := range deployments {
+		if d.Name == appName {
+			return len(d.HealthCheckCmd) > 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,596 @@
+package targets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"sigs.k8s.io/yaml"
+
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/pkg/dockerapi"
+)
+
+const (
+	// KubernetesDirName is the name of the directory where kubernetes manifests are stored.
+	KubernetesDirName = "k8s"
+
+	// kubernetesInitImage is the image used by init containers preparing home directory and waiting for dependencies.
+	kubernetesInitImage = "busybox:1.36"
+
+	// kubernetesHomeVolume is the name of the persistent volume storing home directory of the app.
+	kubernetesHomeVolume = "home"
+
+	// kubernetesHomeSize is the size of the persistent volume requested for each app.
+	kubernetesHomeSize = "1Gi"
+
+	// kubernetesConfigVolume is the name of the volume containing files generated by znet.
+	kubernetesConfigVolume = "config"
+
+	// kubernetesConfigMapLimit is the maximum size of ConfigMap accepted by API server.
+	kubernetesConfigMapLimit = 1024 * 1024
+
+	kubernetesHomeMount   = "/znet/home"
+	kubernetesConfigMount = "/znet/config"
+	kubernetesInitMarker  = ".znet-initialized"
+)
+
+// NewKubernetes creates new exporter producing kubernetes manifests.
+func NewKubernetes(config infra.Config) *Kubernetes {
+	return &Kubernetes{
+		config: config,
+	}
+}
+
+// Kubernetes exports the environment to kubernetes manifests, so it might be started on a cluster.
+// Each app is deployed by StatefulSet storing its home directory in a persistent volume. Files generated by znet
+// are stored in ConfigMap and copied to the volume by init container when pod is started for the first time.
+// Binaries don't fit into ConfigMap, so they are copied from the image of the app instead.
+type Kubernetes struct {
+	config infra.Config
+}
+
+// Export prepares files of all the apps and writes manifests into the directory next to the app directories.
+// Path to the directory is returned.
+func (k *Kubernetes) Export(ctx context.Context, appSet infra.AppSet) (string, error) {
+	deployments, err := prepareExport(ctx, k.config, appSet)
+	if err != nil {
+		return "", err
+	}
+
+	manifestDir := filepath.Join(k.config.HomeDir, KubernetesDirName)
+	if err := os.MkdirAll(manifestDir, 0o700); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	for _, deployment := range deployments {
+		objects, err := k.manifests(deployment, deployments)
+		if err != nil {
+			return "", err
+		}
+
+		buf := &bytes.Buffer{}
+		for _, object := range objects {
+			raw, err := yaml.Marshal(object)
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+			buf.WriteString("---\n")
+			buf.Write(raw)
+		}
+		if err := os.WriteFile(filepath.Join(manifestDir, deployment.Name+".yaml"), buf.Bytes(), 0o600); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+	return manifestDir, nil
+}
+
+// manifests returns kubernetes objects required to run the app.
+func (k *Kubernetes) manifests(app infra.Deployment, deployments []infra.Deployment) ([]any, error) {
+	name := k.config.EnvName + "-" + app.Name
+	labels := map[string]string{
+		LabelEnv: k.config.EnvName,
+		LabelApp: app.Name,
+	}
+	appDir := filepath.Join(k.config.AppDir, app.Name)
+
+	hostPorts := map[int]int{}
+	for _, port := range app.Ports {
+		hostPorts[port] = port
+	}
+	spec := newContainerSpec(k.config.EnvName, name, app, hostPorts)
+	config, err := containerConfig(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "app %s can't be exported", app.Name)
+	}
+
+	configMap, items, binaries, err := k.configMap(name, labels, appDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "app %s can't be exported", app.Name)
+	}
+
+	container := k8sContainer{
+		Name:    app.Name,
+		Image:   config.Image,
+		Command: config.Entrypoint,
+		Args:    config.Cmd,
+	}
+	for _, env := range config.Env {
+		envName, value, _ := strings.Cut(env, "=")
+		container.Env = append(container.Env, k8sEnvVar{Name: envName, Value: value})
+	}
+	portNames := k8sPortNames(app.Ports)
+	for _, port := range sortedPorts(hostPorts) {
+		container.Ports = append(container.Ports, k8sContainerPort{Name: portNames[port], ContainerPort: port})
+	}
+
+	// Directories mounted into the app container must exist in the volume even if znet hasn't created any file there.
+	var volumeDirs []string
+	for _, v := range app.Volumes {
+		subPath, err := filepath.Rel(appDir, v.Source)
+		if err != nil || strings.HasPrefix(subPath, "..") {
+			return nil, errors.Errorf("volume %s of app %s is outside of its directory", v.Source, app.Name)
+		}
+		if subPath == "." {
+			subPath = ""
+		}
+		container.VolumeMounts = append(container.VolumeMounts, k8sVolumeMount{
+			Name:      kubernetesHomeVolume,
+			MountPath: v.Destination,
+			SubPath:   subPath,
+		})
+
+		if subPath == "" {
+			continue
+		}
+		info, err := os.Stat(v.Source)
+		switch {
+		case err == nil && info.IsDir(), errors.Is(err, os.ErrNotExist):
+			volumeDirs = append(volumeDirs, subPath)
+		case err != nil:
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if len(app.HealthCheckCmd) > 0 {
+		container.ReadinessProbe = &k8sProbe{
+			Exec:             k8sExecAction{Command: app.HealthCheckCmd},
+			PeriodSeconds:    5,
+			TimeoutSeconds:   5,
+			FailureThreshold: 60,
+		}
+	}
+	if limits := k8sLimits(config.HostConfig); len(limits) > 0 {
+		container.Resources = &k8sResources{Limits: limits}
+	}
+
+	var initContainers []k8sContainer
+	if len(binaries) > 0 {
+		// Binaries are copied before the home directory is marked as initialized by the next container.
+		initContainers = append(initContainers, k8sContainer{
+			Name:    "init-binaries",
+			Image:   config.Image,
+			Command: []string{"sh", "-c", initBinariesScript(binaries)},
+			VolumeMounts: []k8sVolumeMount{
+				{Name: kubernetesHomeVolume, MountPath: kubernetesHomeMount},
+			},
+		})
+	}
+	initContainers = append(initContainers,
+		k8sContainer{
+			Name:    "init-home",
+			Image:   kubernetesInitImage,
+			Command: []string{"sh", "-c", initHomeScript(items, volumeDirs)},
+			VolumeMounts: []k8sVolumeMount{
+				{Name: kubernetesHomeVolume, MountPath: kubernetesHomeMount},
+				{Name: kubernetesConfigVolume, MountPath: kubernetesConfigMount},
+			},
+		},
+	)
+	for _, dep := range app.Requires.Dependencies {
+		waitContainer, err := k.waitContainer(dep.Name(), deployments)
+		if err != nil {
+			return nil, err
+		}
+		if waitContainer != nil {
+			initContainers = append(initContainers, *waitContainer)
+		}
+	}
+
+	objects := []any{configMap}
+
+	if len(app.Ports) > 0 {
+		service := k8sService{
+			APIVersion: "v1",
+			Kind:       "Service",
+			Metadata:   k8sObjectMeta{Name: name, Labels: labels},
+			Spec:       k8sServiceSpec{Selector: labels},
+		}
+		for _, port := range sortedPorts(hostPorts) {
+			service.Spec.Ports = append(service.Spec.Ports, k8sServicePort{
+				Name:       portNames[port],
+				Port:       port,
+				TargetPort: port,
+			})
+		}
+		objects = append(objects, service)
+	}
+
+	objects = append(objects, k8sStatefulSet{
+		APIVersion: "apps/v1",
+		Kind:       "StatefulSet",
+		Metadata:   k8sObjectMeta{Name: name, Labels: labels},
+		Spec: k8sStatefulSetSpec{
+			ServiceName: name,
+			Replicas:    1,
+			Selector:    k8sLabelSelector{MatchLabels: labels},
+			Template: k8sPodTemplate{
+				Metadata: k8sObjectMeta{Labels: labels},
+				Spec: k8sPodSpec{
+					InitContainers: initContainers,
+					Containers:     []k8sContainer{container},
+					Volumes: []k8sVolume{
+						{
+							Name: kubernetesConfigVolume,
+							ConfigMap: k8sConfigMapVolume{
+								Name:  name,
+								Items: items,
+							},
+						},
+					},
+				},
+			},
+			VolumeClaimTemplates: []k8sPersistentVolumeClaim{
+				{
+					Metadata: k8sObjectMeta{Name: kubernetesHomeVolume},
+					Spec: k8sPersistentVolumeClaimSpec{
+						AccessModes: []string{"ReadWriteOnce"},
+						Resources: k8sResources{
+							Requests: map[string]string{"storage": kubernetesHomeSize},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	return objects, nil
+}
+
+// configMap stores files generated by znet for the app. Keys of ConfigMap can't contain `/`, so files are stored
+// under generated keys and mapped to their paths by items. Executable binaries are not stored, their paths are
+// returned instead, so they are copied from the image of the app.
+func (k *Kubernetes) configMap(
+	name string,
+	labels map[string]string,
+	appDir string,
+) (k8sConfigMap, []k8sKeyToPath, []string, error) {
+	configMap := k8sConfigMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   k8sObjectMeta{Name: name, Labels: labels},
+	}
+	var items []k8sKeyToPath
+	var binaries []string
+	var size int
+	err := filepath.WalkDir(appDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return errors.Errorf("file %s is not a regular one", path)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		relPath, err := filepath.Rel(appDir, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if info.Mode()&0o111 != 0 && !utf8.Valid(content) {
+			binaries = append(binaries, filepath.ToSlash(relPath))
+			return nil
+		}
+
+		size += len(content)
+		if size > kubernetesConfigMapLimit {
+			return errors.Errorf("files exceed the limit of %d bytes of ConfigMap at %s",
+				kubernetesConfigMapLimit, relPath)
+		}
+
+		key := "file-" + strconv.Itoa(len(items))
+		items = append(items, k8sKeyToPath{Key: key, Path: filepath.ToSlash(relPath)})
+		if utf8.Valid(content) {
+			if configMap.Data == nil {
+				configMap.Data = map[string]string{}
+			}
+			configMap.Data[key] = string(content)
+			return nil
+		}
+		if configMap.BinaryData == nil {
+			configMap.BinaryData = map[string][]byte{}
+		}
+		configMap.BinaryData[key] = content
+		return nil
+	})
+	if err != nil {
+		return k8sConfigMap{}, nil, nil, err
+	}
+
+	// Image provides single version of each binary, so it can't provide the others, e.g. the ones used by upgrades.
+	for _, group := range lo.GroupBy(binaries, func(binary string) string { return filepath.Base(binary) }) {
+		if len(group) > 1 {
+			return k8sConfigMap{}, nil, nil, errors.Errorf(
+				"binaries %s can't be provided by the image, only one binary of the same name is supported",
+				strings.Join(group, ", "))
+		}
+	}
+	return configMap, items, binaries, nil
+}
+
+// waitContainer returns init container waiting until the dependency accepts connections. Service routes traffic only
+// to ready pods, so if dependency provides health check, it is waited until it is healthy.
+func (k *Kubernetes) waitContainer(depName string, deployments []infra.Deployment) (*k8sContainer, error) {
+	for _, d := range deployments {
+		if d.Name != depName {
+			continue
+		}
+		if len(d.Ports) == 0 {
+			// There is no way to check if app without ports is running.
+			return nil, nil //nolint:nilnil // nil means that there is nothing to wait for
+		}
+
+		host := k.config.EnvName + "-" + d.Name
+		port := lowestPort(d.Ports)
+		return &k8sContainer{
+			Name:  "wait-" + d.Name,
+			Image: kubernetesInitImage,
+			Command: []string{
+				"sh", "-c", fmt.Sprintf("until nc -z -w 2 %s %d; do sleep 2; done", host, port),
+			},
+		}, nil
+	}
+	return nil, errors.Errorf("dependency %s does not exist", depName)
+}
+
+// initHomeScript returns the script copying files generated by znet to the persistent volume.
+// Files are copied only once, so the state of the app is preserved when pod is restarted.
+func initHomeScript(items []k8sKeyToPath, dirs []string) string {
+	lines := []string{
+		fmt.Sprintf("if [ -f %s/%s ]; then exit 0; fi", kubernetesHomeMount, kubernetesInitMarker),
+	}
+	for _, dir := range dirs {
+		lines = append(lines, "mkdir -p "+shellQuote(kubernetesHomeMount+"/"+dir))
+	}
+	for _, item := range items {
+		dst := shellQuote(kubernetesHomeMount + "/" + item.Path)
+		lines = append(lines,
+			"mkdir -p $(dirname "+dst+")",
+			"cp "+shellQuote(kubernetesConfigMount+"/"+item.Path)+" "+dst,
+		)
+	}
+	return strings.Join(append(lines,
+		// Image of the app might use any user, so it must be able to modify files created by root.
+		"chmod -R a+rwX "+kubernetesHomeMount,
+		fmt.Sprintf("touch %s/%s", kubernetesHomeMount, kubernetesInitMarker),
+	), "\n")
+}
+
+// initBinariesScript returns the script copying binaries from the image of the app to the home directory.
+func initBinariesScript(binaries []string) string {
+	lines := []string{
+		fmt.Sprintf("if [ -f %s/%s ]; then exit 0; fi", kubernetesHomeMount, kubernetesInitMarker),
+	}
+	for _, binary := range binaries {
+		dst := shellQuote(kubernetesHomeMount + "/" + binary)
+		lines = append(lines,
+			"mkdir -p $(dirname "+dst+")",
+			"cp \"$(command -v "+shellQuote(filepath.Base(binary))+")\" "+dst,
+		)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// k8sPortNames converts names of the ports to the ones accepted by kubernetes:
+// at most 15 lowercase alphanumeric characters or `-`.
+func k8sPortNames(ports map[string]int) map[int]string {
+	names := map[int]string{}
+	for name, port := range ports {
+		var sb strings.Builder
+		for i, r := range name {
+			switch {
+			case unicode.IsUpper(r):
+				if i > 0 {
+					sb.WriteRune('-')
+				}
+				sb.WriteRune(unicode.ToLower(r))
+			case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+				sb.WriteRune(r)
+			default:
+				sb.WriteRune('-')
+			}
+		}
+		k8sName := sb.String()
+		if len(k8sName) > 15 {
+			k8sName = k8sName[:15]
+		}
+		names[port] = strings.Trim(k8sName, "-")
+	}
+	return names
+}
+
+// k8sLimits converts docker resource limits to kubernetes ones. Kubernetes doesn't limit pids per container,
+// so the pids limit is skipped.
+func k8sLimits(hostConfig dockerapi.HostConfig) map[string]string {
+	limits := map[string]string{}
+	if hostConfig.Memory > 0 {
+		limits["memory"] = strconv.FormatInt(hostConfig.Memory, 10)
+	}
+	if hostConfig.NanoCPUs > 0 {
+		limits["cpu"] = strconv.FormatInt(hostConfig.NanoCPUs/1e6, 10) + "m"
+	}
+	return limits
+}
+
+func lowestPort(ports map[string]int) int {
+	lowest := 0
+	for _, port := range ports {
+		if lowest == 0 || port < lowest {
+			lowest = port
+		}
+	}
+	return lowest
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+type k8sObjectMeta struct {
+	Name   string            `json:"name,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type k8sConfigMap struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   k8sObjectMeta     `json:"metadata"`
+	Data       map[string]string `json:"data,omitempty"`
+	BinaryData map[string][]byte `json:"binaryData,omitempty"`
+}
+
+type k8sService struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   k8sObjectMeta  `json:"metadata"`
+	Spec       k8sServiceSpec `json:"spec"`
+}
+
+type k8sServiceSpec struct {
+	Selector map[string]string `json:"selector"`
+	Ports    []k8sServicePort  `json:"ports"`
+}
+
+type k8sServicePort struct {
+	Name       string `json:"name"`
+	Port       int    `json:"port"`
+	TargetPort int    `json:"targetPort"`
+}
+
+type k8sStatefulSet struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   k8sObjectMeta      `json:"metadata"`
+	Spec       k8sStatefulSetSpec `json:"spec"`
+}
+
+type k8sStatefulSetSpec struct {
+	ServiceName          string                     `json:"serviceName"`
+	Replicas             int                        `json:"replicas"`
+	Selector             k8sLabelSelector           `json:"selector"`
+	Template             k8sPodTemplate             `json:"template"`
+	VolumeClaimTemplates []k8sPersistentVolumeClaim `json:"volumeClaimTemplates"`
+}
+
+type k8sLabelSelector struct {
+	MatchLabels map[string]string `json:"matchLabels"`
+}
+
+type k8sPodTemplate struct {
+	Metadata k8sObjectMeta `json:"metadata"`
+	Spec     k8sPodSpec    `json:"spec"`
+}
+
+type k8sPodSpec struct {
+	InitContainers []k8sContainer `json:"initContainers,omitempty"`
+	Containers     []k8sContainer `json:"containers"`
+	Volumes        []k8sVolume    `json:"volumes,omitempty"`
+}
+
+type k8sContainer struct {
+	Name           string             `json:"name"`
+	Image          string             `json:"image"`
+	Command        []string           `json:"command,omitempty"`
+	Args           []string           `json:"args,omitempty"`
+	Env            []k8sEnvVar        `json:"env,omitempty"`
+	Ports          []k8sContainerPort `json:"ports,omitempty"`
+	VolumeMounts   []k8sVolumeMount   `json:"volumeMounts,omitempty"`
+	ReadinessProbe *k8sProbe          `json:"readinessProbe,omitempty"`
+	Resources      *k8sResources      `json:"resources,omitempty"`
+}
+
+type k8sEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type k8sContainerPort struct {
+	Name          string `json:"name"`
+	ContainerPort int    `json:"containerPort"`
+}
+
+type k8sVolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	SubPath   string `json:"subPath,omitempty"`
+}
+
+type k8sProbe struct {
+	Exec             k8sExecAction `json:"exec"`
+	PeriodSeconds    int           `json:"periodSeconds"`
+	TimeoutSeconds   int           `json:"timeoutSeconds"`
+	FailureThreshold int           `json:"failureThreshold"`
+}
+
+type k8sExecAction struct {
+	Command []string `json:"command"`
+}
+
+type k8sResources struct {
+	Limits   map[string]string `json:"limits,omitempty"`
+	Requests map[string]string `json:"requests,omitempty"`
+}
+
+type k8sVolume struct {
+	Name      string             `json:"name"`
+	ConfigMap k8sConfigMapVolume `json:"configMap"`
+}
+
+type k8sConfigMapVolume struct {
+	Name  string         `json:"name"`
+	Items []k8sKeyToPath `json:"items,omitempty"`
+}
+
+type k8sKeyToPath struct {
+	Key  string `json:"key"`
+	Path string `json:"path"`
+}
+
+type k8sPersistentVolumeClaim struct {
+	Metadata k8sObjectMeta                `json:"metadata"`
+	Spec     k8sPersistentVolumeClaimSpec `json:"spec"`
+}
+
+type k8sPersistentVolumeClaimSpec struct {
+	AccessModes []string     `json:"accessModes"`
+	Resources   k8sResources `json:"resources"`
+}
@@ -0,0 +1,115 @@
+package targets_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/coreum/v6/pkg/config/constant"
+	"github.com/CoreumFoundation/crust/build/tools"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/cored"
+	"github.com/CoreumFoundation/crust/znet/infra/targets"
+)
+
+// k8sManifest contains the fields of kubernetes objects verified by the tests.
+type k8sManifest struct {
+	Kind       string            `json:"kind"`
+	Data       map[string]string `json:"data"`
+	BinaryData map[string][]byte `json:"binaryData"`
+	Spec       struct {
+		Template struct {
+			Spec struct {
+				InitContainers []struct {
+					Name    string   `json:"name"`
+					Image   string   `json:"image"`
+					Command []string `json:"command"`
+				} `json:"initContainers"`
+				Volumes []struct {
+					ConfigMap struct {
+						Items []k8sKeyToPath `json:"items"`
+					} `json:"configMap"`
+				} `json:"volumes"`
+			} `json:"spec"`
+		} `json:"template"`
+	} `json:"spec"`
+}
+
+type k8sKeyToPath struct {
+	Path string `json:"path"`
+}
+
+func TestKubernetesExportCored(t *testing.T) {
+	rootDir := t.TempDir()
+	homeDir := t.TempDir()
+	config := infra.Config{
+		EnvName:       "test",
+		HomeDir:       homeDir,
+		RootDir:       rootDir,
+		AppDir:        filepath.Join(homeDir, "app"),
+		WrapperDir:    filepath.Join(homeDir, "bin"),
+		TimeoutCommit: time.Second,
+	}
+
+	// Genesis is generated by the binary built for the local platform.
+	binDir := filepath.Join(rootDir, "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "cored"),
+		[]byte("#!/bin/sh\necho '{}' > \"$3\"\n"), 0o700))
+
+	// Binary built for docker is copied to the app directory, it exceeds the size limit of ConfigMap.
+	dockerBinDir := filepath.Join(binDir, ".cache", "cored", tools.TargetPlatformLinuxLocalArchInDocker.String(), "bin")
+	require.NoError(t, os.MkdirAll(dockerBinDir, 0o700))
+	binary := append([]byte("\x7fELF\xff"), make([]byte, 2*1024*1024)...)
+	require.NoError(t, os.WriteFile(filepath.Join(dockerBinDir, "cored"), binary, 0o700))
+
+	spec := infra.NewSpec(&infra.ConfigFactory{EnvName: config.EnvName, HomeDir: homeDir})
+	appSet, coredApp, err := apps.BuildAppSet(t.Context(), apps.NewFactory(config, spec),
+		apps.PresetDefinition([]string{apps.Profile1Cored}), "")
+	require.NoError(t, err)
+
+	ctx := logger.WithLogger(t.Context(), logger.New(logger.Config{
+		Format:  logger.FormatJSON,
+		Verbose: true,
+	}))
+	manifestDir, err := targets.NewKubernetes(config).Export(ctx, appSet)
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(filepath.Join(manifestDir, coredApp.Name()+".yaml"))
+	require.NoError(t, err)
+	manifests := lo.Map(strings.Split(strings.TrimPrefix(string(raw), "---\n"), "---\n"),
+		func(doc string, _ int) k8sManifest {
+			var manifest k8sManifest
+			require.NoError(t, yaml.Unmarshal([]byte(doc), &manifest))
+			return manifest
+		})
+	configMap, _ := lo.Find(manifests, func(m k8sManifest) bool { return m.Kind == "ConfigMap" })
+	statefulSet, _ := lo.Find(manifests, func(m k8sManifest) bool { return m.Kind == "StatefulSet" })
+
+	// Binary is not stored in ConfigMap, it is copied from the image instead.
+	assert.Empty(t, configMap.BinaryData)
+	assert.Contains(t, lo.Values(configMap.Data), "{}\n")
+	podSpec := statefulSet.Spec.Template.Spec
+	require.Len(t, podSpec.Volumes, 1)
+	paths := lo.Map(podSpec.Volumes[0].ConfigMap.Items, func(item k8sKeyToPath, _ int) string {
+		return item.Path
+	})
+	assert.Contains(t, paths, string(constant.ChainIDDev)+"/config/genesis.json")
+	assert.NotContains(t, paths, string(constant.ChainIDDev)+"/cosmovisor/genesis/bin/cored")
+
+	require.NotEmpty(t, podSpec.InitContainers)
+	initBinaries := podSpec.InitContainers[0]
+	assert.Equal(t, "init-binaries", initBinaries.Name)
+	assert.Equal(t, cored.DockerImageStandard, initBinaries.Image)
+	assert.Contains(t, initBinaries.Command[2],
+		`cp "$(command -v 'cored')" '/znet/home/`+string(constant.ChainIDDev)+`/cosmovisor/genesis/bin/cored'`)
+}
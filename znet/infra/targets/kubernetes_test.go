@@ -0,0 +1,405 @@
+package targets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/crust/znet/infra"
+)
+
+type testApp struct {
+	deployment infra.Deployment
+}
+
+func (a testApp) Type() infra.AppType {
+	return "test"
+}
+
+func (a testApp) Info() infra.DeploymentInfo {
+	return a.deployment.Info.Info()
+}
+
+func (a testApp) Name() string {
+	return a.deployment.Name
+}
+
+func (a testApp) Deployment() infra.Deployment {
+	return a.deployment
+}
+
+func (a testApp) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func TestKubernetesExport(t *testing.T) {
+	homeDir := t.TempDir()
+	config := infra.Config{
+		EnvName: "test",
+		HomeDir: homeDir,
+		AppDir:  filepath.Join(homeDir, "app"),
+	}
+	dbDir := filepath.Join(config.AppDir, "db")
+	apiDir := filepath.Join(config.AppDir, "api")
+
+	db := testApp{deployment: infra.Deployment{
+		Name:  "db",
+		Info:  &infra.AppInfo{},
+		Image: "postgres:14",
+		Ports: map[string]int{"sql": 5432},
+		EnvVarsFunc: func() []infra.EnvVar {
+			return []infra.EnvVar{{Name: "POSTGRES_USER", Value: "postgres"}}
+		},
+		Volumes: []infra.Volume{
+			{Source: filepath.Join(dbDir, "data"), Destination: "/var/lib/postgresql/data"},
+			{Source: filepath.Join(dbDir, "init.sql"), Destination: "/docker-entrypoint-initdb.d/init.sql"},
+		},
+		PrepareFunc: func(ctx context.Context) error {
+			return os.WriteFile(filepath.Join(dbDir, "init.sql"), []byte("CREATE DATABASE test;"), 0o600)
+		},
+		HealthCheckCmd: []string{"pg_isready", "-U", "postgres"},
+	}}
+	api := testApp{deployment: infra.Deployment{
+		Name:       "api",
+		Info:       &infra.AppInfo{},
+		Image:      "api:znet",
+		Entrypoint: "api",
+		Ports:      map[string]int{"grpcWeb": 9091, "rpc": 26657},
+		Requires: infra.Prerequisites{
+			Dependencies: []infra.HealthCheckCapable{db},
+		},
+		ArgsFunc: func() []string {
+			return []string{"--db", infra.JoinNetAddr("", db.Info().HostFromContainer, 5432)}
+		},
+		Volumes: []infra.Volume{
+			{Source: apiDir, Destination: "/app"},
+		},
+		DockerArgs: []string{"--memory", "512m", "--cpus", "0.5"},
+		PrepareFunc: func(ctx context.Context) error {
+			if err := os.MkdirAll(filepath.Join(apiDir, "config"), 0o700); err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(apiDir, "config", "app.toml"), []byte("debug = true"), 0o600); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Join(apiDir, "data"), 0o700); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(apiDir, "data", "key"), []byte{0xff, 0x00}, 0o600)
+		},
+	}}
+
+	ctx := logger.WithLogger(t.Context(), logger.New(logger.Config{
+		Format:  logger.FormatJSON,
+		Verbose: true,
+	}))
+
+	manifestDir, err := NewKubernetes(config).Export(ctx, infra.AppSet{db, api})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(homeDir, KubernetesDirName), manifestDir)
+
+	dbLabels := map[string]string{LabelEnv: "test", LabelApp: "db"}
+	apiLabels := map[string]string{LabelEnv: "test", LabelApp: "api"}
+	homeClaims := []k8sPersistentVolumeClaim{
+		{
+			Metadata: k8sObjectMeta{Name: "home"},
+			Spec: k8sPersistentVolumeClaimSpec{
+				AccessModes: []string{"ReadWriteOnce"},
+				Resources:   k8sResources{Requests: map[string]string{"storage": "1Gi"}},
+			},
+		},
+	}
+
+	var dbConfigMap k8sConfigMap
+	var dbService k8sService
+	var dbStatefulSet k8sStatefulSet
+	readManifest(t, filepath.Join(manifestDir, "db.yaml"), &dbConfigMap, &dbService, &dbStatefulSet)
+
+	assert.Equal(t, k8sConfigMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   k8sObjectMeta{Name: "test-db", Labels: dbLabels},
+		Data:       map[string]string{"file-0": "CREATE DATABASE test;"},
+	}, dbConfigMap)
+	assert.Equal(t, k8sService{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata:   k8sObjectMeta{Name: "test-db", Labels: dbLabels},
+		Spec: k8sServiceSpec{
+			Selector: dbLabels,
+			Ports:    []k8sServicePort{{Name: "sql", Port: 5432, TargetPort: 5432}},
+		},
+	}, dbService)
+	assert.Equal(t, k8sStatefulSet{
+		APIVersion: "apps/v1",
+		Kind:       "StatefulSet",
+		Metadata:   k8sObjectMeta{Name: "test-db", Labels: dbLabels},
+		Spec: k8sStatefulSetSpec{
+			ServiceName: "test-db",
+			Replicas:    1,
+			Selector:    k8sLabelSelector{MatchLabels: dbLabels},
+			Template: k8sPodTemplate{
+				Metadata: k8sObjectMeta{Labels: dbLabels},
+				Spec: k8sPodSpec{
+					InitContainers: []k8sContainer{
+						{
+							Name:  "init-home",
+							Image: "busybox:1.36",
+							Command: []string{"sh", "-c", strings.Join([]string{
+								"if [ -f /znet/home/.znet-initialized ]; then exit 0; fi",
+								"mkdir -p '/znet/home/data'",
+								"mkdir -p $(dirname '/znet/home/init.sql')",
+								"cp '/znet/config/init.sql' '/znet/home/init.sql'",
+								"chmod -R a+rwX /znet/home",
+								"touch /znet/home/.znet-initialized",
+							}, "\n")},
+							VolumeMounts: []k8sVolumeMount{
+								{Name: "home", MountPath: "/znet/home"},
+								{Name: "config", MountPath: "/znet/config"},
+							},
+						},
+					},
+					Containers: []k8sContainer{
+						{
+							Name:  "db",
+							Image: "postgres:14",
+							Env:   []k8sEnvVar{{Name: "POSTGRES_USER", Value: "postgres"}},
+							Ports: []k8sContainerPort{{Name: "sql", ContainerPort: 5432}},
+							VolumeMounts: []k8sVolumeMount{
+								{Name: "home", MountPath: "/var/lib/postgresql/data", SubPath: "data"},
+								{Name: "home", MountPath: "/docker-entrypoint-initdb.d/init.sql", SubPath: "init.sql"},
+							},
+							ReadinessProbe: &k8sProbe{
+								Exec:             k8sExecAction{Command: []string{"pg_isready", "-U", "postgres"}},
+								PeriodSeconds:    5,
+								TimeoutSeconds:   5,
+								FailureThreshold: 60,
+							},
+						},
+					},
+					Volumes: []k8sVolume{
+						{
+							Name: "config",
+							ConfigMap: k8sConfigMapVolume{
+								Name:  "test-db",
+								Items: []k8sKeyToPath{{Key: "file-0", Path: "init.sql"}},
+							},
+						},
+					},
+				},
+			},
+			VolumeClaimTemplates: homeClaims,
+		},
+	}, dbStatefulSet)
+
+	var apiConfigMap k8sConfigMap
+	var apiService k8sService
+	var apiStatefulSet k8sStatefulSet
+	readManifest(t, filepath.Join(manifestDir, "api.yaml"), &apiConfigMap, &apiService, &apiStatefulSet)
+
+	assert.Equal(t, k8sConfigMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   k8sObjectMeta{Name: "test-api", Labels: apiLabels},
+		Data:       map[string]string{"file-0": "debug = true"},
+		BinaryData: map[string][]byte{"file-1": {0xff, 0x00}},
+	}, apiConfigMap)
+	assert.Equal(t, k8sService{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata:   k8sObjectMeta{Name: "test-api", Labels: apiLabels},
+		Spec: k8sServiceSpec{
+			Selector: apiLabels,
+			Ports: []k8sServicePort{
+				{Name: "grpc-web", Port: 9091, TargetPort: 9091},
+				{Name: "rpc", Port: 26657, TargetPort: 26657},
+			},
+		},
+	}, apiService)
+	assert.Equal(t, k8sStatefulSet{
+		APIVersion: "apps/v1",
+		Kind:       "StatefulSet",
+		Metadata:   k8sObjectMeta{Name: "test-api", Labels: apiLabels},
+		Spec: k8sStatefulSetSpec{
+			ServiceName: "test-api",
+			Replicas:    1,
+			Selector:    k8sLabelSelector{MatchLabels: apiLabels},
+			Template: k8sPodTemplate{
+				Metadata: k8sObjectMeta{Labels: apiLabels},
+				Spec: k8sPodSpec{
+					InitContainers: []k8sContainer{
+						{
+							Name:  "init-home",
+							Image: "busybox:1.36",
+							Command: []string{"sh", "-c", strings.Join([]string{
+								"if [ -f /znet/home/.znet-initialized ]; then exit 0; fi",
+								"mkdir -p $(dirname '/znet/home/config/app.toml')",
+								"cp '/znet/config/config/app.toml' '/znet/home/config/app.toml'",
+								"mkdir -p $(dirname '/znet/home/data/key')",
+								"cp '/znet/config/data/key' '/znet/home/data/key'",
+								"chmod -R a+rwX /znet/home",
+								"touch /znet/home/.znet-initialized",
+							}, "\n")},
+							VolumeMounts: []k8sVolumeMount{
+								{Name: "home", MountPath: "/znet/home"},
+								{Name: "config", MountPath: "/znet/config"},
+							},
+						},
+						{
+							Name:    "wait-db",
+							Image:   "busybox:1.36",
+							Command: []string{"sh", "-c", "until nc -z -w 2 test-db 5432; do sleep 2; done"},
+						},
+					},
+					Containers: []k8sContainer{
+						{
+							Name:    "api",
+							Image:   "api:znet",
+							Command: []string{"api"},
+							Args:    []string{"--db", "test-db:5432"},
+							Ports: []k8sContainerPort{
+								{Name: "grpc-web", ContainerPort: 9091},
+								{Name: "rpc", ContainerPort: 26657},
+							},
+							VolumeMounts: []k8sVolumeMount{{Name: "home", MountPath: "/app"}},
+							Resources: &k8sResources{
+								Limits: map[string]string{"memory": "536870912", "cpu": "500m"},
+							},
+						},
+					},
+					Volumes: []k8sVolume{
+						{
+							Name: "config",
+							ConfigMap: k8sConfigMapVolume{
+								Name: "test-api",
+								Items: []k8sKeyToPath{
+									{Key: "file-0", Path: "config/app.toml"},
+									{Key: "file-1", Path: "data/key"},
+								},
+							},
+						},
+					},
+				},
+			},
+			VolumeClaimTemplates: homeClaims,
+		},
+	}, apiStatefulSet)
+}
+
+func TestKubernetesExportVolumeOutsideAppDir(t *testing.T) {
+	homeDir := t.TempDir()
+	config := infra.Config{
+		EnvName: "test",
+		HomeDir: homeDir,
+		AppDir:  filepath.Join(homeDir, "app"),
+	}
+
+	app := testApp{deployment: infra.Deployment{
+		Name:    "app",
+		Info:    &infra.AppInfo{},
+		Image:   "app:znet",
+		Volumes: []infra.Volume{{Source: filepath.Join(config.AppDir, "other"), Destination: "/other"}},
+	}}
+
+	ctx := logger.WithLogger(t.Context(), logger.New(logger.Config{
+		Format:  logger.FormatJSON,
+		Verbose: true,
+	}))
+
+	_, err := NewKubernetes(config).Export(ctx, infra.AppSet{app})
+	assert.ErrorContains(t, err, "is outside of its directory")
+}
+
+func TestKubernetesExportInvalidFiles(t *testing.T) {
+	binary := []byte("\x7fELF\xff")
+	testCases := []struct {
+		name        string
+		files       map[string][]byte
+		expectedErr string
+	}{
+		{
+			name:        "too_large",
+			files:       map[string][]byte{"data/large.json": []byte(strings.Repeat("a", 1024*1024+1))},
+			expectedErr: "files exceed the limit of 1048576 bytes of ConfigMap at data/large.json",
+		},
+		{
+			name: "binaries_of_same_name",
+			files: map[string][]byte{
+				"genesis/bin/app": binary,
+				"upgrade/bin/app": binary,
+			},
+			expectedErr: "only one binary of the same name is supported",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			homeDir := t.TempDir()
+			config := infra.Config{
+				EnvName: "test",
+				HomeDir: homeDir,
+				AppDir:  filepath.Join(homeDir, "app"),
+			}
+
+			appDir := filepath.Join(config.AppDir, "app")
+			app := testApp{deployment: infra.Deployment{
+				Name:  "app",
+				Info:  &infra.AppInfo{},
+				Image: "app:znet",
+				PrepareFunc: func(ctx context.Context) error {
+					for path, content := range tc.files {
+						if err := os.MkdirAll(filepath.Dir(filepath.Join(appDir, path)), 0o700); err != nil {
+							return err
+						}
+						if err := os.WriteFile(filepath.Join(appDir, path), content, 0o700); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			}}
+
+			ctx := logger.WithLogger(t.Context(), logger.New(logger.Config{
+				Format:  logger.FormatJSON,
+				Verbose: true,
+			}))
+
+			_, err := NewKubernetes(config).Export(ctx, infra.AppSet{app})
+			assert.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestK8sPortNames(t *testing.T) {
+	assert.Equal(t, map[int]string{
+		1: "rpc",
+		2: "grpc-web",
+		3: "metrics-port",
+		4: "very-long-port",
+	}, k8sPortNames(map[string]int{
+		"rpc":                1,
+		"grpcWeb":            2,
+		"metrics_port":       3,
+		"veryLongPortNumber": 4,
+	}))
+}
+
+func readManifest(t *testing.T, path string, objects ...any) {
+	t.Helper()
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	docs := strings.Split(strings.TrimPrefix(string(raw), "---\n"), "---\n")
+	require.Len(t, docs, len(objects))
+	for i, doc := range docs {
+		require.NoError(t, yaml.UnmarshalStrict([]byte(doc), objects[i]))
+	}
+}
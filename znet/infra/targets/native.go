@@ -0,0 +1,593 @@
+package targets
+
+import (
+	"context"
+	"io"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/pkg/tools"
+)
+
+const (
+	// nativeDir is the name of the directory inside environment's home where native target keeps its files.
+	nativeDir = "native"
+
+	// logsPollInterval is the interval between checks for new logs of the app.
+	logsPollInterval = 500 * time.Millisecond
+
+	// watchPollInterval is the interval between checks of the processes reported by Watch.
+	watchPollInterval = time.Second
+)
+
+// NewNative creates new target running apps as processes on the host.
+func NewNative(config infra.Config, spec *infra.Spec) infra.Target {
+	return &Native{
+		config: config,
+		spec:   spec,
+	}
+}
+
+// Native is the target running apps as processes on the host, without docker.
+//
+// Apps are configured to run in containers, so paths used in their arguments and environment variables refer
+// to the volumes mounted inside the container. For each app, native target creates a root directory containing
+// symlinks to the host directories at the paths of volumes, and paths passed to the app are moved into that root.
+type Native struct {
+	config infra.Config
+	spec   *infra.Spec
+}
+
+// Deploy deploys app set to the host.
+func (n *Native) Deploy(ctx context.Context, appSet infra.AppSet) error {
+	err := appSet.Deploy(ctx, n, n.config, n.spec)
+	if err != nil {
+		return err
+	}
+
+	return infra.WaitUntilAppSetHealthy(ctx, appSet)
+}
+
+// Stop stops running applications.
+func (n *Native) Stop(ctx context.Context) error {
+	return n.stop(ctx, lo.Keys(n.spec.Apps))
+}
+
+// StopApps stops selected applications.
+func (n *Native) StopApps(ctx context.Context, appNames []string) error {
+	for _, appName := range appNames {
+		if _, exists := n.spec.Apps[appName]; !exists {
+			return errors.Errorf("app %s does not exist in the environment", appName)
+		}
+	}
+	return n.stop(ctx, appNames)
+}
+
+// stop stops processes of the apps. Apps are stopped after all the selected apps depending on them.
+func (n *Native) stop(ctx context.Context, appNames []string) error {
+	dependencies := map[string][]chan struct{}{}
+	readyChs := map[string]chan struct{}{}
+	for _, appName := range appNames {
+		readyCh := make(chan struct{})
+		readyChs[appName] = readyCh
+
+		for _, depName := range n.spec.Apps[appName].Info().DependsOn {
+			dependencies[depName] = append(dependencies[depName], readyCh)
+		}
+	}
+
+	return parallel.Run(ctx, func(ctx context.Context, spawn parallel.SpawnFn) error {
+		for _, appName := range appNames {
+			spawn("stop."+appName, parallel.Continue, func(ctx context.Context) error {
+				defer close(readyChs[appName])
+
+				pid := n.spec.Apps[appName].Info().PID
+				if !n.isAppRunning(appName, pid) {
+					return nil
+				}
+
+				log := logger.Get(ctx).With(zap.Int("pid", pid), zap.String("appName", appName))
+				if deps := dependencies[appName]; len(deps) > 0 {
+					log.Info("Waiting for dependencies to be stopped")
+					for _, depCh := range deps {
+						select {
+						case <-ctx.Done():
+							return ctx.Err()
+						case <-depCh:
+						}
+					}
+				}
+
+				if err := n.markStopped(appName); err != nil {
+					return err
+				}
+
+				log.Info("Stopping process")
+				if err := stopProcess(ctx, pid); err != nil {
+					return errors.Wrapf(err, "stopping process of app %s failed", appName)
+				}
+				log.Info("Process stopped")
+				return nil
+			})
+		}
+		return nil
+	})
+}
+
+// Remove stops running applications and removes files created by the target.
+func (n *Native) Remove(ctx context.Context) error {
+	if err := n.Stop(ctx); err != nil {
+		return err
+	}
+	return errors.WithStack(os.RemoveAll(filepath.Join(n.config.HomeDir, nativeDir)))
+}
+
+// RemoveApps stops selected applications and removes files created for them by the target.
+func (n *Native) RemoveApps(ctx context.Context, appNames []string) error {
+	for _, appName := range appNames {
+		app, exists := n.spec.Apps[appName]
+		if !exists {
+			continue
+		}
+		if pid := app.Info().PID; n.isAppRunning(appName, pid) {
+			if err := n.markStopped(appName); err != nil {
+				return err
+			}
+			logger.Get(ctx).Info("Killing process", zap.Int("pid", pid), zap.String("appName", appName))
+			// Everything will be removed, so we don't care about graceful shutdown
+			if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+				return errors.Wrapf(err, "killing process of app %s failed", appName)
+			}
+		}
+		if err := os.RemoveAll(n.rootDir(appName)); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, file := range []string{
+			NativeLogFile(n.config, appName),
+			n.stoppedMarkerFile(appName),
+			n.pidFile(appName),
+		} {
+			if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+// Status returns runtime state of the apps.
+func (n *Native) Status(ctx context.Context) (map[string]infra.RuntimeState, error) {
+	states := map[string]infra.RuntimeState{}
+	for appName, app := range n.spec.Apps {
+		pid := app.Info().PID
+		if pid == 0 {
+			continue
+		}
+		state := "exited"
+		if n.isAppRunning(appName, pid) {
+			state = "running"
+		}
+		states[appName] = infra.RuntimeState{State: state}
+	}
+	return states, nil
+}
+
+// Logs streams logs of the app until it stops or context is canceled.
+// Process writes both stdout and stderr to the same file, so everything is written to stdout.
+func (n *Native) Logs(ctx context.Context, appName string, stdout, stderr io.Writer) error {
+	app, exists := n.spec.Apps[appName]
+	if !exists {
+		return errors.Errorf("app %s does not exist in the environment", appName)
+	}
+	pid := app.Info().PID
+	if pid == 0 {
+		return errors.Errorf("app %s hasn't been deployed", appName)
+	}
+
+	logFile, err := os.Open(NativeLogFile(n.config, appName))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer logFile.Close()
+
+	for {
+		running := n.isAppRunning(appName, pid)
+		if _, err := io.Copy(stdout, logFile); err != nil {
+			return errors.WithStack(err)
+		}
+		if !running {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-time.After(logsPollInterval):
+		}
+	}
+}
+
+// Watch reports start and exit of the processes until context is canceled. Processes are polled because
+// they might be started by other znet processes. Exit code is unknown, so -1 is always reported.
+func (n *Native) Watch(ctx context.Context, fn func(event infra.AppEvent) error) error {
+	running := map[string]int{}
+	for appName, app := range n.spec.Apps {
+		if pid := app.Info().PID; n.isAppRunning(appName, pid) {
+			running[appName] = pid
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-time.After(watchPollInterval):
+		}
+
+		for appName, app := range n.spec.Apps {
+			pid := app.Info().PID
+			runningPID, wasRunning := running[appName]
+			isRunning := n.isAppRunning(appName, pid)
+
+			var actions []string
+			switch {
+			case isRunning && (!wasRunning || runningPID != pid):
+				running[appName] = pid
+				actions = []string{infra.AppEventStart}
+			case !isRunning && wasRunning:
+				delete(running, appName)
+				if _, err := os.Stat(n.stoppedMarkerFile(appName)); err == nil {
+					actions = []string{infra.AppEventKill}
+				}
+				actions = append(actions, infra.AppEventDie)
+			}
+
+			for _, action := range actions {
+				if err := fn(infra.AppEvent{
+					AppName:  appName,
+					Action:   action,
+					ExitCode: -1,
+					Time:     time.Now(),
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// ImageExists returns true because images are not used by the native target.
+func (n *Native) ImageExists(ctx context.Context, image string) (bool, error) {
+	return true, nil
+}
+
+// ImageDigests returns no digests because images are not used by the native target.
+func (n *Native) ImageDigests(ctx context.Context, image string) ([]string, error) {
+	return nil, nil
+}
+
+// PullImage does nothing because images are not used by the native target.
+func (n *Native) PullImage(ctx context.Context, image string) error {
+	return nil
+}
+
+// DeployContainer starts the app as a process on the host.
+func (n *Native) DeployContainer(ctx context.Context, app infra.Deployment) (infra.DeploymentInfo, error) {
+	info := infra.DeploymentInfo{
+		Status:            infra.AppStatusRunning,
+		HostFromHost:      "localhost",
+		HostFromContainer: "localhost",
+		Ports:             app.Ports,
+	}
+
+	log := logger.Get(ctx).With(zap.String("appName", app.Name))
+	if pid := app.Info.Info().PID; n.isAppRunning(app.Name, pid) {
+		log.Info("Process is already running", zap.Int("pid", pid))
+		info.PID = pid
+		return info, nil
+	}
+
+	log.Info("Starting process")
+
+	if err := os.Remove(n.stoppedMarkerFile(app.Name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return infra.DeploymentInfo{}, errors.WithStack(err)
+	}
+
+	rootDir := n.rootDir(app.Name)
+	if err := n.prepareRoot(rootDir, app.Volumes); err != nil {
+		return infra.DeploymentInfo{}, err
+	}
+
+	cmd, err := n.command(rootDir, app)
+	if err != nil {
+		return infra.DeploymentInfo{}, err
+	}
+
+	logFile, err := os.OpenFile(NativeLogFile(n.config, app.Name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return infra.DeploymentInfo{}, errors.WithStack(err)
+	}
+	defer logFile.Close()
+
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// Process runs in its own session, so it is not killed together with znet and all its children
+	// might be signalled at once.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return infra.DeploymentInfo{}, errors.Wrapf(err, "starting process of app %s failed", app.Name)
+	}
+	// Start time is read before process is reaped, so it is available even if process exited immediately.
+	if err := n.writePIDFile(app.Name, cmd.Process.Pid); err != nil {
+		return infra.DeploymentInfo{}, err
+	}
+	// Process is reaped once it exits, otherwise it would be reported as running while znet is alive.
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	log.Info("Process started", zap.Int("pid", cmd.Process.Pid))
+
+	info.PID = cmd.Process.Pid
+	return info, nil
+}
+
+// rootDir returns the directory containing volumes of the app at the paths used inside container.
+func (n *Native) rootDir(appName string) string {
+	return filepath.Join(n.config.HomeDir, nativeDir, appName)
+}
+
+// stoppedMarkerFile returns the path of the file marking that the app has been stopped on request.
+func (n *Native) stoppedMarkerFile(appName string) string {
+	return filepath.Join(n.config.HomeDir, nativeDir, appName+".stopped")
+}
+
+// markStopped creates the marker file, so Watch knows that the process exited on request.
+func (n *Native) markStopped(appName string) error {
+	return errors.WithStack(os.WriteFile(n.stoppedMarkerFile(appName), nil, 0o600))
+}
+
+// pidFile returns the path of the file storing pid and start time of the process running the app.
+func (n *Native) pidFile(appName string) string {
+	return filepath.Join(n.config.HomeDir, nativeDir, appName+".pid")
+}
+
+// writePIDFile stores pid and start time of the process started for the app.
+func (n *Native) writePIDFile(appName string, pid int) error {
+	startTime, err := processStartTime(pid)
+	if err != nil {
+		return errors.Wrapf(err, "reading start time of process of app %s failed", appName)
+	}
+	return errors.WithStack(os.WriteFile(n.pidFile(appName), []byte(processID(pid, startTime)), 0o600))
+}
+
+// isAppRunning checks if the process started for the app is running. After reboot or pid wraparound
+// an unrelated process might use the same pid, so its start time must match the one stored in pid file too.
+func (n *Native) isAppRunning(appName string, pid int) bool {
+	if !isProcessRunning(pid) {
+		return false
+	}
+	storedID, err := os.ReadFile(n.pidFile(appName))
+	if err != nil {
+		return false
+	}
+	startTime, err := processStartTime(pid)
+	return err == nil && string(storedID) == processID(pid, startTime)
+}
+
+// processID returns the identifier of the process which is unique across pid reuses.
+func processID(pid int, startTime string) string {
+	return strconv.Itoa(pid) + " " + startTime
+}
+
+// prepareRoot creates symlinks to the host directories at the paths of the volumes.
+func (n *Native) prepareRoot(rootDir string, volumes []infra.Volume) error {
+	for _, v := range volumes {
+		// Docker creates missing directory when it is mounted, so we do the same.
+		if _, err := os.Stat(v.Source); errors.Is(err, os.ErrNotExist) {
+			if err := os.MkdirAll(v.Source, 0o700); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		link := filepath.Join(rootDir, v.Destination)
+		if err := os.MkdirAll(filepath.Dir(link), 0o700); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := os.Remove(link); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.WithStack(err)
+		}
+		if err := os.Symlink(v.Source, link); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// command returns the command running the app.
+func (n *Native) command(rootDir string, app infra.Deployment) (*osexec.Cmd, error) {
+	rewrite := pathRewriter(rootDir, app.Volumes)
+
+	binPath := []string{
+		filepath.Join(tools.BinariesRootPath(tools.PlatformLocal), "bin"),
+		filepath.Join(n.config.RootDir, "bin"),
+	}
+
+	var binary string
+	switch {
+	case app.Binary != "":
+		var err error
+		if binary, err = lookupBinary(app.Binary, binPath); err != nil {
+			return nil, errors.Wrapf(err, "app %s can't be run as a process", app.Name)
+		}
+	case app.Entrypoint != "":
+		binary = rewrite(app.Entrypoint)
+	default:
+		return nil, errors.Errorf("app %s can't be run as a process, neither binary nor entrypoint is defined",
+			app.Name)
+	}
+
+	var args []string
+	if app.ArgsFunc != nil {
+		args = lo.Map(app.ArgsFunc(), func(arg string, _ int) string {
+			return rewrite(arg)
+		})
+	}
+
+	cmd := osexec.Command(binary, args...)
+	cmd.Dir = rootDir
+	cmd.Env = append(os.Environ(),
+		"PATH="+strings.Join(append(binPath, os.Getenv("PATH")), ":"),
+	)
+	if app.EnvVarsFunc != nil {
+		for _, env := range app.EnvVarsFunc() {
+			cmd.Env = append(cmd.Env, env.Name+"="+rewrite(env.Value))
+		}
+	}
+
+	if policy, maxRetries, ok := restartPolicy(app.DockerArgs); ok {
+		// Restart policy is applied by the shell loop running the app.
+		cmd.Args = append([]string{"sh", "-c", restartScript(policy, maxRetries), "sh", binary}, args...)
+		cmd.Path = "/bin/sh"
+	}
+	return cmd, nil
+}
+
+// NativeLogFile returns the path of the file the output of the app running as a process is written to.
+func NativeLogFile(config infra.Config, appName string) string {
+	return filepath.Join(config.HomeDir, nativeDir, appName+".log")
+}
+
+// pathRewriter returns the function moving paths located inside volumes into the root directory of the app.
+func pathRewriter(rootDir string, volumes []infra.Volume) func(value string) string {
+	// All the paths starting from the same top-level directory as any volume are moved, because apps refer to
+	// the parent directories of volumes too, e.g. cored uses /app as home while volumes are mounted inside it.
+	topDirs := map[string]bool{}
+	for _, v := range volumes {
+		if top, _, _ := strings.Cut(strings.TrimPrefix(v.Destination, "/"), "/"); top != "" {
+			topDirs["/"+top] = true
+		}
+	}
+
+	return func(value string) string {
+		for topDir := range topDirs {
+			if value == topDir || strings.HasPrefix(value, topDir+"/") {
+				return rootDir + value
+			}
+		}
+		return value
+	}
+}
+
+// lookupBinary finds the binary in the provided directories or in PATH.
+func lookupBinary(binary string, dirs []string) (string, error) {
+	if filepath.IsAbs(binary) {
+		if _, err := os.Stat(binary); err != nil {
+			return "", errors.WithStack(err)
+		}
+		return binary, nil
+	}
+	for _, dir := range dirs {
+		path := filepath.Join(dir, binary)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	path, err := osexec.LookPath(binary)
+	if err != nil {
+		return "", errors.Wrapf(err, "binary %s not found", binary)
+	}
+	return path, nil
+}
+
+// restartPolicy returns the restart policy defined by docker arguments.
+func restartPolicy(dockerArgs []string) (string, int, bool) {
+	for i, arg := range dockerArgs {
+		value, found := strings.CutPrefix(arg, "--restart=")
+		if !found {
+			if arg != "--restart" || i+1 >= len(dockerArgs) {
+				continue
+			}
+			value = dockerArgs[i+1]
+		}
+
+		policy, maxRetriesStr, _ := strings.Cut(value, ":")
+		if policy == "no" {
+			return "", 0, false
+		}
+		maxRetries, _ := strconv.Atoi(maxRetriesStr)
+		return policy, maxRetries, true
+	}
+	return "", 0, false
+}
+
+// restartScript returns the shell script running the command passed in arguments and restarting it according
+// to the docker restart policy.
+func restartScript(policy string, maxRetries int) string {
+	script := `retries=0
+while true; do
+  "$@"
+  code=$?
+`
+	if policy == "on-failure" {
+		script += `  if [ $code -eq 0 ]; then exit 0; fi
+`
+	}
+	if maxRetries > 0 {
+		script += `  retries=$((retries+1))
+  if [ $retries -ge ` + strconv.Itoa(maxRetries) + ` ]; then exit $code; fi
+`
+	}
+	return script + `  sleep 1
+done`
+}
+
+// isProcessRunning checks if process started by the target is running. Processes are started in their own
+// sessions, so if process group of the pid is different, pid has been reused by another process.
+func isProcessRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	pgid, err := syscall.Getpgid(pid)
+	return err == nil && pgid == pid
+}
+
+// stopProcess sends SIGTERM to the process group and kills it if it doesn't exit before timeout.
+func stopProcess(ctx context.Context, pid int) error {
+	if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+
+	timeout := time.After(stopTimeout)
+	for {
+		if !isProcessRunning(pid) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-timeout:
+			if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+				return errors.WithStack(err)
+			}
+			return nil
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
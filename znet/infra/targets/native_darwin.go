@@ -0,0 +1,21 @@
+package targets
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// processStartTime returns the time process was started at.
+func processStartTime(pid int) (string, error) {
+	proc, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if proc.Proc.P_pid != int32(pid) {
+		return "", errors.Errorf("process %d does not exist", pid)
+	}
+	startTime := proc.Proc.P_starttime
+	return strconv.FormatInt(startTime.Sec, 10) + "." + strconv.FormatInt(int64(startTime.Usec), 10), nil
+}
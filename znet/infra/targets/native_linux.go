@@ -0,0 +1,25 @@
+package targets
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// processStartTime returns the time process was started at, in clock ticks since boot.
+func processStartTime(pid int) (string, error) {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	// Command name is enclosed in parentheses and might contain spaces, so fields are counted after it.
+	// Start time is the 22nd field, which is the 20th one after the command name.
+	statStr := string(stat)
+	fields := strings.Fields(statStr[strings.LastIndexByte(statStr, ')')+1:])
+	if len(fields) < 20 {
+		return "", errors.Errorf("invalid stat of process %d", pid)
+	}
+	return fields[19], nil
+}
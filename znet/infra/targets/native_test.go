@@ -0,0 +1,70 @@
+package targets
+
+import (
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/crust/znet/infra"
+)
+
+func TestNativeStatus(t *testing.T) {
+	testCases := []struct {
+		name string
+		// pidFile returns content of the pid file of the app, file is not created if it is empty
+		pidFile       func(pid int, startTime string) string
+		expectedState string
+	}{
+		{
+			name:          "running",
+			pidFile:       processID,
+			expectedState: "running",
+		},
+		{
+			name:          "missing_pid_file",
+			pidFile:       func(pid int, startTime string) string { return "" },
+			expectedState: "exited",
+		},
+		{
+			name: "pid_reused",
+			pidFile: func(pid int, startTime string) string {
+				return processID(pid, startTime+"1")
+			},
+			expectedState: "exited",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := osexec.Command("sleep", "60")
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+			require.NoError(t, cmd.Start())
+			t.Cleanup(func() {
+				_ = cmd.Process.Kill()
+				_ = cmd.Wait()
+			})
+			pid := cmd.Process.Pid
+			startTime, err := processStartTime(pid)
+			require.NoError(t, err)
+
+			homeDir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(homeDir, nativeDir), 0o700))
+			native := NewNative(infra.Config{EnvName: "test", HomeDir: homeDir},
+				infra.NewSpec(&infra.ConfigFactory{EnvName: "test", HomeDir: homeDir})).(*Native)
+			native.spec.DescribeApp("test", "app").SetInfo(infra.DeploymentInfo{PID: pid})
+			native.spec.DescribeApp("test", "not-deployed")
+			if content := tc.pidFile(pid, startTime); content != "" {
+				require.NoError(t, os.WriteFile(native.pidFile("app"), []byte(content), 0o600))
+			}
+
+			states, err := native.Status(t.Context())
+			require.NoError(t, err)
+			assert.Equal(t, map[string]infra.RuntimeState{"app": {State: tc.expectedState}}, states)
+		})
+	}
+}
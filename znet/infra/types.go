@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -15,11 +17,9 @@ import (
 	"github.com/samber/lo"
 	"go.uber.org/zap"
 
-	"github.com/CoreumFoundation/coreum-tools/pkg/libexec"
 	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
 	"github.com/CoreumFoundation/coreum-tools/pkg/must"
 	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
-	"github.com/CoreumFoundation/crust/exec"
 )
 
 // AppType represents the type of application.
@@ -49,37 +49,33 @@ func (m AppSet) Deploy(ctx context.Context, t AppTarget, config Config, spec *Sp
 	log.Info("Staring AppSet deployment, apps: " + strings.Join(lo.Map(m, func(app App, _ int) string {
 		return app.Name()
 	}), ","))
+
+	// All the images must be present before anything is deployed, so deployment doesn't fail halfway through.
+	toDeploy := lo.Filter(m, func(app App, _ int) bool {
+		appSpec, exists := spec.Apps[app.Name()]
+		return !exists || appSpec.Info().Status != AppStatusRunning
+	})
+	if err := toDeploy.ensureImages(ctx, t, config); err != nil {
+		return err
+	}
+
 	err := parallel.Run(ctx, func(ctx context.Context, spawn parallel.SpawnFn) error {
 		deploymentSlots := make(chan struct{}, runtime.NumCPU())
 		for range cap(deploymentSlots) {
 			deploymentSlots <- struct{}{}
 		}
-		imagePullSlots := make(chan struct{}, 3)
-		for range cap(imagePullSlots) {
-			imagePullSlots <- struct{}{}
-		}
 
 		deployments := map[string]struct {
-			Deployment   Deployment
-			ImageReadyCh chan struct{}
-			ReadyCh      chan struct{}
+			Deployment Deployment
+			ReadyCh    chan struct{}
 		}{}
-		images := map[string]chan struct{}{}
 		for _, app := range m {
-			deployment := app.Deployment()
-			if _, exists := images[deployment.Image]; !exists {
-				ch := make(chan struct{}, 1)
-				ch <- struct{}{}
-				images[deployment.Image] = ch
-			}
 			deployments[app.Name()] = struct {
-				Deployment   Deployment
-				ImageReadyCh chan struct{}
-				ReadyCh      chan struct{}
+				Deployment Deployment
+				ReadyCh    chan struct{}
 			}{
-				Deployment:   deployment,
-				ImageReadyCh: images[deployment.Image],
-				ReadyCh:      make(chan struct{}),
+				Deployment: ConfiguredDeployment(app, config),
+				ReadyCh:    make(chan struct{}),
 			}
 		}
 		for name, toDeploy := range deployments {
@@ -94,10 +90,6 @@ func (m AppSet) Deploy(ctx context.Context, t AppTarget, config Config, spec *Sp
 
 				log.Info("Deployment initialized")
 
-				if err := ensureDockerImage(ctx, deployment.Image, imagePullSlots, toDeploy.ImageReadyCh); err != nil {
-					return err
-				}
-
 				var depNames []string
 				if dependencies := deployment.Requires.Dependencies; len(dependencies) > 0 {
 					depNames = make([]string, 0, len(dependencies))
@@ -105,6 +97,7 @@ func (m AppSet) Deploy(ctx context.Context, t AppTarget, config Config, spec *Sp
 						depNames = append(depNames, d.Name())
 					}
 					log.Info("Waiting for dependencies", zap.Strings("dependencies", depNames))
+					ReportEvent(ctx, Event{Type: EventWaitingForDependencies, AppName: name, Dependencies: depNames})
 					for _, name := range depNames {
 						select {
 						case <-ctx.Done():
@@ -123,15 +116,34 @@ func (m AppSet) Deploy(ctx context.Context, t AppTarget, config Config, spec *Sp
 				}
 
 				log.Info("Deployment started")
+				ReportEvent(ctx, Event{Type: EventSlotAcquired, AppName: name})
 
 				info, err := deployment.Deploy(ctx, t, config)
 				if err != nil {
+					// Failure is recorded, so the next start removes the leftovers and deploys the app again.
+					log.Error("Deployment failed", zap.String("appName", name), zap.Error(err))
+					ReportEvent(ctx, Event{Type: EventDeploymentFailed, AppName: name, Error: err.Error()})
+					appInfo.SetInfo(DeploymentInfo{
+						Status:    AppStatusFailed,
+						Error:     err.Error(),
+						DependsOn: depNames,
+					})
+					if saveErr := spec.Save(); saveErr != nil {
+						log.Error("Saving spec failed", zap.Error(saveErr))
+					}
 					return err
 				}
 				info.DependsOn = depNames
 				appInfo.SetInfo(info)
 
+				// Progress is stored immediately, so apps deployed successfully are not deployed again
+				// if deployment of another one fails.
+				if err := spec.Save(); err != nil {
+					return err
+				}
+
 				log.Info("Deployment succeeded")
+				ReportEvent(ctx, Event{Type: EventDeploymentSucceeded, AppName: name})
 
 				close(toDeploy.ReadyCh)
 				deploymentSlots <- struct{}{}
@@ -146,6 +158,50 @@ func (m AppSet) Deploy(ctx context.Context, t AppTarget, config Config, spec *Sp
 	return spec.Save()
 }
 
+// ConfiguredDeployment returns the deployment of the app with resource limits and overrides defined by config
+// applied.
+func ConfiguredDeployment(app App, config Config) Deployment {
+	deployment := app.Deployment()
+	if resources, exists := config.Resources[app.Type()]; exists {
+		deployment.Resources = resources
+	}
+	if override, exists := config.Definition.Apps[app.Name()]; exists {
+		deployment = override.Apply(deployment)
+	}
+	return deployment
+}
+
+// WithDependencies returns the subset of app set containing selected apps and all their transitive dependencies.
+func (m AppSet) WithDependencies(appNames []string) (AppSet, error) {
+	selected := map[string]bool{}
+	var collect func(appName string) error
+	collect = func(appName string) error {
+		if selected[appName] {
+			return nil
+		}
+		app := m.FindAppByName(appName)
+		if app == nil {
+			return errors.Errorf("app %s does not exist in the environment", appName)
+		}
+		selected[appName] = true
+		for _, dep := range app.Deployment().Requires.Dependencies {
+			if err := collect(dep.Name()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, appName := range appNames {
+		if err := collect(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	return lo.Filter(m, func(app App, _ int) bool {
+		return selected[app.Name()]
+	}), nil
+}
+
 // FindRunningAppByName returns running app by name available in app set.
 func (m AppSet) FindRunningAppByName(appName string) App {
 	for _, app := range m {
@@ -166,50 +222,100 @@ func (m AppSet) FindAppByName(appName string) App {
 	return nil
 }
 
-func ensureDockerImage(ctx context.Context, image string, slots, readyCh chan struct{}) error {
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case _, ok := <-readyCh:
-		// If <-imageReadyCh blocks it means another goroutine is pulling the image
-		if !ok {
-			// Channel is closed, image has been already pulled, we are ready to go
-			return nil
+// Images returns sorted list of docker images used by the apps.
+func (m AppSet) Images(config Config) []string {
+	images := lo.Uniq(lo.Map(m, func(app App, _ int) string {
+		return ConfiguredDeployment(app, config).Image
+	}))
+	sort.Strings(images)
+	return images
+}
+
+// MissingImages returns sorted list of docker images used by the apps which don't exist in the target.
+func (m AppSet) MissingImages(ctx context.Context, t AppTarget, config Config) ([]string, error) {
+	var missing []string
+	for _, image := range m.Images(config) {
+		exists, err := t.ImageExists(ctx, image)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, image)
 		}
 	}
+	return missing, nil
+}
 
-	log := logger.Get(ctx).With(zap.String("image", image))
-
-	imageBuf := &bytes.Buffer{}
-	imageCmd := exec.Docker("images", "-q", image)
-	imageCmd.Stdout = imageBuf
-	if err := libexec.Exec(ctx, imageCmd); err != nil {
-		return errors.Wrapf(err, "failed to list image '%s'", image)
+// ensureImages pulls missing images of the apps and verifies them against the lock file. In offline mode error is
+// returned if any image is missing.
+func (m AppSet) ensureImages(ctx context.Context, t AppTarget, config Config) error {
+	lock, err := loadImageLock(ctx, config)
+	if err != nil {
+		return err
 	}
-	if imageBuf.Len() > 0 {
-		log.Info("Docker image exists")
-		close(readyCh)
-		return nil
+	images := m.Images(config)
+	if lock != nil {
+		if err := lock.checkPinned(ctx, config, images); err != nil {
+			return err
+		}
 	}
 
-	log.Info("Waiting for free slot for pulling the docker image")
-
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-slots:
+	if err := m.pullImages(ctx, t, config, lock); err != nil {
+		return err
 	}
 
-	log.Info("Pulling docker image")
+	if lock != nil {
+		return lock.verify(ctx, t, config, images)
+	}
+	return nil
+}
 
-	if err := libexec.Exec(ctx, exec.Docker("pull", image)); err != nil {
-		return errors.Wrapf(err, "failed to pull docker image '%s'", image)
+// pullImages pulls missing images of the apps. Images pinned in the lock file are pulled by digest.
+func (m AppSet) pullImages(ctx context.Context, t AppTarget, config Config, lock ImageLock) error {
+	missing, err := m.MissingImages(ctx, t, config)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if config.Offline {
+		return errors.Errorf("docker images %s are missing, load them using `znet images load` first",
+			strings.Join(missing, ", "))
 	}
 
-	log.Info("Image pulled")
-	close(readyCh)
-	slots <- struct{}{}
-	return nil
+	log := logger.Get(ctx)
+	log.Info("Pulling missing docker images", zap.Strings("images", missing))
+
+	return parallel.Run(ctx, func(ctx context.Context, spawn parallel.SpawnFn) error {
+		pullSlots := make(chan struct{}, 3)
+		for range cap(pullSlots) {
+			pullSlots <- struct{}{}
+		}
+		for _, image := range missing {
+			spawn("pull."+image, parallel.Continue, func(ctx context.Context) error {
+				select {
+				case <-ctx.Done():
+					return errors.WithStack(ctx.Err())
+				case <-pullSlots:
+				}
+				defer func() {
+					pullSlots <- struct{}{}
+				}()
+
+				log.Info("Pulling docker image", zap.String("image", image))
+				ReportEvent(ctx, Event{Type: EventImagePullStarted, Image: image})
+				if err := t.PullImage(ctx, lock.PinnedImage(image)); err != nil {
+					ReportEvent(ctx, Event{Type: EventImagePullFinished, Image: image, Error: err.Error()})
+					return err
+				}
+				ReportEvent(ctx, Event{Type: EventImagePullFinished, Image: image})
+				log.Info("Image pulled", zap.String("image", image))
+				return nil
+			})
+		}
+		return nil
+	})
 }
 
 // DeploymentInfo contains info about deployed application.
@@ -217,6 +323,9 @@ type DeploymentInfo struct {
 	// Container stores the name of the docker container where app is running - present only for apps running in docker
 	Container string `json:"container,omitempty"`
 
+	// PID stores the ID of the process running the app - present only for apps running as host processes
+	PID int `json:"pid,omitempty"`
+
 	// HostFromHost is the host's hostname application binds to
 	HostFromHost string `json:"hostFromHost,omitempty"`
 
@@ -226,13 +335,37 @@ type DeploymentInfo struct {
 	// Status indicates the status of the application
 	Status AppStatus `json:"status"`
 
+	// Error is the error which caused the deployment to fail - present only for failed apps
+	Error string `json:"error,omitempty"`
+
 	// DependsOn is the list of other application which must be started before this one or stopped after this one
 	DependsOn []string `json:"dependsOn,omitempty"`
 
 	// Ports describe network ports provided by the application
 	Ports map[string]int `json:"ports,omitempty"`
+
+	// HostPorts maps ports provided by the application to the ports they are published on by the host
+	HostPorts map[int]int `json:"hostPorts,omitempty"`
 }
 
+// HostPort returns the port on the host where the application's port is published.
+// If port is not mapped, it is assumed that it is published on the same port.
+func (i DeploymentInfo) HostPort(port int) int {
+	if hostPort, exists := i.HostPorts[port]; exists {
+		return hostPort
+	}
+	return port
+}
+
+// Names of the targets apps might be deployed to.
+const (
+	// TargetDocker runs apps in docker containers.
+	TargetDocker = "docker"
+
+	// TargetNative runs apps as processes on the host.
+	TargetNative = "native"
+)
+
 // Target represents target of deployment from the perspective of znet.
 type Target interface {
 	// Deploy deploys app set to the target
@@ -241,14 +374,89 @@ type Target interface {
 	// Stop stops apps in the app set
 	Stop(ctx context.Context) error
 
+	// StopApps stops selected apps, dependent apps must be included in the list
+	StopApps(ctx context.Context, appNames []string) error
+
 	// Remove removes apps in the app set
 	Remove(ctx context.Context) error
+
+	// RemoveApps removes selected apps
+	RemoveApps(ctx context.Context, appNames []string) error
+
+	// Status returns runtime state of deployed apps indexed by app name
+	Status(ctx context.Context) (map[string]RuntimeState, error)
+
+	// Logs streams logs of the app until it stops or context is canceled
+	Logs(ctx context.Context, appName string, stdout, stderr io.Writer) error
+
+	// Watch reports changes of runtime state of the apps until context is canceled
+	Watch(ctx context.Context, fn func(event AppEvent) error) error
+}
+
+// Actions reported by AppEvent.
+const (
+	// AppEventStart is reported when application starts.
+	AppEventStart = "start"
+
+	// AppEventKill is reported when application is being stopped on request.
+	AppEventKill = "kill"
+
+	// AppEventDie is reported when application exits.
+	AppEventDie = "die"
+)
+
+// AppEvent is the change of application's runtime state reported by the target.
+type AppEvent struct {
+	// AppName is the name of the application
+	AppName string
+
+	// Action is the action taken, one of AppEvent* constants
+	Action string
+
+	// ExitCode is the exit code of the application, reported together with AppEventDie.
+	// It is -1 if target doesn't know it.
+	ExitCode int
+
+	// Time is the time of the event
+	Time time.Time
+}
+
+// RuntimeState describes the state of deployed application reported by the target.
+type RuntimeState struct {
+	// State is the state of the application, e.g. running, exited
+	State string `json:"state"`
+
+	// ExitCode is the exit code of the application if it is not running
+	ExitCode int `json:"exitCode,omitempty"`
+
+	// Usage reports resources used by the running application, if target supports it
+	Usage *ResourceUsage `json:"usage,omitempty"`
 }
 
 // AppTarget represents target of deployment from the perspective of application.
 type AppTarget interface {
 	// DeployContainer deploys container to the target
 	DeployContainer(ctx context.Context, app Deployment) (DeploymentInfo, error)
+
+	// ImageExists checks if container image is available in the target
+	ImageExists(ctx context.Context, image string) (bool, error)
+
+	// PullImage pulls container image to the target. Image pinned to the digest, in the form <image>@<digest>,
+	// is pulled by the digest and stored under the original reference.
+	PullImage(ctx context.Context, image string) error
+
+	// ImageDigests returns digests of container image available in the target. Empty list is returned if digest is
+	// unknown, e.g. because image has been built locally.
+	ImageDigests(ctx context.Context, image string) ([]string, error)
+}
+
+// ImageArchiver is implemented by targets able to move container images between machines.
+type ImageArchiver interface {
+	// SaveImages saves images to the tarball
+	SaveImages(ctx context.Context, images []string, path string) error
+
+	// LoadImages loads images from the tarball created by SaveImages
+	LoadImages(ctx context.Context, path string) error
 }
 
 // Prerequisites specifies list of other apps which have to be healthy before app may be started.
@@ -318,6 +526,21 @@ type Deployment struct {
 
 	// DockerArgs is the arguments passed to docker when creating the container
 	DockerArgs []string
+
+	// Resources defines limits of resources available to the application
+	Resources Resources
+
+	// RestartPolicy defines if supervisor restarts the application after it crashes
+	RestartPolicy RestartPolicy
+
+	// Binary is the binary executed by targets running apps as host processes, in place of the image's default
+	// entrypoint. It is either an absolute path or the name of the binary available in the tool cache.
+	// If it is not set, Entrypoint is executed.
+	Binary string
+
+	// HealthCheckCmd is the command executed inside the container to check if application is healthy.
+	// It is used by targets which can't execute health checks implemented in go, like docker compose.
+	HealthCheckCmd []string
 }
 
 // Deploy deploys container to the target.
@@ -330,6 +553,7 @@ func (app Deployment) Deploy(ctx context.Context, target AppTarget, config Confi
 	if err != nil {
 		return DeploymentInfo{}, err
 	}
+	ReportEvent(ctx, Event{Type: EventContainerStarted, AppName: app.Name})
 
 	if err := app.postprocess(ctx, info); err != nil {
 		return DeploymentInfo{}, err
@@ -376,12 +600,64 @@ type ConfigFactory struct {
 	// Profiles defines the list of application profiles to run
 	Profiles []string
 
+	// ProfilesSet is true if profiles were passed explicitly instead of using the default ones
+	ProfilesSet bool
+
 	// TimeoutCommit allows to define custom timeout commit for all used chains.
 	TimeoutCommit time.Duration
 
 	// CoredVersion defines the version of the cored to be used on start
 	CoredVersion string
 
+	// EnvFile is the path to the file containing environment definition
+	EnvFile string
+
+	// Definition is the environment definition loaded from EnvFile
+	Definition *EnvDefinition
+
+	// Validators, Sentries, Seeds and FullNodes override the numbers of cored nodes defined by profiles or EnvFile,
+	// negative value keeps the defined number
+	Validators int
+	Sentries   int
+	Seeds      int
+	FullNodes  int
+
+	// StakeWeights overrides the stake weights of cored validators defined by EnvFile
+	StakeWeights []int
+
+	// CoredNodeVersions overrides the versions of cored nodes defined by EnvFile, indexed by node name
+	CoredNodeVersions map[string]string
+
+	// GenesisPatchFile is the path to the file containing patch applied to genesis of cored network
+	GenesisPatchFile string
+
+	// GenesisPatch is the patch loaded from GenesisPatchFile
+	GenesisPatch json.RawMessage
+
+	// GenesisFixtureFile is the path to the file containing fixture included in genesis of cored network
+	GenesisFixtureFile string
+
+	// GenesisFixture is the fixture loaded from GenesisFixtureFile, converted to JSON
+	GenesisFixture json.RawMessage
+
+	// Target is the name of the target apps are deployed to
+	Target string
+
+	// Resources overrides default resource limits of applications, indexed by app type
+	Resources map[AppType]Resources
+
+	// Offline disables pulling of docker images
+	Offline bool
+
+	// AllowUnpinnedImages allows using docker images which are not pinned in the lock file or don't match it
+	AllowUnpinnedImages bool
+
+	// Events is the file or socket deployment events are written to
+	Events string
+
+	// Progress enables printing the progress of deployment
+	Progress bool
+
 	// HomeDir is the path where all the files are kept
 	HomeDir string
 
@@ -399,12 +675,31 @@ type ConfigFactory struct {
 
 	// CoredUpgrades is the map of cored upgrades to binary names
 	CoredUpgrades map[string]string
+
+	// Extensions is the list of extensions adding custom applications
+	Extensions []Extension
+
+	// TestGroups is the list of integration test groups to run
+	TestGroups []string
+
+	// TestFilter is the regular expression used to select tests to run
+	TestFilter string
+
+	// TestTimeout is the maximum time a single test group may run
+	TestTimeout time.Duration
+
+	// TestBinariesDir is the directory containing prebuilt test binaries, named after test groups
+	TestBinariesDir string
 }
 
 // NewConfigFactory creates new ConfigFactory.
 func NewConfigFactory() *ConfigFactory {
 	return &ConfigFactory{
 		CoredUpgrades: make(map[string]string),
+		Validators:    -1,
+		Sentries:      -1,
+		Seeds:         -1,
+		FullNodes:     -1,
 	}
 }
 
@@ -419,6 +714,24 @@ func ConfigFactoryWithCoredUpgrades(upgrades map[string]string) ConfigFactoryOpt
 	}
 }
 
+// ConfigFactoryWithExtensions is the option to register extensions adding custom applications.
+func ConfigFactoryWithExtensions(extensions ...Extension) ConfigFactoryOption {
+	return func(inConfig *ConfigFactory) *ConfigFactory {
+		inConfig.Extensions = append(inConfig.Extensions, extensions...)
+		return inConfig
+	}
+}
+
+// Extension adds custom applications to the environment. Extension is enabled by its profile.
+type Extension struct {
+	// Profile is the name of the profile enabling the extension
+	Profile string
+
+	// BuildFunc builds applications of the extension. Applications built so far are passed,
+	// so the new ones may depend on them. Apps must be described in the spec using spec.DescribeApp.
+	BuildFunc func(ctx context.Context, config Config, spec *Spec, appSet AppSet) (AppSet, error)
+}
+
 // Spec describes running environment.
 type Spec struct {
 	specFile string
@@ -427,12 +740,24 @@ type Spec struct {
 	// Profiles is the list of deployed application profiles
 	Profiles []string `json:"profiles"`
 
+	// Definition is the definition of the environment, present only if environment was created from file
+	Definition *EnvDefinition `json:"definition,omitempty"`
+
 	// TimeoutCommit allows to define custom timeout commit for all used chains.
 	TimeoutCommit time.Duration `json:"timeoutCommit"`
 
+	// GenesisPatch is the JSON patch or JSON merge patch applied to genesis of cored network
+	GenesisPatch json.RawMessage `json:"genesisPatch,omitempty"`
+
+	// GenesisFixture is the fixture declaring balances, assets and messages included in genesis of cored network
+	GenesisFixture json.RawMessage `json:"genesisFixture,omitempty"`
+
 	// Env is the name of env
 	Env string `json:"env"`
 
+	// Target is the name of the target apps are deployed to
+	Target string `json:"target,omitempty"`
+
 	mu sync.Mutex
 
 	// Apps is the description of running apps
@@ -460,33 +785,91 @@ func NewSpec(configF *ConfigFactory) *Spec {
 		specFile: specFile,
 		configF:  configF,
 
-		Profiles:      configF.Profiles,
-		TimeoutCommit: configF.TimeoutCommit,
-		Env:           configF.EnvName,
-		Apps:          map[string]*AppInfo{},
+		Profiles:       configF.Profiles,
+		Definition:     configF.Definition,
+		TimeoutCommit:  configF.TimeoutCommit,
+		GenesisPatch:   configF.GenesisPatch,
+		GenesisFixture: configF.GenesisFixture,
+		Env:            configF.EnvName,
+		Target:         configF.Target,
+		Apps:           map[string]*AppInfo{},
+	}
+	if spec.Target == "" {
+		spec.Target = TargetDocker
+	}
+	if spec.Definition != nil {
+		spec.Profiles = nil
 	}
 	return spec
 }
 
+// InheritDefinition makes the config use the profiles and definition of the existing environment, unless the new
+// ones are passed explicitly.
+func InheritDefinition(configF *ConfigFactory) {
+	if configF.EnvFile != "" || configF.ProfilesSet {
+		return
+	}
+	spec := NewSpec(configF)
+	if len(spec.Apps) == 0 {
+		return
+	}
+	configF.Profiles = spec.Profiles
+	configF.Definition = spec.Definition
+}
+
 // Verify verifies that env and profiles in config matches the ones in spec.
 func (s *Spec) Verify() error {
 	if s.Env != s.configF.EnvName {
 		return errors.Errorf("env mismatch, spec: %s, config: %s", s.Env, s.configF.EnvName)
 	}
-	if !lo.Every(s.Profiles, s.configF.Profiles) {
+	if s.configF.Definition != nil &&
+		(s.Definition == nil || s.Definition.String() != s.configF.Definition.String()) {
+		return errors.New("environment definition mismatch, remove the environment to apply the new definition")
+	}
+	if s.Definition == nil && !lo.Every(s.Profiles, s.configF.Profiles) {
 		return errors.Errorf(
 			"profile mismatch, spec: %s, config: %s",
 			strings.Join(s.Profiles, ","),
 			strings.Join(s.configF.Profiles, ","),
 		)
 	}
+	if s.TargetName() != TargetDocker && s.TargetName() != TargetNative {
+		return errors.Errorf("unknown target %s", s.TargetName())
+	}
+	if s.configF.Target != "" && s.configF.Target != s.TargetName() {
+		return errors.Errorf("target mismatch, spec: %s, config: %s", s.TargetName(), s.configF.Target)
+	}
 	if s.TimeoutCommit != s.configF.TimeoutCommit {
 		return errors.Errorf("timeout commit mismatch, spec: %s, config: %s", s.TimeoutCommit, s.configF.TimeoutCommit)
 	}
+	if s.configF.GenesisPatch != nil && compactJSON(s.GenesisPatch) != compactJSON(s.configF.GenesisPatch) {
+		return errors.New("genesis patch mismatch, remove the environment to apply the new patch")
+	}
+	if s.configF.GenesisFixture != nil && compactJSON(s.GenesisFixture) != compactJSON(s.configF.GenesisFixture) {
+		return errors.New("genesis fixture mismatch, remove the environment to apply the new fixture")
+	}
 
 	return nil
 }
 
+// compactJSON returns JSON without insignificant whitespaces, so documents formatted differently may be compared.
+func compactJSON(data []byte) string {
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, data); err != nil {
+		return string(data)
+	}
+	return buf.String()
+}
+
+// TargetName returns the name of the target apps are deployed to. Environments created before targets were
+// recorded in the spec run in docker.
+func (s *Spec) TargetName() string {
+	if s.Target == "" {
+		return TargetDocker
+	}
+	return s.Target
+}
+
 // DescribeApp adds description of running app.
 func (s *Spec) DescribeApp(appType AppType, name string) *AppInfo {
 	s.mu.Lock()
@@ -513,16 +896,116 @@ func (s *Spec) DescribeApp(appType AppType, name string) *AppInfo {
 	return appDesc
 }
 
+// RemoveApp removes description of the app from the spec.
+func (s *Spec) RemoveApp(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.Apps, name)
+}
+
+// RunningDependents returns names of running apps which transitively depend on the selected ones.
+// Selected apps are not included in the result.
+func (s *Spec) RunningDependents(appNames []string) []string {
+	selected := lo.SliceToMap(appNames, func(appName string) (string, bool) {
+		return appName, true
+	})
+
+	var dependents []string
+	for found := true; found; {
+		found = false
+		for appName, app := range s.Apps {
+			info := app.Info()
+			if selected[appName] || info.Status != AppStatusRunning {
+				continue
+			}
+			if lo.SomeBy(info.DependsOn, func(depName string) bool { return selected[depName] }) {
+				selected[appName] = true
+				dependents = append(dependents, appName)
+				found = true
+			}
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// Reload updates descriptions of apps with the content of the spec file, so changes done by other znet processes
+// are visible. Descriptions are updated in place, so apps referring to them observe the changes and the map of apps
+// is not modified. Apps missing in the file are marked as not deployed.
+func (s *Spec) Reload() error {
+	specRaw, err := os.ReadFile(s.specFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var stored struct {
+		Apps map[string]*AppInfo `json:"apps"`
+	}
+	if err := json.Unmarshal(specRaw, &stored); err != nil {
+		return errors.Wrapf(err, "unmarshalling spec %s failed", s.specFile)
+	}
+
+	for name, app := range s.Apps {
+		if storedApp, exists := stored.Apps[name]; exists {
+			app.setData(storedApp.data)
+			continue
+		}
+		app.SetInfo(DeploymentInfo{})
+	}
+	return nil
+}
+
 // String converts spec to json string.
 func (s *Spec) String() string {
 	return string(must.Bytes(json.MarshalIndent(s, "", "  ")))
 }
 
-// Save saves spec into file.
+// Save saves spec into file. It is safe to call it concurrently.
 func (s *Spec) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	return os.WriteFile(s.specFile, []byte(s.String()), 0o600)
 }
 
+// RestartPolicy defines when supervisor restarts the application after it exits unexpectedly.
+type RestartPolicy string
+
+// Restart policies.
+const (
+	// RestartNever means application is never restarted.
+	RestartNever RestartPolicy = "never"
+
+	// RestartOnFailure means application is restarted if it exits with non-zero code.
+	RestartOnFailure RestartPolicy = "on-failure"
+
+	// RestartAlways means application is restarted whenever it exits.
+	RestartAlways RestartPolicy = "always"
+)
+
+// Validate verifies that the policy is known.
+func (p RestartPolicy) Validate() error {
+	switch p {
+	case RestartNever, RestartOnFailure, RestartAlways:
+		return nil
+	default:
+		return errors.Errorf("unknown restart policy %s, available ones: %s, %s, %s",
+			p, RestartNever, RestartOnFailure, RestartAlways)
+	}
+}
+
+// ShouldRestart returns true if application exited with the code should be restarted.
+func (p RestartPolicy) ShouldRestart(exitCode int) bool {
+	switch p {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exitCode != 0
+	default:
+		return false
+	}
+}
+
 // AppStatus describes current status of an application.
 type AppStatus string
 
@@ -535,6 +1018,9 @@ const (
 
 	// AppStatusStopped means app was running but now is stopped.
 	AppStatusStopped AppStatus = "stopped"
+
+	// AppStatusFailed means that deployment of the app failed, it is deployed again by the next start.
+	AppStatusFailed AppStatus = "failed"
 )
 
 type appInfoData struct {
@@ -543,6 +1029,27 @@ type appInfoData struct {
 
 	// Info stores app deployment information
 	Info DeploymentInfo `json:"info"`
+
+	// Version is the effective version of the app's binary, present only for apps running versioned binaries
+	Version string `json:"version,omitempty"`
+
+	// Crashes stores the latest crashes of the app detected by supervisor
+	Crashes []Crash `json:"crashes,omitempty"`
+}
+
+// maxCrashes is the number of latest crashes stored for each app.
+const maxCrashes = 10
+
+// Crash describes unexpected exit of the application.
+type Crash struct {
+	// Time is the time when application exited
+	Time time.Time `json:"time"`
+
+	// ExitCode is the exit code of the application, -1 if it is unknown
+	ExitCode int `json:"exitCode"`
+
+	// Logs are the last lines of the application's logs
+	Logs []string `json:"logs,omitempty"`
 }
 
 // AppInfo describes app running in environment.
@@ -576,6 +1083,48 @@ func (ai *AppInfo) Info() DeploymentInfo {
 	return ai.data.Info
 }
 
+// SetVersion sets the effective version of the app's binary.
+func (ai *AppInfo) SetVersion(version string) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+
+	ai.data.Version = version
+}
+
+// Version returns the effective version of the app's binary.
+func (ai *AppInfo) Version() string {
+	ai.mu.RLock()
+	defer ai.mu.RUnlock()
+
+	return ai.data.Version
+}
+
+func (ai *AppInfo) setData(data appInfoData) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+
+	ai.data = data
+}
+
+// AddCrash records the crash of the app. Only the latest crashes are kept.
+func (ai *AppInfo) AddCrash(crash Crash) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+
+	ai.data.Crashes = append(ai.data.Crashes, crash)
+	if len(ai.data.Crashes) > maxCrashes {
+		ai.data.Crashes = ai.data.Crashes[len(ai.data.Crashes)-maxCrashes:]
+	}
+}
+
+// Crashes returns the latest crashes of the app.
+func (ai *AppInfo) Crashes() []Crash {
+	ai.mu.RLock()
+	defer ai.mu.RUnlock()
+
+	return append([]Crash{}, ai.data.Crashes...)
+}
+
 // MarshalJSON marshals data to JSON.
 func (ai *AppInfo) MarshalJSON() ([]byte, error) {
 	ai.mu.RLock()
@@ -0,0 +1,469 @@
+package infra_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/infratest"
+)
+
+func TestAppSetDeploy(t *testing.T) {
+	errFailure := errors.New("failure")
+
+	testCases := []struct {
+		name string
+		// setup builds the app set and configures the target
+		setup func(spec *infra.Spec, target *infratest.Target) infra.AppSet
+		// running lists apps marked as running in the spec before deployment
+		running       []string
+		expectedErr   error
+		expectedOrder [][]string
+		notDeployed   []string
+		expectedPulls []string
+	}{
+		{
+			name: "dependencies_first",
+			setup: func(spec *infra.Spec, target *infratest.Target) infra.AppSet {
+				target.WithImages("image").Delay(infratest.MethodDeployContainer, "db", 50*time.Millisecond)
+				db := infratest.NewApp(spec, "db", "image")
+				api := infratest.NewApp(spec, "api", "image", db)
+				web := infratest.NewApp(spec, "web", "image", api, db)
+				return infra.AppSet{web, api, db}
+			},
+			expectedOrder: [][]string{{"db", "api"}, {"api", "web"}},
+		},
+		{
+			name: "running_apps_skipped",
+			setup: func(spec *infra.Spec, target *infratest.Target) infra.AppSet {
+				target.WithImages("image")
+				db := infratest.NewApp(spec, "db", "image")
+				api := infratest.NewApp(spec, "api", "image", db)
+				return infra.AppSet{db, api}
+			},
+			running:     []string{"db"},
+			notDeployed: []string{"db"},
+		},
+		{
+			name: "image_pulled_once",
+			setup: func(spec *infra.Spec, target *infratest.Target) infra.AppSet {
+				target.WithImages("existing").Delay(infratest.MethodPullImage, "shared", 50*time.Millisecond)
+				return infra.AppSet{
+					infratest.NewApp(spec, "app1", "shared"),
+					infratest.NewApp(spec, "app2", "shared"),
+					infratest.NewApp(spec, "app3", "shared"),
+					infratest.NewApp(spec, "app4", "existing"),
+				}
+			},
+			expectedPulls: []string{"shared"},
+		},
+		{
+			name: "deployment_failure",
+			setup: func(spec *infra.Spec, target *infratest.Target) infra.AppSet {
+				target.WithImages("image").Fail(infratest.MethodDeployContainer, "db", errFailure)
+				db := infratest.NewApp(spec, "db", "image")
+				api := infratest.NewApp(spec, "api", "image", db)
+				return infra.AppSet{db, api}
+			},
+			expectedErr: errFailure,
+			notDeployed: []string{"api"},
+		},
+		{
+			name: "pull_failure",
+			setup: func(spec *infra.Spec, target *infratest.Target) infra.AppSet {
+				target.WithImages("existing").Fail(infratest.MethodPullImage, "missing", errFailure)
+				return infra.AppSet{
+					infratest.NewApp(spec, "app1", "missing"),
+					infratest.NewApp(spec, "app2", "missing"),
+					infratest.NewApp(spec, "app3", "existing"),
+				}
+			},
+			expectedErr:   errFailure,
+			notDeployed:   []string{"app1", "app2", "app3"},
+			expectedPulls: []string{"missing"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, config, spec := newTestEnv(t)
+			target := infratest.NewTarget(config, spec)
+			appSet := tc.setup(spec, target)
+			for _, appName := range tc.running {
+				spec.Apps[appName].SetInfo(infra.DeploymentInfo{Status: infra.AppStatusRunning})
+			}
+
+			err := target.Deploy(ctx, appSet)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			deployed := target.CallArgs(infratest.MethodDeployContainer)
+			for _, pair := range tc.expectedOrder {
+				assert.Less(t, lo.IndexOf(deployed, pair[0]), lo.IndexOf(deployed, pair[1]),
+					"%s must be deployed before %s", pair[0], pair[1])
+			}
+			for _, appName := range tc.notDeployed {
+				assert.NotContains(t, deployed, appName)
+			}
+			if tc.expectedErr == nil {
+				assert.ElementsMatch(t, lo.Without(lo.Map(appSet, func(app infra.App, _ int) string {
+					return app.Name()
+				}), tc.running...), deployed)
+			}
+			assert.ElementsMatch(t, tc.expectedPulls, target.CallArgs(infratest.MethodPullImage))
+		})
+	}
+}
+
+func TestAppSetDeployStoresSpec(t *testing.T) {
+	ctx, config, spec := newTestEnv(t)
+	target := infratest.NewTarget(config, spec).WithImages("image")
+
+	db := infratest.NewApp(spec, "db", "image")
+	api := infratest.NewApp(spec, "api", "image", db)
+	spec.Apps["db"].SetVersion("v1.0.0")
+	require.NoError(t, target.Deploy(ctx, infra.AppSet{db, api}))
+
+	assert.Equal(t, infra.AppStatusRunning, db.Info().Status)
+	assert.Equal(t, infra.AppStatusRunning, api.Info().Status)
+	assert.Empty(t, db.Info().DependsOn)
+	assert.Equal(t, []string{"db"}, api.Info().DependsOn)
+
+	specFile := filepath.Join(config.HomeDir, "spec.json")
+	require.FileExists(t, specFile)
+	saved := infra.NewSpec(&infra.ConfigFactory{EnvName: config.EnvName, HomeDir: filepath.Dir(config.HomeDir)})
+	assert.Equal(t, []string{"db"}, saved.Apps["api"].Info().DependsOn)
+	assert.Equal(t, "v1.0.0", saved.Apps["db"].Version())
+	assert.Empty(t, saved.Apps["api"].Version())
+}
+
+func TestAppSetDeployResumesAfterFailure(t *testing.T) {
+	errFailure := errors.New("failure")
+
+	ctx, config, spec := newTestEnv(t)
+	target := infratest.NewTarget(config, spec).
+		WithImages("image").
+		Fail(infratest.MethodDeployContainer, "db", errFailure)
+
+	cache := infratest.NewApp(spec, "cache", "image")
+	db := infratest.NewApp(spec, "db", "image", cache)
+	api := infratest.NewApp(spec, "api", "image", db)
+	appSet := infra.AppSet{db, cache, api}
+	require.ErrorIs(t, target.Deploy(ctx, appSet), errFailure)
+
+	// Progress is stored even though the deployment failed.
+	saved := infra.NewSpec(&infra.ConfigFactory{EnvName: config.EnvName, HomeDir: filepath.Dir(config.HomeDir)})
+	assert.Equal(t, infra.AppStatusRunning, saved.Apps["cache"].Info().Status)
+	assert.Equal(t, infra.AppStatusFailed, saved.Apps["db"].Info().Status)
+	assert.Equal(t, "failure", saved.Apps["db"].Info().Error)
+	assert.Equal(t, infra.AppStatusNotDeployed, saved.Apps["api"].Info().Status)
+
+	// Next deployment deploys only the apps which haven't been deployed successfully.
+	target.Fail(infratest.MethodDeployContainer, "db", nil)
+	require.NoError(t, target.Deploy(ctx, appSet))
+
+	assert.Equal(t, []string{"cache", "db", "db", "api"}, target.CallArgs(infratest.MethodDeployContainer))
+	assert.Equal(t, infra.AppStatusRunning, db.Info().Status)
+	assert.Empty(t, db.Info().Error)
+}
+
+func TestAppSetDeployConcurrencyLimits(t *testing.T) {
+	ctx, config, spec := newTestEnv(t)
+	target := infratest.NewTarget(config, spec)
+
+	appSet := infra.AppSet{}
+	for i := range 2 * runtime.NumCPU() {
+		name := fmt.Sprintf("app%d", i)
+		image := fmt.Sprintf("image%d", i)
+		target.Delay(infratest.MethodPullImage, image, 20*time.Millisecond).
+			Delay(infratest.MethodDeployContainer, name, 20*time.Millisecond)
+		appSet = append(appSet, infratest.NewApp(spec, name, image))
+	}
+
+	require.NoError(t, target.Deploy(ctx, appSet))
+
+	assert.Len(t, target.CallArgs(infratest.MethodDeployContainer), len(appSet))
+	assert.LessOrEqual(t, target.MaxConcurrency(infratest.MethodPullImage), 3)
+	assert.LessOrEqual(t, target.MaxConcurrency(infratest.MethodDeployContainer), runtime.NumCPU())
+}
+
+func TestAppSetDeployOffline(t *testing.T) {
+	ctx, config, spec := newTestEnv(t)
+	config.Offline = true
+	target := infratest.NewTarget(config, spec).WithImages("image1")
+
+	appSet := infra.AppSet{
+		infratest.NewApp(spec, "app1", "image1"),
+		infratest.NewApp(spec, "app2", "image2"),
+		infratest.NewApp(spec, "app3", "image3"),
+		infratest.NewApp(spec, "app4", "image2"),
+	}
+	assert.Equal(t, []string{"image1", "image2", "image3"}, appSet.Images(config))
+
+	missing, err := appSet.MissingImages(ctx, target, config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"image2", "image3"}, missing)
+
+	err = target.Deploy(ctx, appSet)
+	require.ErrorContains(t, err, "image2, image3 are missing")
+	assert.Empty(t, target.CallArgs(infratest.MethodPullImage))
+	assert.Empty(t, target.CallArgs(infratest.MethodDeployContainer))
+
+	target.WithImages("image2", "image3")
+	require.NoError(t, target.Deploy(ctx, appSet))
+	assert.Empty(t, target.CallArgs(infratest.MethodPullImage))
+	assert.Len(t, target.CallArgs(infratest.MethodDeployContainer), len(appSet))
+}
+
+func TestAppSetDeployImageLock(t *testing.T) {
+	testCases := []struct {
+		name string
+		// lock is saved to the lock file, file does not exist if it is nil
+		lock  infra.ImageLock
+		allow bool
+		// missing lists images which are pulled
+		missing []string
+		// unknownDigest lists images of unknown digest
+		unknownDigest []string
+		expectedErr   string
+		expectedPull  []string
+	}{
+		{
+			name: "pinned",
+			lock: infra.ImageLock{"postgres:14": "sha256:aaa", "xrpl:1.0": "sha256:bbb"},
+		},
+		{
+			name:         "pull_by_digest",
+			lock:         infra.ImageLock{"postgres:14": "sha256:aaa", "xrpl:1.0": "sha256:bbb"},
+			missing:      []string{"postgres:14"},
+			expectedPull: []string{"postgres:14@sha256:aaa"},
+		},
+		{
+			name:        "missing_lock",
+			expectedErr: "lock file",
+		},
+		{
+			name:  "missing_lock_allowed",
+			allow: true,
+		},
+		{
+			name:          "unknown_digest",
+			lock:          infra.ImageLock{"postgres:14": "sha256:aaa", "xrpl:1.0": "sha256:bbb"},
+			unknownDigest: []string{"xrpl:1.0"},
+			expectedErr:   "xrpl:1.0 (pinned sha256:bbb, digest unknown)",
+		},
+		{
+			name:        "unpinned",
+			lock:        infra.ImageLock{"postgres:14": "sha256:aaa"},
+			expectedErr: "docker images xrpl:1.0 are not pinned",
+		},
+		{
+			name:  "unpinned_allowed",
+			lock:  infra.ImageLock{"postgres:14": "sha256:aaa"},
+			allow: true,
+		},
+		{
+			name:        "mismatch",
+			lock:        infra.ImageLock{"postgres:14": "sha256:ccc", "xrpl:1.0": "sha256:bbb"},
+			expectedErr: "postgres:14 (pinned sha256:ccc, found sha256:aaa)",
+		},
+		{
+			name:  "mismatch_allowed",
+			lock:  infra.ImageLock{"postgres:14": "sha256:ccc", "xrpl:1.0": "sha256:bbb"},
+			allow: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, config, spec := newTestEnv(t)
+			config.ImageLockFile = filepath.Join(t.TempDir(), infra.ImageLockFile)
+			config.AllowUnpinnedImages = tc.allow
+			if tc.lock != nil {
+				require.NoError(t, tc.lock.Save(config.ImageLockFile))
+			}
+
+			target := infratest.NewTarget(config, spec).WithImages("cored:znet")
+			for image, digest := range map[string]string{"postgres:14": "sha256:aaa", "xrpl:1.0": "sha256:bbb"} {
+				if !lo.Contains(tc.missing, image) {
+					target.WithImages(image)
+				}
+				if !lo.Contains(tc.unknownDigest, image) {
+					target.WithDigests(image, digest)
+				}
+			}
+
+			appSet := infra.AppSet{
+				infratest.NewApp(spec, "db", "postgres:14"),
+				infratest.NewApp(spec, "xrpl", "xrpl:1.0"),
+				infratest.NewApp(spec, "cored", "cored:znet"),
+			}
+			err := target.Deploy(ctx, appSet)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				assert.Empty(t, target.CallArgs(infratest.MethodDeployContainer))
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, target.CallArgs(infratest.MethodDeployContainer), len(appSet))
+			assert.Equal(t, tc.expectedPull, target.CallArgs(infratest.MethodPullImage))
+		})
+	}
+}
+
+func TestImageLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), infra.ImageLockFile)
+	lock := infra.ImageLock{"xrpl:1.0": "sha256:bbb", "postgres:14": "sha256:aaa"}
+	require.NoError(t, lock.Save(path))
+
+	loaded, err := infra.LoadImageLock(path)
+	require.NoError(t, err)
+	assert.Equal(t, lock, loaded)
+
+	require.NoError(t, os.WriteFile(path, []byte("postgres:14 sha256:aaa\npostgres:14 sha256:bbb\n"), 0o600))
+	_, err = infra.LoadImageLock(path)
+	require.ErrorContains(t, err, "pinned twice")
+
+	require.NoError(t, os.WriteFile(path, []byte("postgres:14\n"), 0o600))
+	_, err = infra.LoadImageLock(path)
+	require.ErrorContains(t, err, "invalid line 1")
+
+	assert.True(t, infra.IsLocalImage("cored:znet"))
+	assert.True(t, infra.IsLocalImage("localhost:5000/builder:local"))
+	assert.False(t, infra.IsLocalImage("localhost:5000/builder"))
+	assert.False(t, infra.IsLocalImage("postgres:14"))
+
+	assert.Equal(t, "postgres:14@sha256:aaa", lock.PinnedImage("postgres:14"))
+	assert.Equal(t, "cored:znet", lock.PinnedImage("cored:znet"))
+	assert.Equal(t, "postgres", infra.ImageRepository("postgres:14@sha256:aaa"))
+	assert.Equal(t, "localhost:5000/builder", infra.ImageRepository("localhost:5000/builder:local"))
+	assert.Equal(t, "localhost:5000/builder", infra.ImageRepository("localhost:5000/builder"))
+}
+
+func TestAppSetDeployCanceled(t *testing.T) {
+	ctx, config, spec := newTestEnv(t)
+	target := infratest.NewTarget(config, spec).
+		WithImages("image").
+		Delay(infratest.MethodDeployContainer, "db", time.Minute)
+
+	db := infratest.NewApp(spec, "db", "image")
+	api := infratest.NewApp(spec, "api", "image", db)
+
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, target.Deploy(ctx, infra.AppSet{db, api}), context.DeadlineExceeded)
+	assert.Equal(t, []string{"db"}, target.CallArgs(infratest.MethodDeployContainer))
+}
+
+func TestSpecGenesisPatch(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(homeDir, "test"), 0o700))
+
+	spec := infra.NewSpec(&infra.ConfigFactory{
+		EnvName:      "test",
+		HomeDir:      homeDir,
+		GenesisPatch: []byte(`{"app_state":{"gov":{"params":{"voting_period":"10s"}}}}`),
+	})
+	require.NoError(t, spec.Save())
+
+	// Patch stored in the spec is reused if it is not provided again.
+	spec = infra.NewSpec(&infra.ConfigFactory{EnvName: "test", HomeDir: homeDir})
+	require.NoError(t, spec.Verify())
+	assert.JSONEq(t, `{"app_state":{"gov":{"params":{"voting_period":"10s"}}}}`, string(spec.GenesisPatch))
+
+	spec = infra.NewSpec(&infra.ConfigFactory{
+		EnvName:      "test",
+		HomeDir:      homeDir,
+		GenesisPatch: []byte(`{"app_state": {"gov": {"params": {"voting_period": "10s"}}}}`),
+	})
+	require.NoError(t, spec.Verify())
+
+	spec = infra.NewSpec(&infra.ConfigFactory{
+		EnvName:      "test",
+		HomeDir:      homeDir,
+		GenesisPatch: []byte(`{"app_state":{"gov":{"params":{"voting_period":"20s"}}}}`),
+	})
+	require.ErrorContains(t, spec.Verify(), "genesis patch mismatch")
+}
+
+func TestInheritDefinition(t *testing.T) {
+	ctx, config, _ := newTestEnv(t)
+	homeDir := filepath.Dir(config.HomeDir)
+	definition := &infra.EnvDefinition{
+		Cored:  infra.CoredDefinition{Validators: 2, FullNodes: 1},
+		Faucet: true,
+	}
+
+	// Environment is created from the definition file.
+	spec := infra.NewSpec(&infra.ConfigFactory{
+		EnvName:    config.EnvName,
+		HomeDir:    homeDir,
+		EnvFile:    "env.yaml",
+		Definition: definition,
+	})
+	target := infratest.NewTarget(config, spec).WithImages("image")
+	require.NoError(t, target.Deploy(ctx, infra.AppSet{infratest.NewApp(spec, "cored-00-val", "image")}))
+	require.NoError(t, target.Stop(ctx))
+	require.NoError(t, spec.Save())
+
+	// Starting it again without profiles and definition file reuses the definition stored in the spec.
+	configF := &infra.ConfigFactory{
+		EnvName:  config.EnvName,
+		HomeDir:  homeDir,
+		Profiles: []string{"1cored"},
+	}
+	infra.InheritDefinition(configF)
+	require.NotNil(t, configF.Definition)
+	assert.Equal(t, definition.String(), configF.Definition.String())
+	assert.Empty(t, configF.Profiles)
+	require.NoError(t, infra.NewSpec(configF).Verify())
+
+	// Profiles passed explicitly replace the definition.
+	configF = &infra.ConfigFactory{
+		EnvName:     config.EnvName,
+		HomeDir:     homeDir,
+		Profiles:    []string{"1cored"},
+		ProfilesSet: true,
+	}
+	infra.InheritDefinition(configF)
+	assert.Nil(t, configF.Definition)
+	assert.Equal(t, []string{"1cored"}, configF.Profiles)
+}
+
+func newTestEnv(t *testing.T) (context.Context, infra.Config, *infra.Spec) {
+	t.Helper()
+
+	configF := &infra.ConfigFactory{
+		EnvName: "test",
+		HomeDir: t.TempDir(),
+	}
+	homeDir := filepath.Join(configF.HomeDir, configF.EnvName)
+	require.NoError(t, os.MkdirAll(homeDir, 0o700))
+
+	config := infra.Config{
+		EnvName: configF.EnvName,
+		HomeDir: homeDir,
+		AppDir:  filepath.Join(homeDir, "app"),
+	}
+
+	ctx := logger.WithLogger(t.Context(), logger.New(logger.Config{
+		Format:  logger.FormatJSON,
+		Verbose: true,
+	}))
+	return ctx, config, infra.NewSpec(configF)
+}
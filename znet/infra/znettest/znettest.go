@@ -0,0 +1,337 @@
+package znettest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/libexec"
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/crust/exec"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/cored"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/faucet"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/gaiad"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/osmosis"
+	"github.com/CoreumFoundation/crust/znet/infra/cosmoschain"
+)
+
+// Test groups.
+const (
+	GroupCoreumModules = "coreum-modules"
+	GroupCoreumIBC     = "coreum-ibc"
+	GroupCoreumUpgrade = "coreum-upgrade"
+	GroupFaucet        = "faucet"
+)
+
+// DefaultTimeout is the default time a single test group may run.
+const DefaultTimeout = time.Hour
+
+// testBuildTag is the build tag integration tests are guarded by.
+const testBuildTag = "integrationtests"
+
+// group defines the integration test group.
+type group struct {
+	// Profiles is the list of profiles which must be deployed before running the tests
+	Profiles []string
+
+	// Repo is the name of the repository containing the tests
+	Repo string
+
+	// Module is the path to the go module containing the tests, relative to the repository root
+	Module string
+
+	// Package is the path to the package containing the tests, relative to the module root
+	Package string
+
+	// ArgsFunc returns args specific to the test group
+	ArgsFunc func(appSet infra.AppSet) ([]string, error)
+}
+
+var groups = map[string]group{
+	GroupCoreumModules: {
+		Profiles: []string{apps.Profile3Cored},
+		Repo:     "coreum",
+		Module:   "integration-tests",
+		Package:  "modules",
+		ArgsFunc: coreumArgs,
+	},
+	GroupCoreumIBC: {
+		Profiles: []string{apps.Profile3Cored, apps.ProfileIBC},
+		Repo:     "coreum",
+		Module:   "integration-tests",
+		Package:  "ibc",
+		ArgsFunc: ibcArgs,
+	},
+	GroupCoreumUpgrade: {
+		Profiles: []string{apps.Profile3Cored, apps.ProfileIBC},
+		Repo:     "coreum",
+		Module:   "integration-tests",
+		Package:  "upgrade",
+		ArgsFunc: ibcArgs,
+	},
+	GroupFaucet: {
+		Profiles: []string{apps.ProfileFaucet},
+		Repo:     "faucet",
+		Module:   "integration-tests",
+		Package:  ".",
+		ArgsFunc: faucetArgs,
+	},
+}
+
+var groupNames = []string{
+	GroupCoreumModules,
+	GroupCoreumIBC,
+	GroupCoreumUpgrade,
+	GroupFaucet,
+}
+
+// Groups returns the list of available test groups.
+func Groups() []string {
+	return groupNames
+}
+
+// DefaultGroups returns the list of test groups run if user didn't provide anything else.
+func DefaultGroups() []string {
+	return []string{GroupCoreumModules, GroupCoreumIBC}
+}
+
+// ValidateGroups verifies that all the test groups exist.
+func ValidateGroups(testGroups []string) error {
+	if len(testGroups) == 0 {
+		return errors.New("no test groups specified")
+	}
+	for _, g := range testGroups {
+		if _, exists := groups[g]; !exists {
+			return errors.Errorf("test group %s does not exist", g)
+		}
+	}
+	return nil
+}
+
+// Profiles returns the list of profiles required to run the test groups.
+func Profiles(testGroups []string) []string {
+	pMap := map[string]bool{}
+	for _, g := range testGroups {
+		for _, p := range groups[g].Profiles {
+			pMap[p] = true
+		}
+	}
+	if !pMap[apps.Profile1Cored] && !pMap[apps.Profile3Cored] && !pMap[apps.Profile5Cored] &&
+		!pMap[apps.ProfileDevNet] {
+		pMap[apps.Profile1Cored] = true
+	}
+
+	apps.MergeProfiles(pMap)
+
+	// Order of profiles defined in apps package is kept to produce stable result.
+	return lo.Filter(apps.Profiles(), func(p string, _ int) bool {
+		return pMap[p]
+	})
+}
+
+type result struct {
+	Group    string
+	Duration time.Duration
+	Err      error
+}
+
+// Run runs the integration tests of selected groups against the running environment.
+func Run(ctx context.Context, appSet infra.AppSet, config infra.Config) error {
+	log := logger.Get(ctx)
+
+	results := make([]result, 0, len(config.TestGroups))
+	for _, name := range config.TestGroups {
+		start := time.Now()
+		err := runGroup(logger.With(ctx, zap.String("testGroup", name)), appSet, config, name)
+		if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+			return errors.WithStack(ctx.Err())
+		}
+		if err != nil {
+			log.Error("Test group failed", zap.String("testGroup", name), zap.Error(err))
+		}
+		results = append(results, result{
+			Group:    name,
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}
+
+	printSummary(results)
+
+	failed := lo.FilterMap(results, func(r result, _ int) (string, bool) {
+		return r.Group, r.Err != nil
+	})
+	if len(failed) > 0 {
+		return errors.Errorf("%d of %d test groups failed: %s", len(failed), len(results),
+			strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func runGroup(ctx context.Context, appSet infra.AppSet, config infra.Config, name string) error {
+	g := groups[name]
+
+	binPath, err := ensureBinary(ctx, config, name, g)
+	if err != nil {
+		return err
+	}
+
+	groupArgs, err := g.ArgsFunc(appSet)
+	if err != nil {
+		return err
+	}
+
+	timeout := config.TestTimeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	args := []string{
+		// The tests themselves are not computationally expensive, most of the time they spend waiting for
+		// transactions to be included in blocks, so it should be safe to run more tests in parallel than we have CPUs
+		// available.
+		"-test.v", "-test.parallel", strconv.Itoa(2 * runtime.NumCPU()),
+		"-test.timeout", timeout.String(),
+	}
+	if config.TestFilter != "" {
+		args = append(args, "-test.run", config.TestFilter)
+	}
+	args = append(args, groupArgs...)
+
+	// Test binary reports timeout by itself, context timeout is here only as a safety net in case binary hangs.
+	ctx, cancel := context.WithTimeout(ctx, timeout+time.Minute)
+	defer cancel()
+
+	logger.Get(ctx).Info("Running test group", zap.String("binary", binPath))
+
+	return libexec.Exec(ctx, osexec.Command(binPath, args...))
+}
+
+// ensureBinary returns path to the test binary. Prebuilt binary is used only if directory containing them is
+// configured, otherwise binary is always built, so it never gets outdated. Go caches the build, so it's fast if
+// nothing has changed.
+func ensureBinary(ctx context.Context, config infra.Config, name string, g group) (string, error) {
+	if config.TestBinariesDir != "" {
+		binPath := filepath.Join(config.TestBinariesDir, name)
+		if _, err := os.Stat(binPath); err != nil {
+			return "", errors.Wrapf(err, "test binary for group %s not found", name)
+		}
+		return binPath, nil
+	}
+
+	repoDir := filepath.Clean(filepath.Join(config.RootDir, "..", g.Repo))
+
+	log := logger.Get(ctx)
+	log.Info("Building test binary", zap.String("repo", repoDir))
+
+	binPath := filepath.Join(config.RootDir, "bin", ".cache", "integration-tests", name)
+	if err := os.MkdirAll(filepath.Dir(binPath), 0o700); err != nil {
+		return "", errors.WithStack(err)
+	}
+	cmd := exec.Go("test", "-c", "-tags", testBuildTag, "-o", binPath, "./"+filepath.Clean(g.Package))
+	cmd.Dir = filepath.Join(repoDir, g.Module)
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return "", errors.Wrapf(err, "building test binary for group %s failed", name)
+	}
+	return binPath, nil
+}
+
+func coreumArgs(appSet infra.AppSet) ([]string, error) {
+	var coredApp cored.Cored
+	var stakerMnemonics []string
+	for _, app := range appSet {
+		coredNode, ok := app.(cored.Cored)
+		if !ok || coredNode.Info().Status != infra.AppStatusRunning {
+			continue
+		}
+		coredApp = coredNode
+		if coredNode.Config().IsValidator {
+			stakerMnemonics = append(stakerMnemonics, coredNode.Config().StakerMnemonic)
+		}
+	}
+	if coredApp.Name() == "" {
+		return nil, errors.New("no running cored node found")
+	}
+
+	info := coredApp.Info()
+	args := []string{
+		"-run-unsafe=true",
+		"-coreum-grpc-address", infra.JoinNetAddr("", info.HostFromHost, info.HostPort(coredApp.Config().Ports.GRPC)),
+		"-coreum-rpc-address", infra.JoinNetAddr("http", info.HostFromHost, info.HostPort(coredApp.Config().Ports.RPC)),
+		"-coreum-funding-mnemonic", coredApp.Config().FundingMnemonic,
+	}
+	for _, m := range stakerMnemonics {
+		args = append(args, "-coreum-staker-mnemonic", m)
+	}
+	return args, nil
+}
+
+func ibcArgs(appSet infra.AppSet) ([]string, error) {
+	args, err := coreumArgs(appSet)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chain := range []struct {
+		FlagPrefix string
+		AppType    infra.AppType
+	}{
+		{FlagPrefix: "gaia", AppType: gaiad.AppType},
+		{FlagPrefix: "osmosis", AppType: osmosis.AppType},
+	} {
+		name := apps.BuildPrefixedAppName(apps.AppPrefixIBC, string(chain.AppType))
+		app, ok := appSet.FindRunningAppByName(name).(cosmoschain.BaseApp)
+		if !ok {
+			return nil, errors.Errorf("no running %s app found", name)
+		}
+		info := app.Info()
+		args = append(args,
+			"-"+chain.FlagPrefix+"-grpc-address", infra.JoinNetAddr("", info.HostFromHost, info.HostPort(app.Ports().GRPC)),
+			"-"+chain.FlagPrefix+"-rpc-address", infra.JoinNetAddr("http", info.HostFromHost, info.HostPort(app.Ports().RPC)),
+			"-"+chain.FlagPrefix+"-funding-mnemonic", app.AppConfig().FundingMnemonic,
+		)
+	}
+	return args, nil
+}
+
+func faucetArgs(appSet infra.AppSet) ([]string, error) {
+	args, err := coreumArgs(appSet)
+	if err != nil {
+		return nil, err
+	}
+
+	faucetApp, ok := appSet.FindRunningAppByName(string(faucet.AppType)).(faucet.Faucet)
+	if !ok {
+		return nil, errors.Errorf("no running %s app found", faucet.AppType)
+	}
+	return append(args,
+		"-faucet-address", infra.JoinNetAddr("http", faucetApp.Info().HostFromHost,
+			faucetApp.Info().HostPort(faucetApp.Port())),
+	), nil
+}
+
+func printSummary(results []result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEST GROUP\tRESULT\tDURATION")
+	for _, r := range results {
+		status := "PASS"
+		if r.Err != nil {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Group, status, r.Duration.Round(time.Second))
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,179 @@
+package dockerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/must"
+)
+
+const (
+	// apiVersion is the version of Docker Engine API used by the client. It is supported by Docker 20.10 and newer.
+	apiVersion = "v1.41"
+
+	defaultHost = "unix:///var/run/docker.sock"
+)
+
+// Error is returned if docker daemon responds with an error.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return "docker: " + e.Message
+}
+
+// IsNotFound returns true if error says that the requested object does not exist.
+func IsNotFound(err error) bool {
+	var dErr *Error
+	return errors.As(err, &dErr) && dErr.StatusCode == http.StatusNotFound
+}
+
+// Client is the client of Docker Engine API.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// New creates new client of the docker daemon defined by DOCKER_HOST environment variable.
+// If variable is not set, default unix socket is used.
+func New() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = defaultHost
+	}
+	if os.Getenv("DOCKER_TLS_VERIFY") != "" {
+		return nil, errors.New("TLS connection to docker daemon is not supported")
+	}
+
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid docker host %s", host)
+	}
+
+	transport := &http.Transport{}
+	var baseURL string
+	switch hostURL.Scheme {
+	case "unix":
+		socket := hostURL.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		}
+		// Host is ignored when unix socket is used, but it must be a valid one.
+		baseURL = "http://docker"
+	case "tcp", "http":
+		baseURL = "http://" + hostURL.Host
+	default:
+		return nil, errors.Errorf("scheme of docker host %s is not supported", host)
+	}
+
+	return &Client{
+		// Timeout is not set because logs, events and image pulls are streamed for a long time.
+		httpClient: &http.Client{Transport: transport},
+		baseURL:    baseURL + "/" + apiVersion,
+	}, nil
+}
+
+// Ping verifies that docker daemon is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodGet, "/_ping", nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// call sends request and decodes the response into out, if it is not nil.
+func (c *Client) call(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	resp, err := c.do(ctx, method, path, query, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return errors.WithStack(err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "decoding response of %s %s failed", method, path)
+	}
+	return nil
+}
+
+// do sends request to docker daemon. Caller is responsible for closing the response body.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in any) (*http.Response, error) {
+	if in == nil {
+		return c.doRaw(ctx, method, path, query, nil, "")
+	}
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return c.doRaw(ctx, method, path, query, bytes.NewReader(raw), "application/json")
+}
+
+// doRaw sends request with the body of the content type to docker daemon. Caller is responsible for closing
+// the response body.
+func (c *Client) doRaw(
+	ctx context.Context,
+	method, path string,
+	query url.Values,
+	body io.Reader,
+	contentType string,
+) (*http.Response, error) {
+	reqURL := c.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "request %s %s to docker daemon failed", method, path)
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var errResp struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &errResp); err != nil || errResp.Message == "" {
+		errResp.Message = strings.TrimSpace(string(raw))
+	}
+	return nil, errors.WithStack(&Error{StatusCode: resp.StatusCode, Message: errResp.Message})
+}
+
+func filtersQuery(query url.Values, filters map[string][]string) url.Values {
+	if len(filters) == 0 {
+		return query
+	}
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("filters", string(must.Bytes(json.Marshal(filters))))
+	return query
+}
@@ -0,0 +1,220 @@
+package dockerapi
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ContainerSummary is the short description of container returned by ContainerList.
+type ContainerSummary struct {
+	ID     string `json:"Id"` //nolint:tagliatelle // `Id` is defined by docker
+	Names  []string
+	Labels map[string]string
+	State  string
+}
+
+// ContainerState describes the state of container.
+type ContainerState struct {
+	Status   string
+	Running  bool
+	ExitCode int
+}
+
+// PortBinding describes host address container port is published on.
+type PortBinding struct {
+	HostIP   string `json:"HostIp"` //nolint:tagliatelle // `HostIp` is defined by docker
+	HostPort string
+}
+
+// ContainerDetails is the full description of container returned by ContainerInspect.
+type ContainerDetails struct {
+	ID     string `json:"Id"` //nolint:tagliatelle // `Id` is defined by docker
+	Name   string
+	State  ContainerState
+	Config struct {
+		Labels map[string]string
+		Tty    bool
+	}
+	NetworkSettings struct {
+		// Ports maps container ports in the format <port>/<protocol> to host bindings
+		Ports map[string][]PortBinding
+	}
+	HostConfig struct {
+		Memory    int64
+		NanoCPUs  int64 `json:"NanoCpus"` //nolint:tagliatelle // defined by docker
+		PidsLimit int64
+	}
+}
+
+// ContainerConfig defines container to create.
+type ContainerConfig struct {
+	Image        string
+	Cmd          []string            `json:",omitempty"`
+	Entrypoint   []string            `json:",omitempty"`
+	Env          []string            `json:",omitempty"`
+	User         string              `json:",omitempty"`
+	Labels       map[string]string   `json:",omitempty"`
+	ExposedPorts map[string]struct{} `json:",omitempty"`
+	HostConfig   HostConfig
+}
+
+// HostConfig defines the host-related part of container configuration.
+type HostConfig struct {
+	Binds         []string                 `json:",omitempty"`
+	PortBindings  map[string][]PortBinding `json:",omitempty"`
+	NetworkMode   string                   `json:",omitempty"`
+	RestartPolicy RestartPolicy
+	Memory        int64 `json:",omitempty"`
+	NanoCPUs      int64 `json:"NanoCpus,omitempty"` //nolint:tagliatelle // defined by docker
+	PidsLimit     int64 `json:",omitempty"`
+}
+
+// RestartPolicy defines when docker restarts the container.
+type RestartPolicy struct {
+	Name              string `json:",omitempty"`
+	MaximumRetryCount int    `json:",omitempty"`
+}
+
+// CPUStats describes CPU time used by container and the whole host.
+type CPUStats struct {
+	CPUUsage struct {
+		TotalUsage uint64 `json:"total_usage"` //nolint:tagliatelle // defined by docker
+	} `json:"cpu_usage"` //nolint:tagliatelle // defined by docker
+	SystemUsage uint64 `json:"system_cpu_usage"` //nolint:tagliatelle // defined by docker
+	OnlineCPUs  uint32 `json:"online_cpus"`      //nolint:tagliatelle // defined by docker
+}
+
+// ContainerStats is the snapshot of resources used by container returned by ContainerStats.
+type ContainerStats struct {
+	CPUStats    CPUStats `json:"cpu_stats"`    //nolint:tagliatelle // defined by docker
+	PreCPUStats CPUStats `json:"precpu_stats"` //nolint:tagliatelle // defined by docker
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"` //nolint:tagliatelle // defined by docker
+	PidsStats struct {
+		Current uint64 `json:"current"`
+		Limit   uint64 `json:"limit"`
+	} `json:"pids_stats"` //nolint:tagliatelle // defined by docker
+}
+
+// ContainerList returns containers matching filters, including the stopped ones.
+func (c *Client) ContainerList(ctx context.Context, filters map[string][]string) ([]ContainerSummary, error) {
+	var containers []ContainerSummary
+	query := filtersQuery(url.Values{"all": {"1"}}, filters)
+	if err := c.call(ctx, http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// ContainerInspect returns details of the container.
+func (c *Client) ContainerInspect(ctx context.Context, id string) (ContainerDetails, error) {
+	var details ContainerDetails
+	if err := c.call(ctx, http.MethodGet, "/containers/"+id+"/json", nil, nil, &details); err != nil {
+		return ContainerDetails{}, err
+	}
+	return details, nil
+}
+
+// ContainerStats returns resources used by the container. Docker samples CPU usage twice, so it takes around
+// a second.
+func (c *Client) ContainerStats(ctx context.Context, id string) (ContainerStats, error) {
+	var stats ContainerStats
+	query := url.Values{"stream": {"false"}}
+	if err := c.call(ctx, http.MethodGet, "/containers/"+id+"/stats", query, nil, &stats); err != nil {
+		return ContainerStats{}, err
+	}
+	return stats, nil
+}
+
+// ContainerCreate creates container and returns its ID.
+func (c *Client) ContainerCreate(ctx context.Context, name string, config ContainerConfig) (string, error) {
+	var resp struct {
+		ID string `json:"Id"` //nolint:tagliatelle // `Id` is defined by docker
+	}
+	err := c.call(ctx, http.MethodPost, "/containers/create", url.Values{"name": {name}}, config, &resp)
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// ContainerStart starts the container.
+func (c *Client) ContainerStart(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil, nil)
+}
+
+// ContainerStop stops the container. If it doesn't stop before timeout, it is killed.
+func (c *Client) ContainerStop(ctx context.Context, id string, timeout time.Duration) error {
+	query := url.Values{"t": {strconv.Itoa(int(timeout.Seconds()))}}
+	return c.call(ctx, http.MethodPost, "/containers/"+id+"/stop", query, nil, nil)
+}
+
+// ContainerKill kills the container.
+func (c *Client) ContainerKill(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodPost, "/containers/"+id+"/kill", nil, nil, nil)
+}
+
+// ContainerRemove removes the container. If force is set, running container is killed first.
+func (c *Client) ContainerRemove(ctx context.Context, id string, force bool) error {
+	query := url.Values{"force": {strconv.FormatBool(force)}}
+	return c.call(ctx, http.MethodDelete, "/containers/"+id, query, nil, nil)
+}
+
+// ContainerLogs streams logs of the container. If follow is set, it returns when container stops or context
+// is canceled.
+func (c *Client) ContainerLogs(ctx context.Context, id string, follow bool, stdout, stderr io.Writer) error {
+	details, err := c.ContainerInspect(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{
+		"stdout": {"1"},
+		"stderr": {"1"},
+		"follow": {strconv.FormatBool(follow)},
+	}
+	resp, err := c.do(ctx, http.MethodGet, "/containers/"+id+"/logs", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if details.Config.Tty {
+		// Output of container using TTY is not multiplexed.
+		_, err := io.Copy(stdout, resp.Body)
+		return errors.WithStack(err)
+	}
+	return demultiplex(resp.Body, stdout, stderr)
+}
+
+// demultiplex splits stream into stdout and stderr. Each frame starts with 8-byte header containing stream type
+// in the first byte and frame size in the last four ones.
+func demultiplex(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return errors.WithStack(err)
+		}
+
+		w := stdout
+		if header[0] == 2 {
+			w = stderr
+		}
+		if _, err := io.CopyN(w, r, int64(binary.BigEndian.Uint32(header[4:]))); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package dockerapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Event is the event reported by docker daemon.
+type Event struct {
+	// Type is the type of object, e.g. container, network
+	Type string
+
+	// Action is the action taken, e.g. start, die
+	Action string
+
+	// Actor describes the object
+	Actor struct {
+		ID         string
+		Attributes map[string]string
+	}
+
+	// TimeNano is the time of the event in nanoseconds since epoch
+	TimeNano int64 `json:"timeNano"`
+}
+
+// Events subscribes to events matching filters and calls fn for each one.
+// It returns when fn returns an error or context is canceled.
+func (c *Client) Events(ctx context.Context, filters map[string][]string, fn func(event Event) error) error {
+	resp, err := c.do(ctx, http.MethodGet, "/events", filtersQuery(nil, filters), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event Event
+		if err := decoder.Decode(&event); err != nil {
+			if ctx.Err() != nil {
+				return errors.WithStack(ctx.Err())
+			}
+			return errors.Wrap(err, "receiving docker event failed")
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+}
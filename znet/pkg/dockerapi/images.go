@@ -0,0 +1,145 @@
+package dockerapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PullProgress is the progress message reported while image is pulled.
+type PullProgress struct {
+	// ID is the ID of the layer the message refers to
+	ID string `json:"id"`
+
+	// Status is the human-readable status, e.g. Downloading, Pull complete
+	Status string `json:"status"`
+
+	// ProgressDetail contains the number of bytes processed so far and the total one
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+
+	// Error is set if pull failed
+	Error string `json:"error"`
+}
+
+// ImageExists checks if image exists locally.
+func (c *Client) ImageExists(ctx context.Context, image string) (bool, error) {
+	err := c.call(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil, nil)
+	switch {
+	case err == nil:
+		return true, nil
+	case IsNotFound(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// ImageDetails describes the image.
+type ImageDetails struct {
+	// ID is the ID of the image
+	ID string
+
+	// RepoDigests are the references of the image in the form <repository>@<digest>
+	RepoDigests []string
+}
+
+// ImageInspect returns details of the image.
+func (c *Client) ImageInspect(ctx context.Context, image string) (ImageDetails, error) {
+	var details ImageDetails
+	if err := c.call(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil, &details); err != nil {
+		return ImageDetails{}, err
+	}
+	return details, nil
+}
+
+// ImagePull pulls the image. Function progressFn is called for every progress message, it might be nil.
+func (c *Client) ImagePull(ctx context.Context, image string, progressFn func(progress PullProgress)) error {
+	repo, tag := splitImage(image)
+	resp, err := c.do(ctx, http.MethodPost, "/images/create", url.Values{"fromImage": {repo}, "tag": {tag}}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Errors occurring during the pull are reported in the stream, response status is 200 anyway.
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var progress PullProgress
+		if err := decoder.Decode(&progress); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return errors.Wrapf(err, "decoding progress of pulling image %s failed", image)
+		}
+		if progress.Error != "" {
+			return errors.Errorf("pulling image %s failed: %s", image, progress.Error)
+		}
+		if progressFn != nil {
+			progressFn(progress)
+		}
+	}
+}
+
+// ImageTag creates the target reference of the source image.
+func (c *Client) ImageTag(ctx context.Context, source, target string) error {
+	repo, tag := splitImage(target)
+	return c.call(ctx, http.MethodPost, "/images/"+source+"/tag", url.Values{"repo": {repo}, "tag": {tag}}, nil, nil)
+}
+
+// ImagesSave writes the tarball containing images to w.
+func (c *Client) ImagesSave(ctx context.Context, images []string, w io.Writer) error {
+	resp, err := c.do(ctx, http.MethodGet, "/images/get", url.Values{"names": images}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return errors.Wrap(err, "saving images failed")
+}
+
+// ImagesLoad loads images from the tarball produced by ImagesSave.
+func (c *Client) ImagesLoad(ctx context.Context, r io.Reader) error {
+	resp, err := c.doRaw(ctx, http.MethodPost, "/images/load", url.Values{"quiet": {"1"}}, r, "application/x-tar")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Errors occurring during the load are reported in the stream, response status is 200 anyway.
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message struct {
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return errors.Wrap(err, "decoding progress of loading images failed")
+		}
+		if message.Error != "" {
+			return errors.Errorf("loading images failed: %s", message.Error)
+		}
+	}
+}
+
+// splitImage splits image reference into repository and tag or digest. Without tag docker would pull all the tags.
+func splitImage(image string) (string, string) {
+	if repo, digest, found := strings.Cut(image, "@"); found {
+		return repo, digest
+	}
+	// Colon before the last slash separates registry host and port.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
@@ -0,0 +1,41 @@
+package dockerapi
+
+import (
+	"context"
+	"net/http"
+)
+
+// NetworkExists checks if network exists.
+func (c *Client) NetworkExists(ctx context.Context, name string) (bool, error) {
+	var networks []struct {
+		Name string
+	}
+	query := filtersQuery(nil, map[string][]string{"name": {name}})
+	if err := c.call(ctx, http.MethodGet, "/networks", query, nil, &networks); err != nil {
+		return false, err
+	}
+	// Filter matches networks containing the name, not only the equal ones.
+	for _, network := range networks {
+		if network.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// NetworkCreate creates bridge network.
+func (c *Client) NetworkCreate(ctx context.Context, name string) error {
+	req := struct {
+		Name           string
+		CheckDuplicate bool
+	}{
+		Name:           name,
+		CheckDuplicate: true,
+	}
+	return c.call(ctx, http.MethodPost, "/networks/create", nil, req, nil)
+}
+
+// NetworkRemove removes network.
+func (c *Client) NetworkRemove(ctx context.Context, name string) error {
+	return c.call(ctx, http.MethodDelete, "/networks/"+name, nil, nil, nil)
+}
@@ -0,0 +1,423 @@
+// Package jsonpatch applies JSON patches (RFC 6902) and JSON merge patches (RFC 7396) to JSON documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Apply applies the patch to the document. Patch being a JSON array is applied as JSON patch defined by RFC 6902,
+// patch being a JSON object is applied as JSON merge patch defined by RFC 7396.
+// Result is indented and its object keys are sorted, so the same input always produces the same output.
+func Apply(doc, patch []byte) ([]byte, error) {
+	docValue, err := decode(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding document failed")
+	}
+
+	p, err := decodePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var result any
+	if p.merge != nil {
+		result = mergePatch(docValue, p.merge)
+	} else if result, err = applyOperations(docValue, p.operations); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Validate verifies that the patch is either valid JSON patch or JSON merge patch.
+func Validate(patch []byte) error {
+	_, err := decodePatch(patch)
+	return err
+}
+
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+type decodedPatch struct {
+	operations []operation
+	merge      map[string]any
+}
+
+func decodePatch(patch []byte) (decodedPatch, error) {
+	value, err := decode(patch)
+	if err != nil {
+		return decodedPatch{}, errors.Wrap(err, "decoding patch failed")
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		return decodedPatch{merge: v}, nil
+	case []any:
+		var operations []operation
+		if err := json.Unmarshal(patch, &operations); err != nil {
+			return decodedPatch{}, errors.Wrap(err, "decoding patch operations failed")
+		}
+		for i, op := range operations {
+			if err := op.validate(); err != nil {
+				return decodedPatch{}, errors.Wrapf(err, "invalid operation %d", i)
+			}
+		}
+		return decodedPatch{operations: operations}, nil
+	default:
+		return decodedPatch{}, errors.New("patch must be either JSON array or JSON object")
+	}
+}
+
+func (op operation) validate() error {
+	if op.Path == nil {
+		return errors.Errorf("path is missing in %s operation", op.Op)
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return errors.Errorf("value is missing in %s operation", op.Op)
+		}
+	case "move", "copy":
+		if op.From == nil {
+			return errors.Errorf("from is missing in %s operation", op.Op)
+		}
+	case "remove":
+	default:
+		return errors.Errorf("unknown operation %q", op.Op)
+	}
+	return nil
+}
+
+func applyOperations(doc any, operations []operation) (any, error) {
+	for i, op := range operations {
+		var err error
+		doc, err = op.apply(doc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "applying operation %d (%s %s) failed", i, op.Op, *op.Path)
+		}
+	}
+	return doc, nil
+}
+
+func (op operation) apply(doc any) (any, error) {
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding value failed")
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return replace(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(current, value) {
+				return nil, errors.New("test failed, values are different")
+			}
+			return doc, nil
+		}
+	case "remove":
+		newDoc, _, err := remove(doc, path)
+		return newDoc, err
+	default:
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			value, err := get(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, deepCopy(value))
+		}
+		if isPrefix(from, path) && len(from) < len(path) {
+			return nil, errors.New("value can't be moved into its own child")
+		}
+		newDoc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(newDoc, path, value)
+	}
+}
+
+// parsePointer parses JSON pointer defined by RFC 6901.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Errorf("pointer %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			child, exists := node[token]
+			if !exists {
+				return nil, errors.Errorf("key %q does not exist", token)
+			}
+			doc = child
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, errors.Errorf("value containing %q is neither object nor array", token)
+		}
+	}
+	return doc, nil
+}
+
+// update calls fn for the container holding the value pointed by the path and stores the container it returns.
+func update(doc any, path []string, fn func(container any, token string) (any, error)) (any, error) {
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	newParent, err := fn(parent, path[len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 1 {
+		return newParent, nil
+	}
+
+	// Array might be reallocated, so it must be stored again in its container.
+	return update(doc, path[:len(path)-1], func(container any, token string) (any, error) {
+		switch node := container.(type) {
+		case map[string]any:
+			node[token] = newParent
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = newParent
+		}
+		return container, nil
+	})
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(container any, token string) (any, error) {
+		switch node := container.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			i := len(node)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			return append(node[:i], append([]any{value}, node[i:]...)...), nil
+		default:
+			return nil, errors.Errorf("value containing %q is neither object nor array", token)
+		}
+	})
+}
+
+func replace(doc any, path []string, value any) (any, error) {
+	if _, err := get(doc, path); err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(container any, token string) (any, error) {
+		switch node := container.(type) {
+		case map[string]any:
+			node[token] = value
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = value
+		}
+		return container, nil
+	})
+}
+
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("root of the document can't be removed")
+	}
+	removed, err := get(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err = update(doc, path, func(container any, token string) (any, error) {
+		switch node := container.(type) {
+		case map[string]any:
+			delete(node, token)
+			return node, nil
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:i], node[i+1:]...), nil
+		default:
+			return container, nil
+		}
+	})
+	return doc, removed, err
+}
+
+func arrayIndex(token string, maxIndex int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > maxIndex {
+		return 0, errors.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+func equal(a, b any) bool {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, exists := bv[key]
+			if !exists || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, _, errA := big.ParseFloat(string(av), 10, 256, big.ToNearestEven)
+		bf, _, errB := big.ParseFloat(string(bv), 10, 256, big.ToNearestEven)
+		return errA == nil && errB == nil && af.Cmp(bf) == 0
+	default:
+		return a == b
+	}
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, child := range v {
+			result[key] = deepCopy(child)
+		}
+		return result
+	case []any:
+		result := make([]any, 0, len(v))
+		for _, child := range v {
+			result = append(result, deepCopy(child))
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// decode decodes JSON value keeping numbers intact, so big integers used by genesis are not rounded.
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return value, nil
+}
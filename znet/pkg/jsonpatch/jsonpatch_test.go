@@ -0,0 +1,127 @@
+package jsonpatch_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/crust/znet/pkg/jsonpatch"
+)
+
+func TestApply(t *testing.T) {
+	const doc = `{
+		"app_state": {
+			"gov": {"params": {"voting_period": "20s", "min_deposit": [{"denom": "udevcore", "amount": "1000"}]}},
+			"staking": {"params": {"max_validators": 32}},
+			"supply": "100000000000000000000000001"
+		},
+		"total": 100000000000000000000000001,
+		"validators": ["a", "b"]
+	}`
+
+	testCases := []struct {
+		name        string
+		patch       string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:  "merge_patch",
+			patch: `{"app_state": {"gov": {"params": {"voting_period": "10s"}}, "staking": null}}`,
+			expected: `{
+				"app_state": {
+					"gov": {"params": {"voting_period": "10s", "min_deposit": [{"denom": "udevcore", "amount": "1000"}]}},
+					"supply": "100000000000000000000000001"
+				},
+				"total": 100000000000000000000000001,
+				"validators": ["a", "b"]
+			}`,
+		},
+		{
+			name: "json_patch",
+			patch: `[
+				{"op": "test", "path": "/app_state/staking/params/max_validators", "value": 32.0},
+				{"op": "replace", "path": "/app_state/gov/params/voting_period", "value": "10s"},
+				{"op": "add", "path": "/app_state/gov/params/min_deposit/-", "value": {"denom": "uatom", "amount": "1"}},
+				{"op": "add", "path": "/validators/0", "value": "z"},
+				{"op": "remove", "path": "/validators/2"},
+				{"op": "copy", "from": "/app_state/supply", "path": "/app_state/staking/supply"},
+				{"op": "move", "from": "/app_state/staking/params", "path": "/staking~1params"}
+			]`,
+			expected: `{
+				"app_state": {
+					"gov": {"params": {"voting_period": "10s", "min_deposit": [
+						{"denom": "udevcore", "amount": "1000"},
+						{"denom": "uatom", "amount": "1"}
+					]}},
+					"staking": {"supply": "100000000000000000000000001"},
+					"supply": "100000000000000000000000001"
+				},
+				"staking/params": {"max_validators": 32},
+				"total": 100000000000000000000000001,
+				"validators": ["z", "a"]
+			}`,
+		},
+		{
+			name:        "failed_test",
+			patch:       `[{"op": "test", "path": "/validators/0", "value": "b"}]`,
+			expectedErr: "test failed",
+		},
+		{
+			name:        "missing_path",
+			patch:       `[{"op": "replace", "path": "/app_state/mint/params", "value": {}}]`,
+			expectedErr: `key "mint" does not exist`,
+		},
+		{
+			name:        "invalid_index",
+			patch:       `[{"op": "remove", "path": "/validators/2"}]`,
+			expectedErr: `invalid array index "2"`,
+		},
+		{
+			name:        "move_into_child",
+			patch:       `[{"op": "move", "from": "/app_state", "path": "/app_state/gov/old"}]`,
+			expectedErr: "can't be moved into its own child",
+		},
+		{
+			name:        "unknown_operation",
+			patch:       `[{"op": "merge", "path": "/app_state"}]`,
+			expectedErr: `unknown operation "merge"`,
+		},
+		{
+			name:        "invalid_patch",
+			patch:       `"value"`,
+			expectedErr: "patch must be either JSON array or JSON object",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := jsonpatch.Apply([]byte(doc), []byte(tc.patch))
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(result))
+			// Big numbers must not be rounded.
+			assert.Contains(t, string(result), `"total": 100000000000000000000000001`)
+		})
+	}
+}
+
+func TestApplyIsDeterministic(t *testing.T) {
+	doc := []byte(`{"b": {"y": 1, "x": 2}, "a": "<html>"}`)
+	patch := []byte(`{"c": 3}`)
+
+	first, err := jsonpatch.Apply(doc, patch)
+	require.NoError(t, err)
+	for range 10 {
+		next, err := jsonpatch.Apply(doc, patch)
+		require.NoError(t, err)
+		assert.Equal(t, string(first), string(next))
+	}
+	assert.True(t, json.Valid(first))
+	assert.Contains(t, string(first), `"<html>"`)
+}
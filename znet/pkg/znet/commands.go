@@ -1,27 +1,40 @@
 package znet
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	osexec "os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
 
 	"github.com/CoreumFoundation/coreum-tools/pkg/libexec"
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
 	"github.com/CoreumFoundation/coreum-tools/pkg/must"
 	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
 	"github.com/CoreumFoundation/coreum/v6/pkg/config/constant"
 	"github.com/CoreumFoundation/crust/znet/infra"
 	"github.com/CoreumFoundation/crust/znet/infra/apps"
 	"github.com/CoreumFoundation/crust/znet/infra/apps/cored"
-	"github.com/CoreumFoundation/crust/znet/infra/targets"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/prometheus"
+	"github.com/CoreumFoundation/crust/znet/infra/znettest"
+	"github.com/CoreumFoundation/crust/znet/pkg/jsonpatch"
 )
 
 var exe = must.String(filepath.EvalSymlinks(must.String(os.Executable())))
@@ -44,12 +57,19 @@ func Activate(ctx context.Context, configF *infra.ConfigFactory) error {
 
 	saveWrapper(config.WrapperDir, "start", "start")
 	saveWrapper(config.WrapperDir, "stop", "stop")
+	saveWrapper(config.WrapperDir, "restart", "restart")
 	saveWrapper(config.WrapperDir, "remove", "remove")
 	// `test` can't be used here because it is a reserved keyword in bash
 	saveWrapper(config.WrapperDir, "tests", "test")
 	saveWrapper(config.WrapperDir, "spec", "spec")
+	saveWrapper(config.WrapperDir, "definition", "definition")
+	saveWrapper(config.WrapperDir, "status", "status")
+	saveWrapper(config.WrapperDir, "snapshot", "snapshot")
 	saveWrapper(config.WrapperDir, "console", "console")
-	saveLogsWrapper(config.WrapperDir, config.EnvName, "logs")
+	saveWrapper(config.WrapperDir, "logs", "logs")
+	saveWrapper(config.WrapperDir, "supervise", "supervise")
+	saveWrapper(config.WrapperDir, "images", "images")
+	saveWrapper(config.WrapperDir, "export", "export")
 
 	shell, promptVar, err := shellConfig(config.EnvName)
 	if err != nil {
@@ -59,11 +79,14 @@ func Activate(ctx context.Context, configF *infra.ConfigFactory) error {
 	shellCmd.Env = append(os.Environ(),
 		"PATH="+config.WrapperDir+":"+os.Getenv("PATH"),
 		"CRUST_ZNET_ENV="+configF.EnvName,
-		"CRUST_ZNET_PROFILES="+strings.Join(configF.Profiles, ","),
 		"CRUST_ZNET_CORED_VERSION="+configF.CoredVersion,
 		"CRUST_ZNET_HOME="+configF.HomeDir,
 		"CRUST_ZNET_ROOT_DIR="+configF.RootDir,
 	)
+	if configF.ProfilesSet {
+		// Otherwise commands executed in the shell use the profiles of the existing environment.
+		shellCmd.Env = append(shellCmd.Env, "CRUST_ZNET_PROFILES="+strings.Join(configF.Profiles, ","))
+	}
 	if promptVar != "" {
 		shellCmd.Env = append(shellCmd.Env, promptVar)
 	}
@@ -107,21 +130,34 @@ func Activate(ctx context.Context, configF *infra.ConfigFactory) error {
 
 // Start starts environment.
 func Start(ctx context.Context, configF *infra.ConfigFactory) error {
-	if err := apps.ValidateProfiles(configF.Profiles); err != nil {
+	infra.InheritDefinition(configF)
+	if err := loadDefinition(configF); err != nil {
+		return err
+	}
+	if err := loadGenesisPatch(configF); err != nil {
+		return err
+	}
+	if err := loadGenesisFixture(configF); err != nil {
 		return err
 	}
 
 	spec := infra.NewSpec(configF)
+	if err := updateProfiles(ctx, configF, spec); err != nil {
+		return err
+	}
 	config := NewConfig(configF, spec)
 
 	if err := spec.Verify(); err != nil {
 		return err
 	}
 
-	target := targets.NewDocker(config, spec)
+	target := NewTarget(config, spec)
+	if err := resetFailedApps(ctx, target, spec); err != nil {
+		return err
+	}
 	appF := apps.NewFactory(config, spec)
 
-	appSet, _, err := apps.BuildAppSet(ctx, appF, config.Profiles, config.CoredVersion)
+	appSet, _, err := apps.BuildAppSet(ctx, appF, config.Definition, config.CoredVersion)
 	if err != nil {
 		return err
 	}
@@ -129,6 +165,233 @@ func Start(ctx context.Context, configF *infra.ConfigFactory) error {
 	return target.Deploy(ctx, appSet)
 }
 
+// loadDefinition loads the environment definition from file if it is provided, otherwise it validates profiles.
+// If the cored network is customized by flags, definition inherited from the existing environment is used as a base,
+// otherwise the one corresponding to the profiles is used.
+func loadDefinition(configF *infra.ConfigFactory) error {
+	if configF.EnvFile != "" {
+		definition, err := infra.LoadEnvDefinition(configF.EnvFile)
+		if err != nil {
+			return err
+		}
+		configF.Definition = &definition
+		return overrideCoredDefinition(configF)
+	}
+
+	registry, err := apps.NewRegistry(configF.Extensions)
+	if err != nil {
+		return err
+	}
+	if err := registry.ValidateProfiles(configF.Profiles); err != nil {
+		return err
+	}
+	if configF.Validators < 0 && configF.Sentries < 0 && configF.Seeds < 0 && configF.FullNodes < 0 &&
+		len(configF.StakeWeights) == 0 && len(configF.CoredNodeVersions) == 0 {
+		return nil
+	}
+	definition := apps.PresetDefinition(configF.Profiles)
+	if configF.Definition != nil {
+		definition = *configF.Definition
+	}
+	configF.Definition = &definition
+	return overrideCoredDefinition(configF)
+}
+
+// overrideCoredDefinition applies the cored network parameters passed by flags to the definition.
+func overrideCoredDefinition(configF *infra.ConfigFactory) error {
+	coredDefinition := &configF.Definition.Cored
+	for _, override := range []struct {
+		value  int
+		target *int
+	}{
+		{value: configF.Validators, target: &coredDefinition.Validators},
+		{value: configF.Sentries, target: &coredDefinition.Sentries},
+		{value: configF.Seeds, target: &coredDefinition.Seeds},
+		{value: configF.FullNodes, target: &coredDefinition.FullNodes},
+	} {
+		if override.value >= 0 {
+			*override.target = override.value
+		}
+	}
+	if len(configF.StakeWeights) > 0 {
+		coredDefinition.StakeWeights = configF.StakeWeights
+	}
+	if len(configF.CoredNodeVersions) > 0 {
+		versions := maps.Clone(coredDefinition.Versions)
+		if versions == nil {
+			versions = map[string]string{}
+		}
+		maps.Copy(versions, configF.CoredNodeVersions)
+		coredDefinition.Versions = versions
+	}
+	return configF.Definition.Validate()
+}
+
+// loadGenesisPatch loads the patch applied to genesis of cored network if it is provided.
+func loadGenesisPatch(configF *infra.ConfigFactory) error {
+	if configF.GenesisPatchFile == "" {
+		return nil
+	}
+	patch, err := os.ReadFile(configF.GenesisPatchFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := jsonpatch.Validate(patch); err != nil {
+		return errors.Wrapf(err, "invalid genesis patch %s", configF.GenesisPatchFile)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, patch); err != nil {
+		return errors.WithStack(err)
+	}
+	configF.GenesisPatch = buf.Bytes()
+	return nil
+}
+
+// loadGenesisFixture loads the fixture included in genesis of cored network if it is provided.
+// Fixture might be written in YAML or JSON, it is converted to JSON to be stored in the spec.
+func loadGenesisFixture(configF *infra.ConfigFactory) error {
+	if configF.GenesisFixtureFile == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(configF.GenesisFixtureFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	fixture, err := yaml.YAMLToJSON(raw)
+	if err != nil {
+		return errors.Wrapf(err, "parsing genesis fixture %s failed", configF.GenesisFixtureFile)
+	}
+	if _, err := cored.ParseGenesisFixture(fixture); err != nil {
+		return errors.Wrapf(err, "invalid genesis fixture %s", configF.GenesisFixtureFile)
+	}
+	configF.GenesisFixture = fixture
+	return nil
+}
+
+// updateProfiles applies the profiles requested by the user to the existing environment.
+// Apps which are not needed anymore are removed. Apps affected by the change are redeployed, new ones are deployed
+// later by the regular deployment, without touching the running chain.
+func updateProfiles(ctx context.Context, configF *infra.ConfigFactory, spec *infra.Spec) error {
+	if len(spec.Apps) == 0 || spec.Env != configF.EnvName || spec.TimeoutCommit != configF.TimeoutCommit {
+		// Nothing is deployed yet, or spec verification is going to fail anyway.
+		return nil
+	}
+
+	config := NewConfig(configF, spec)
+	definition := apps.PresetDefinition(configF.Profiles)
+	if configF.Definition != nil {
+		definition = *configF.Definition
+	}
+	if !reflect.DeepEqual(definition.Cored, config.Definition.Cored) {
+		return errors.New("cored network can't be changed in the existing environment, remove it first")
+	}
+
+	oldDefinition := config.Definition
+	oldAppSet, _, err := apps.BuildAppSet(ctx, apps.NewFactory(config, spec), oldDefinition, config.CoredVersion)
+	if err != nil {
+		return err
+	}
+
+	spec.Profiles = configF.Profiles
+	spec.Definition = configF.Definition
+	if spec.Definition != nil {
+		spec.Profiles = nil
+	}
+	config = NewConfig(configF, spec)
+
+	newAppSet, _, err := apps.BuildAppSet(ctx, apps.NewFactory(config, spec), config.Definition, config.CoredVersion)
+	if err != nil {
+		return err
+	}
+
+	var removed, redeployed []string
+	for _, oldApp := range oldAppSet {
+		newApp := newAppSet.FindAppByName(oldApp.Name())
+		switch {
+		case newApp == nil:
+			removed = append(removed, oldApp.Name())
+		case oldApp.Info().Status == infra.AppStatusNotDeployed:
+		case oldApp.Type() == prometheus.AppType,
+			!slices.Equal(dependencyNames(oldApp), dependencyNames(newApp)),
+			!reflect.DeepEqual(oldDefinition.Apps[oldApp.Name()], definition.Apps[oldApp.Name()]):
+			// Configuration of those apps depends on other apps or on overrides, so it must be generated again.
+			redeployed = append(redeployed, oldApp.Name())
+		}
+	}
+	added := lo.Filter(newAppSet, func(app infra.App, _ int) bool {
+		return oldAppSet.FindAppByName(app.Name()) == nil
+	})
+	if len(removed) == 0 && len(added) == 0 {
+		// Set of apps is the same, only the overridden ones must be redeployed.
+		redeployed = lo.Filter(redeployed, func(appName string, _ int) bool {
+			return !reflect.DeepEqual(oldDefinition.Apps[appName], definition.Apps[appName])
+		})
+		if len(redeployed) == 0 {
+			return spec.Save()
+		}
+	}
+	if len(removed) > 0 && !confirm(fmt.Sprintf("Apps %s are not needed by the new profiles, remove them?",
+		strings.Join(removed, ", "))) {
+		return errors.Errorf("apps %s would be removed, environment hasn't been changed", strings.Join(removed, ", "))
+	}
+
+	target := NewTarget(config, spec)
+	if err := target.RemoveApps(ctx, append(append([]string{}, removed...), redeployed...)); err != nil {
+		return err
+	}
+	for _, appName := range removed {
+		if err := os.RemoveAll(filepath.Join(config.AppDir, appName)); err != nil {
+			return errors.WithStack(err)
+		}
+		spec.RemoveApp(appName)
+	}
+	for _, appName := range redeployed {
+		spec.Apps[appName].SetInfo(infra.DeploymentInfo{})
+	}
+
+	log := logger.Get(ctx)
+	log.Info("Environment updated",
+		zap.Strings("added", lo.Map(added, func(app infra.App, _ int) string { return app.Name() })),
+		zap.Strings("removed", removed),
+		zap.Strings("redeployed", redeployed),
+	)
+
+	return spec.Save()
+}
+
+// resetFailedApps removes leftovers of the apps whose deployment failed before, so the deployment is resumed
+// by deploying them again, while the apps deployed successfully keep running.
+func resetFailedApps(ctx context.Context, target infra.Target, spec *infra.Spec) error {
+	var failed []string
+	for appName, appInfo := range spec.Apps {
+		if appInfo.Info().Status == infra.AppStatusFailed {
+			failed = append(failed, appName)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Strings(failed)
+
+	logger.Get(ctx).Info("Resuming failed deployment", zap.Strings("failed", failed))
+	if err := target.RemoveApps(ctx, failed); err != nil {
+		return err
+	}
+	for _, appName := range failed {
+		spec.Apps[appName].SetInfo(infra.DeploymentInfo{})
+	}
+	return spec.Save()
+}
+
+func dependencyNames(app infra.App) []string {
+	names := lo.Map(app.Deployment().Requires.Dependencies, func(dep infra.HealthCheckCapable, _ int) string {
+		return dep.Name()
+	})
+	sort.Strings(names)
+	return names
+}
+
 // Stop stops environment.
 func Stop(ctx context.Context, configF *infra.ConfigFactory) (retErr error) {
 	spec := infra.NewSpec(configF)
@@ -136,23 +399,174 @@ func Stop(ctx context.Context, configF *infra.ConfigFactory) (retErr error) {
 
 	defer func() {
 		for _, app := range spec.Apps {
-			app.SetInfo(infra.DeploymentInfo{Status: infra.AppStatusStopped})
+			// Failed apps and the ones never deployed keep their status, so they are deployed from scratch later.
+			if app.Info().Status == infra.AppStatusRunning {
+				app.SetInfo(infra.DeploymentInfo{Status: infra.AppStatusStopped})
+			}
 		}
 		if err := spec.Save(); retErr == nil {
 			retErr = err
 		}
 	}()
 
-	target := targets.NewDocker(config, spec)
+	target := NewTarget(config, spec)
 	return target.Stop(ctx)
 }
 
+// StartApps starts selected applications together with their stopped dependencies.
+func StartApps(ctx context.Context, configF *infra.ConfigFactory, appNames []string) error {
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	if err := spec.Verify(); err != nil {
+		return err
+	}
+
+	return startApps(ctx, config, spec, appNames)
+}
+
+// StopApps stops selected applications. If other running applications depend on them, user is asked if those
+// should be stopped too, unless cascade is set.
+func StopApps(ctx context.Context, configF *infra.ConfigFactory, appNames []string, cascade bool) error {
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	_, err := stopApps(ctx, config, spec, appNames, cascade)
+	return err
+}
+
+// RestartApps restarts selected applications. Applications depending on them are restarted too.
+func RestartApps(ctx context.Context, configF *infra.ConfigFactory, appNames []string, cascade bool) error {
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	if err := spec.Verify(); err != nil {
+		return err
+	}
+
+	stopped, err := stopApps(ctx, config, spec, appNames, cascade)
+	if err != nil {
+		return err
+	}
+	return startApps(ctx, config, spec, stopped)
+}
+
+func startApps(ctx context.Context, config infra.Config, spec *infra.Spec, appNames []string) error {
+	target := NewTarget(config, spec)
+	if err := resetFailedApps(ctx, target, spec); err != nil {
+		return err
+	}
+	appF := apps.NewFactory(config, spec)
+
+	appSet, _, err := apps.BuildAppSet(ctx, appF, config.Definition, config.CoredVersion)
+	if err != nil {
+		return err
+	}
+
+	// Dependencies which are already running are skipped by the deployment.
+	appSet, err = appSet.WithDependencies(appNames)
+	if err != nil {
+		return err
+	}
+
+	return target.Deploy(ctx, appSet)
+}
+
+// stopApps stops selected applications and returns the names of all the stopped ones, including dependents.
+func stopApps(
+	ctx context.Context,
+	config infra.Config,
+	spec *infra.Spec,
+	appNames []string,
+	cascade bool,
+) ([]string, error) {
+	for _, appName := range appNames {
+		if _, exists := spec.Apps[appName]; !exists {
+			return nil, errors.Errorf("app %s does not exist in the environment", appName)
+		}
+	}
+
+	if dependents := spec.RunningDependents(appNames); len(dependents) > 0 {
+		if !cascade && !confirm(fmt.Sprintf("Apps %s depend on %s, stop them too?",
+			strings.Join(dependents, ", "), strings.Join(appNames, ", "))) {
+			return nil, errors.Errorf("apps %s depend on the stopped ones, use --cascade to stop them too",
+				strings.Join(dependents, ", "))
+		}
+		appNames = append(append([]string{}, appNames...), dependents...)
+	}
+
+	target := NewTarget(config, spec)
+	stopErr := target.StopApps(ctx, appNames)
+	stopped := appNames
+	if stopErr != nil {
+		// Only the apps which are not running anymore are recorded as stopped.
+		states, err := target.Status(ctx)
+		if err != nil {
+			return nil, stopErr
+		}
+		stopped = lo.Filter(appNames, func(appName string, _ int) bool {
+			state := states[appName].State
+			return state != "running" && state != "restarting"
+		})
+	}
+
+	for _, appName := range stopped {
+		app := spec.Apps[appName]
+		info := app.Info()
+		info.Status = infra.AppStatusStopped
+		app.SetInfo(info)
+	}
+	if err := spec.Save(); err != nil {
+		return nil, err
+	}
+	if stopErr != nil {
+		return nil, stopErr
+	}
+	return appNames, nil
+}
+
+// confirm asks user to confirm the action.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// Logs streams logs of the application.
+func Logs(ctx context.Context, configF *infra.ConfigFactory, appName string) error {
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	target := NewTarget(config, spec)
+	return target.Logs(ctx, appName, os.Stdout, os.Stderr)
+}
+
+// Supervise restarts crashed applications until context is canceled or environment is removed.
+func Supervise(ctx context.Context, configF *infra.ConfigFactory) error {
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	target := NewTarget(config, spec)
+	appF := apps.NewFactory(config, spec)
+
+	appSet, _, err := apps.BuildAppSet(ctx, appF, config.Definition, config.CoredVersion)
+	if err != nil {
+		return err
+	}
+
+	return infra.NewSupervisor(config, spec, target, appSet).Run(ctx)
+}
+
 // Remove removes environment.
 func Remove(ctx context.Context, configF *infra.ConfigFactory) (retErr error) {
 	spec := infra.NewSpec(configF)
 	config := NewConfig(configF, spec)
 
-	target := targets.NewDocker(config, spec)
+	target := NewTarget(config, spec)
 	if err := target.Remove(ctx); err != nil {
 		return err
 	}
@@ -173,12 +587,54 @@ func Remove(ctx context.Context, configF *infra.ConfigFactory) (retErr error) {
 	return errors.WithStack(err)
 }
 
+// Test starts environment required by the test groups and runs integration tests there.
+func Test(ctx context.Context, configF *infra.ConfigFactory) error {
+	if err := znettest.ValidateGroups(configF.TestGroups); err != nil {
+		return err
+	}
+	configF.Profiles = znettest.Profiles(configF.TestGroups)
+	if err := loadDefinition(configF); err != nil {
+		return err
+	}
+
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	if err := spec.Verify(); err != nil {
+		return err
+	}
+
+	target := NewTarget(config, spec)
+	appF := apps.NewFactory(config, spec)
+
+	appSet, _, err := apps.BuildAppSet(ctx, appF, config.Definition, config.CoredVersion)
+	if err != nil {
+		return err
+	}
+
+	if err := target.Deploy(ctx, appSet); err != nil {
+		return err
+	}
+
+	return znettest.Run(ctx, appSet, config)
+}
+
 // Spec prints specification of running environment.
 func Spec(spec *infra.Spec) error {
 	fmt.Println(spec)
 	return nil
 }
 
+// Definition prints the definition of the environment in the format accepted by `start --from-file`.
+func Definition(spec *infra.Spec) error {
+	definition := apps.PresetDefinition(spec.Profiles)
+	if spec.Definition != nil {
+		definition = *spec.Definition
+	}
+	fmt.Print(definition)
+	return nil
+}
+
 // CoverageConvert converts & stores coverage from the first cored app we find.
 func CoverageConvert(ctx context.Context, configF *infra.ConfigFactory) error {
 	spec := infra.NewSpec(configF)
@@ -214,16 +670,6 @@ exec "`+exe+`" "`+command+`" "$@"
 `), 0o700))
 }
 
-func saveLogsWrapper(dir, envName, file string) {
-	must.OK(os.WriteFile(dir+"/"+file, []byte(`#!/bin/bash
-if [ "$1" == "" ]; then
-  echo "Provide the name of application"
-  exit 1
-fi
-exec docker logs -f "`+envName+`-$1"
-`), 0o700))
-}
-
 var supportedShells = map[string]func(envName string) string{
 	"bash": func(envName string) string {
 		return "PS1=(" + envName + `) [\u@\h \W]\$ `
@@ -0,0 +1,185 @@
+package znet
+
+import (
+	"bytes"
+	"context"
+	"os"
+	osexec "os/exec"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/libexec"
+	"github.com/CoreumFoundation/crust/exec"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/bigdipper"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/bridgexrpl"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/callisto"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/cored"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/faucet"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/gaiad"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/grafana"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/hasura"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/hermes"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/osmosis"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/postgres"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/prometheus"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/xrpl"
+	"github.com/CoreumFoundation/crust/znet/infra/targets"
+)
+
+// Console windows.
+const (
+	consoleWindowCored      = "cored"
+	consoleWindowIBC        = "ibc"
+	consoleWindowExplorer   = "explorer"
+	consoleWindowMonitoring = "monitoring"
+	consoleWindowBridge     = "bridge"
+	consoleWindowOther      = "other"
+)
+
+var consoleWindows = []string{
+	consoleWindowCored,
+	consoleWindowIBC,
+	consoleWindowExplorer,
+	consoleWindowMonitoring,
+	consoleWindowBridge,
+	consoleWindowOther,
+}
+
+var consoleWindowByAppType = map[infra.AppType]string{
+	cored.AppType:      consoleWindowCored,
+	faucet.AppType:     consoleWindowCored,
+	gaiad.AppType:      consoleWindowIBC,
+	osmosis.AppType:    consoleWindowIBC,
+	hermes.AppType:     consoleWindowIBC,
+	postgres.AppType:   consoleWindowExplorer,
+	callisto.AppType:   consoleWindowExplorer,
+	hasura.AppType:     consoleWindowExplorer,
+	bigdipper.AppType:  consoleWindowExplorer,
+	prometheus.AppType: consoleWindowMonitoring,
+	grafana.AppType:    consoleWindowMonitoring,
+	xrpl.AppType:       consoleWindowBridge,
+	bridgexrpl.AppType: consoleWindowBridge,
+}
+
+// Console starts tmux session containing logs of all the running applications.
+// If session already exists, it is reattached.
+func Console(ctx context.Context, configF *infra.ConfigFactory) error {
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	session := "znet-" + config.EnvName
+
+	if err := libexec.Exec(ctx, exec.TMuxNoOut("has-session", "-t", session)); err != nil {
+		if err := createConsoleSession(ctx, session, config, spec); err != nil {
+			return err
+		}
+	}
+
+	// Inside tmux we can't attach to another session, but we may switch the client to it.
+	attachCmd := exec.TMux("attach-session", "-t", session)
+	if os.Getenv("TMUX") != "" {
+		attachCmd = exec.TMux("switch-client", "-t", session)
+	}
+	attachCmd.Stdin = os.Stdin
+	attachCmd.Stdout = os.Stdout
+	attachCmd.Stderr = os.Stderr
+	return libexec.Exec(ctx, attachCmd)
+}
+
+func createConsoleSession(ctx context.Context, session string, config infra.Config, spec *infra.Spec) error {
+	windows := map[string][]string{}
+	for appName, app := range spec.Apps {
+		if app.Info().Container == "" && app.Info().PID == 0 {
+			continue
+		}
+		window, exists := consoleWindowByAppType[app.Type()]
+		if !exists {
+			window = consoleWindowOther
+		}
+		windows[window] = append(windows[window], appName)
+	}
+	if len(windows) == 0 {
+		return errors.New("there are no applications deployed in the environment, start it first")
+	}
+
+	err := func() error {
+		sessionCreated := false
+		for _, window := range consoleWindows {
+			appNames := windows[window]
+			if len(appNames) == 0 {
+				continue
+			}
+			sort.Strings(appNames)
+
+			target := session + ":" + window
+			for i, appName := range appNames {
+				logsCmd := "docker logs -f --tail 1000 " + spec.Apps[appName].Info().Container
+				if spec.Apps[appName].Info().Container == "" {
+					// Apps running as host processes write logs to files.
+					logsCmd = "tail -n 1000 -F " + targets.NativeLogFile(config, appName)
+				}
+
+				var args []string
+				switch {
+				case !sessionCreated:
+					args = []string{"new-session", "-d", "-s", session, "-n", window}
+					sessionCreated = true
+				case i == 0:
+					args = []string{"new-window", "-d", "-t", session, "-n", window}
+				default:
+					args = []string{"split-window", "-d", "-t", target}
+				}
+				paneID, err := tmuxOutput(ctx, append(args, "-P", "-F", "#{pane_id}", logsCmd)...)
+				if err != nil {
+					return err
+				}
+
+				cmds := []*osexec.Cmd{
+					exec.TMuxNoOut("select-pane", "-t", paneID, "-T", appName),
+					// Layout is applied after each split, otherwise tmux refuses to split too small pane.
+					exec.TMuxNoOut("select-layout", "-t", target, "tiled"),
+				}
+				if i == 0 {
+					cmds = append(cmds,
+						// Keep the pane open after container stops, so the last logs are still visible.
+						exec.TMuxNoOut("set-option", "-w", "-t", target, "remain-on-exit", "on"),
+						exec.TMuxNoOut("set-option", "-w", "-t", target, "pane-border-status", "top"),
+						exec.TMuxNoOut("set-option", "-w", "-t", target, "pane-border-format", " #{pane_title} "),
+					)
+				}
+				if err := libexec.Exec(ctx, cmds...); err != nil {
+					return err
+				}
+			}
+		}
+		return libexec.Exec(ctx, exec.TMuxNoOut("select-window", "-t", session+":"+firstConsoleWindow(windows)))
+	}()
+	if err != nil {
+		// Don't leave half-configured session behind.
+		_ = libexec.Exec(ctx, exec.TMuxNoOut("kill-session", "-t", session))
+		return errors.Wrap(err, "creating tmux session failed")
+	}
+	return nil
+}
+
+func tmuxOutput(ctx context.Context, args ...string) (string, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.TMux(args...)
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func firstConsoleWindow(windows map[string][]string) string {
+	for _, window := range consoleWindows {
+		if len(windows[window]) > 0 {
+			return window
+		}
+	}
+	return consoleWindows[0]
+}
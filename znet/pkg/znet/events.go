@@ -0,0 +1,137 @@
+package znet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/crust/znet/infra"
+)
+
+// withEventHandlers returns context reporting deployment events to the stream and the progress view enabled by
+// the config. Returned function closes the stream.
+func withEventHandlers(ctx context.Context, configF *infra.ConfigFactory) (context.Context, func(), error) {
+	closeFn := func() {}
+	if configF.Events != "" {
+		stream, err := openEventStream(configF.Events)
+		if err != nil {
+			return nil, nil, err
+		}
+		ctx = infra.WithEventHandler(ctx, newEventWriter(ctx, stream))
+		closeFn = func() {
+			_ = stream.Close()
+		}
+	}
+	if configF.Progress {
+		ctx = infra.WithEventHandler(ctx, newProgressView(os.Stderr).handle)
+	}
+	return ctx, closeFn, nil
+}
+
+// openEventStream opens the file or socket events are written to. Sockets are selected using unix:// and tcp://
+// prefixes, any other value is the path to the file.
+func openEventStream(address string) (io.WriteCloser, error) {
+	for _, network := range []string{"unix", "tcp"} {
+		if addr, ok := strings.CutPrefix(address, network+"://"); ok {
+			conn, err := net.Dial(network, addr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "connecting to event stream %s failed", address)
+			}
+			return conn, nil
+		}
+	}
+
+	f, err := os.OpenFile(address, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening event stream %s failed", address)
+	}
+	return f, nil
+}
+
+// newEventWriter returns event handler writing events to the stream as JSON lines. Deployment is not interrupted
+// if writing fails, the failure is logged once.
+func newEventWriter(ctx context.Context, w io.Writer) infra.EventHandler {
+	var mu sync.Mutex
+	var failed bool
+	encoder := json.NewEncoder(w)
+	return func(event infra.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failed {
+			return
+		}
+		if err := encoder.Encode(event); err != nil {
+			failed = true
+			logger.Get(ctx).Warn("Writing deployment events failed", zap.Error(err))
+		}
+	}
+}
+
+func newProgressView(w io.Writer) *progressView {
+	return &progressView{
+		w:        w,
+		deployed: map[string]bool{},
+		healthy:  map[string]bool{},
+	}
+}
+
+// progressView prints compact, single-line summary of each state transition of the deployment.
+type progressView struct {
+	w io.Writer
+
+	mu       sync.Mutex
+	deployed map[string]bool
+	healthy  map[string]bool
+	failed   int
+}
+
+func (v *progressView) handle(event infra.Event) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var description string
+	switch event.Type {
+	case infra.EventImagePullStarted:
+		description = "pulling"
+	case infra.EventImagePullFinished:
+		description = "pulled"
+		if event.Error != "" {
+			description = "pull failed: " + firstLine(event.Error)
+		}
+	case infra.EventWaitingForDependencies:
+		description = "waiting for " + strings.Join(event.Dependencies, ", ")
+	case infra.EventSlotAcquired:
+		description = "deploying"
+	case infra.EventContainerStarted:
+		description = "started"
+	case infra.EventDeploymentSucceeded:
+		v.deployed[event.AppName] = true
+		description = "deployed"
+	case infra.EventDeploymentFailed:
+		v.failed++
+		description = "failed: " + firstLine(event.Error)
+	case infra.EventHealthCheckPassed:
+		v.healthy[event.AppName] = true
+		description = "healthy"
+	default:
+		// Health check attempts are too frequent to be printed.
+		return
+	}
+
+	subject := event.AppName
+	if subject == "" {
+		subject = event.Image
+	}
+	fmt.Fprintf(v.w, "%s [deployed: %d, healthy: %d, failed: %d] %s: %s\n", event.Time.Local().Format("15:04:05"),
+		len(v.deployed), len(v.healthy), v.failed, subject, description)
+}
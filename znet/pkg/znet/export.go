@@ -0,0 +1,73 @@
+package znet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps"
+	"github.com/CoreumFoundation/crust/znet/infra/targets"
+)
+
+// ExportCompose generates files of the environment and docker compose file starting it, inside the output directory.
+func ExportCompose(ctx context.Context, configF *infra.ConfigFactory, outputDir string) error {
+	return export(ctx, configF, outputDir, func(ctx context.Context, config infra.Config, appSet infra.AppSet) (
+		string, error,
+	) {
+		return targets.NewCompose(config).Export(ctx, appSet)
+	})
+}
+
+// ExportKubernetes generates files of the environment and kubernetes manifests starting it, inside the output
+// directory.
+func ExportKubernetes(ctx context.Context, configF *infra.ConfigFactory, outputDir string) error {
+	return export(ctx, configF, outputDir, func(ctx context.Context, config infra.Config, appSet infra.AppSet) (
+		string, error,
+	) {
+		return targets.NewKubernetes(config).Export(ctx, appSet)
+	})
+}
+
+func export(
+	ctx context.Context,
+	configF *infra.ConfigFactory,
+	outputDir string,
+	exportFn func(ctx context.Context, config infra.Config, appSet infra.AppSet) (string, error),
+) error {
+	if err := loadDefinition(configF); err != nil {
+		return err
+	}
+
+	// Exported environment is generated from scratch, it has nothing in common with the one running locally.
+	exportF := *configF
+	exportF.HomeDir = outputDir
+
+	envDir := filepath.Join(outputDir, exportF.EnvName)
+	switch _, err := os.Stat(envDir); {
+	case err == nil:
+		return errors.Errorf("directory %s already exists", envDir)
+	case !errors.Is(err, os.ErrNotExist):
+		return errors.WithStack(err)
+	}
+
+	spec := infra.NewSpec(&exportF)
+	config := NewConfig(&exportF, spec)
+
+	appF := apps.NewFactory(config, spec)
+	appSet, _, err := apps.BuildAppSet(ctx, appF, config.Definition, config.CoredVersion)
+	if err != nil {
+		return err
+	}
+
+	path, err := exportFn(ctx, config, appSet)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(path)
+	return nil
+}
@@ -0,0 +1,213 @@
+package znet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps"
+	"github.com/CoreumFoundation/crust/znet/infra/targets"
+)
+
+// imageTarget is the target storing docker images used by the environment.
+type imageTarget interface {
+	infra.AppTarget
+	infra.ImageArchiver
+}
+
+// Images prints docker images required by the environment. Error is returned if any of them is missing.
+func Images(ctx context.Context, configF *infra.ConfigFactory) error {
+	images, missing, _, err := environmentImages(ctx, configF)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tSTATUS")
+	for _, image := range images {
+		fmt.Fprintf(w, "%s\t%s\n", image, lo.Ternary(lo.Contains(missing, image), "missing", "present"))
+	}
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("docker images %s are missing", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// SaveImages saves all the docker images required by the environment to the tarball. Missing images are pulled
+// first, unless offline mode is enabled.
+func SaveImages(ctx context.Context, configF *infra.ConfigFactory, path string) error {
+	images, missing, target, err := environmentImages(ctx, configF)
+	if err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		if configF.Offline {
+			return errors.Errorf("docker images %s are missing", strings.Join(missing, ", "))
+		}
+		for _, image := range missing {
+			if err := target.PullImage(ctx, image); err != nil {
+				return err
+			}
+		}
+	}
+
+	return target.SaveImages(ctx, images, path)
+}
+
+// LoadImages loads docker images from the tarball created by SaveImages.
+func LoadImages(ctx context.Context, configF *infra.ConfigFactory, path string) error {
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	return targets.NewDocker(config, spec).(imageTarget).LoadImages(ctx, path)
+}
+
+// LockImages pins digests of docker images used by all the available profiles in the lock file. Images are pulled
+// first to pin their latest versions, unless offline mode is enabled.
+func LockImages(ctx context.Context, configF *infra.ConfigFactory) error {
+	images, target, err := pinnedImages(ctx, configF)
+	if err != nil {
+		return err
+	}
+
+	lock := infra.ImageLock{}
+	for _, image := range images {
+		if !configF.Offline {
+			if err := target.PullImage(ctx, image); err != nil {
+				return err
+			}
+		}
+		digests, err := target.ImageDigests(ctx, image)
+		if err != nil {
+			return err
+		}
+		if len(digests) == 0 {
+			return errors.Errorf("digest of docker image %s is unknown, pull it from the registry", image)
+		}
+		lock[image] = digests[0]
+	}
+
+	path := filepath.Join(configF.RootDir, infra.ImageLockFile)
+	if err := lock.Save(path); err != nil {
+		return err
+	}
+	logger.Get(ctx).Info("Docker images pinned", zap.String("path", path), zap.Int("images", len(lock)))
+	return nil
+}
+
+// checkImageLock verifies that the lock file pins all the docker images used by the available profiles.
+func checkImageLock(ctx context.Context, configF *infra.ConfigFactory) error {
+	path := filepath.Join(configF.RootDir, infra.ImageLockFile)
+	lock, err := infra.LoadImageLock(path)
+	if err != nil {
+		return errors.Wrapf(err, "loading image lock file failed, create it using `znet images lock`")
+	}
+
+	images, _, err := pinnedImages(ctx, configF)
+	if err != nil {
+		return err
+	}
+	unpinned := lo.Filter(images, func(image string, _ int) bool {
+		_, exists := lock[image]
+		return !exists
+	})
+	if len(unpinned) > 0 {
+		return errors.Errorf("docker images %s are not pinned in %s, update it using `znet images lock`",
+			strings.Join(unpinned, ", "), path)
+	}
+	return nil
+}
+
+// pinnedImages returns docker images pulled from registries by the apps of all the available profiles.
+func pinnedImages(ctx context.Context, configF *infra.ConfigFactory) ([]string, imageTarget, error) {
+	lockF := *configF
+	lockF.Profiles = lockProfiles(configF)
+	lockF.Definition = nil
+	lockF.EnvFile = ""
+	if err := loadDefinition(&lockF); err != nil {
+		return nil, nil, err
+	}
+
+	config, appSet, target, err := environmentAppSet(ctx, &lockF)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lo.Filter(appSet.Images(config), func(image string, _ int) bool {
+		return !infra.IsLocalImage(image)
+	}), target, nil
+}
+
+// lockProfiles returns profiles enabling all the apps, including the ones provided by extensions.
+func lockProfiles(configF *infra.ConfigFactory) []string {
+	profiles := []string{
+		apps.Profile1Cored,
+		apps.ProfileIBC,
+		apps.ProfileFaucet,
+		apps.ProfileExplorer,
+		apps.ProfileMonitoring,
+		apps.ProfileXRPL,
+		apps.ProfileXRPLBridge,
+		apps.ProfileDEX,
+	}
+	for _, ext := range configF.Extensions {
+		profiles = append(profiles, ext.Profile)
+	}
+	return profiles
+}
+
+// environmentImages returns all the docker images required by the environment and the missing ones.
+func environmentImages(ctx context.Context, configF *infra.ConfigFactory) ([]string, []string, imageTarget, error) {
+	if err := loadDefinition(configF); err != nil {
+		return nil, nil, nil, err
+	}
+
+	config, appSet, target, err := environmentAppSet(ctx, configF)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	missing, err := appSet.MissingImages(ctx, target, config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return appSet.Images(config), missing, target, nil
+}
+
+// environmentAppSet builds the apps of the environment without affecting the running one.
+func environmentAppSet(ctx context.Context, configF *infra.ConfigFactory) (
+	infra.Config, infra.AppSet, imageTarget, error,
+) {
+	// Environment is built in temporary directory, so the running one is not affected.
+	homeDir, err := os.MkdirTemp("", "znet-images-*")
+	if err != nil {
+		return infra.Config{}, nil, nil, errors.WithStack(err)
+	}
+	defer os.RemoveAll(homeDir)
+
+	imagesF := *configF
+	imagesF.HomeDir = homeDir
+
+	spec := infra.NewSpec(&imagesF)
+	config := NewConfig(&imagesF, spec)
+
+	appSet, _, err := apps.BuildAppSet(ctx, apps.NewFactory(config, spec), config.Definition, config.CoredVersion)
+	if err != nil {
+		return infra.Config{}, nil, nil, err
+	}
+	return config, appSet, targets.NewDocker(config, spec).(imageTarget), nil
+}
@@ -0,0 +1,28 @@
+package znet
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/crust/znet/infra"
+)
+
+func TestImageLockPinsAllImages(t *testing.T) {
+	rootDir, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	require.NoError(t, err)
+
+	require.NoError(t, checkImageLock(context.Background(), &infra.ConfigFactory{
+		EnvName:       "znet",
+		TimeoutCommit: time.Second,
+		Validators:    -1,
+		Sentries:      -1,
+		Seeds:         -1,
+		FullNodes:     -1,
+		HomeDir:       t.TempDir(),
+		RootDir:       rootDir,
+	}))
+}
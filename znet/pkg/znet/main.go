@@ -4,10 +4,12 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 
 	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
@@ -15,6 +17,7 @@ import (
 	"github.com/CoreumFoundation/coreum-tools/pkg/run"
 	"github.com/CoreumFoundation/crust/znet/infra"
 	"github.com/CoreumFoundation/crust/znet/infra/apps"
+	"github.com/CoreumFoundation/crust/znet/infra/znettest"
 )
 
 // Main is the main function of znet.
@@ -29,8 +32,18 @@ func Main(opts ...infra.ConfigFactoryOption) {
 		rootCmd := rootCmd(ctx, configF, cmdF)
 		rootCmd.AddCommand(startCmd(ctx, configF, cmdF))
 		rootCmd.AddCommand(stopCmd(ctx, configF, cmdF))
+		rootCmd.AddCommand(restartCmd(ctx, configF, cmdF))
 		rootCmd.AddCommand(removeCmd(ctx, configF, cmdF))
+		rootCmd.AddCommand(testCmd(ctx, configF, cmdF))
 		rootCmd.AddCommand(specCmd(configF, cmdF))
+		rootCmd.AddCommand(definitionCmd(configF, cmdF))
+		rootCmd.AddCommand(statusCmd(ctx, configF, cmdF))
+		rootCmd.AddCommand(snapshotCmd(ctx, configF, cmdF))
+		rootCmd.AddCommand(logsCmd(ctx, configF, cmdF))
+		rootCmd.AddCommand(superviseCmd(ctx, configF, cmdF))
+		rootCmd.AddCommand(imagesCmd(ctx, configF, cmdF))
+		rootCmd.AddCommand(consoleCmd(ctx, configF, cmdF))
+		rootCmd.AddCommand(exportCmd(ctx, configF, cmdF))
 		rootCmd.AddCommand(coverageConvertCmd(ctx, configF, cmdF))
 
 		return rootCmd.Execute()
@@ -66,29 +79,79 @@ func rootCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory
 }
 
 func startCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	var supervise bool
 	startCmd := &cobra.Command{
-		Use:   "start",
-		Short: "Starts environment",
-		RunE: cmdF.Cmd(func() error {
-			return Start(ctx, configF)
+		Use:   "start [app]...",
+		Short: "Starts environment or selected applications together with their dependencies",
+		RunE: cmdF.CmdWithArgs(func(appNames []string) error {
+			ctx, closeEvents, err := withEventHandlers(ctx, configF)
+			if err != nil {
+				return err
+			}
+			defer closeEvents()
+
+			if len(appNames) > 0 {
+				err = StartApps(ctx, configF, appNames)
+			} else {
+				err = Start(ctx, configF)
+			}
+			if err != nil || !supervise {
+				return err
+			}
+			return Supervise(ctx, configF)
 		}),
 	}
 	addRootDirFlag(startCmd, configF)
 	addProfileFlag(startCmd, configF)
 	addCoredVersionFlag(startCmd, configF)
 	addTimeoutCommitFlag(startCmd, configF)
+	addEnvFileFlag(startCmd, configF)
+	addGenesisPatchFlag(startCmd, configF)
+	addGenesisFixtureFlag(startCmd, configF)
+	addCoredNodesFlags(startCmd, configF)
+	addStakeWeightsFlag(startCmd, configF)
+	addCoredNodeVersionFlag(startCmd, configF)
+	addTargetFlag(startCmd, configF)
+	addLimitsFlag(startCmd, configF)
+	addSuperviseFlag(startCmd, &supervise)
+	addOfflineFlag(startCmd, configF)
+	addAllowUnpinnedImagesFlag(startCmd, configF)
+	addEventsFlag(startCmd, configF)
+	addProgressFlag(startCmd, configF)
 
 	return startCmd
 }
 
 func stopCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
-	return &cobra.Command{
-		Use:   "stop",
-		Short: "Stops environment",
-		RunE: cmdF.Cmd(func() error {
+	var cascade bool
+	stopCmd := &cobra.Command{
+		Use:   "stop [app]...",
+		Short: "Stops environment or selected applications",
+		RunE: cmdF.CmdWithArgs(func(appNames []string) error {
+			if len(appNames) > 0 {
+				return StopApps(ctx, configF, appNames, cascade)
+			}
 			return Stop(ctx, configF)
 		}),
 	}
+	addCascadeFlag(stopCmd, &cascade)
+
+	return stopCmd
+}
+
+func restartCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	var cascade bool
+	restartCmd := &cobra.Command{
+		Use:   "restart app...",
+		Short: "Restarts selected applications",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: cmdF.CmdWithArgs(func(appNames []string) error {
+			return RestartApps(ctx, configF, appNames, cascade)
+		}),
+	}
+	addCascadeFlag(restartCmd, &cascade)
+
+	return restartCmd
 }
 
 func removeCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
@@ -101,6 +164,25 @@ func removeCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFacto
 	}
 }
 
+func testCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Runs integration tests",
+		RunE: cmdF.Cmd(func() error {
+			return Test(ctx, configF)
+		}),
+	}
+	addRootDirFlag(testCmd, configF)
+	addCoredVersionFlag(testCmd, configF)
+	addTimeoutCommitFlag(testCmd, configF)
+	addTestGroupsFlag(testCmd, configF)
+	addTestFilterFlag(testCmd, configF)
+	addTestTimeoutFlag(testCmd, configF)
+	addTestBinariesFlag(testCmd, configF)
+
+	return testCmd
+}
+
 func specCmd(configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
 	return &cobra.Command{
 		Use:   "spec",
@@ -112,6 +194,181 @@ func specCmd(configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
 	}
 }
 
+func definitionCmd(configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	return &cobra.Command{
+		Use:   "definition",
+		Short: "Prints definition of the environment in the format accepted by --from-file flag",
+		RunE: cmdF.Cmd(func() error {
+			spec := infra.NewSpec(configF)
+			return Definition(spec)
+		}),
+	}
+}
+
+func statusCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	var jsonOutput bool
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Prints live status of applications running in the environment",
+		RunE: cmdF.Cmd(func() error {
+			return Status(ctx, configF, jsonOutput)
+		}),
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print status in JSON format")
+	return cmd
+}
+
+func snapshotCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Saves and restores snapshots of the environment",
+	}
+	snapshotCmd.AddCommand(&cobra.Command{
+		Use:   "save name",
+		Short: "Stops environment and saves its state in the snapshot",
+		Args:  cobra.ExactArgs(1),
+		RunE: cmdF.CmdWithArgs(func(args []string) error {
+			return SnapshotSave(ctx, configF, args[0])
+		}),
+	})
+	snapshotCmd.AddCommand(&cobra.Command{
+		Use:   "restore name",
+		Short: "Replaces environment with the state saved in the snapshot and starts it",
+		Args:  cobra.ExactArgs(1),
+		RunE: cmdF.CmdWithArgs(func(args []string) error {
+			return SnapshotRestore(ctx, configF, args[0])
+		}),
+	})
+	snapshotCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "Prints the list of saved snapshots",
+		RunE: cmdF.Cmd(func() error {
+			return SnapshotList(configF)
+		}),
+	})
+	return snapshotCmd
+}
+
+func logsCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logs app",
+		Short: "Streams logs of the application",
+		Args:  cobra.ExactArgs(1),
+		RunE: cmdF.CmdWithArgs(func(args []string) error {
+			return Logs(ctx, configF, args[0])
+		}),
+	}
+}
+
+func superviseCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	return &cobra.Command{
+		Use:   "supervise",
+		Short: "Restarts crashed applications until the command is interrupted or environment is removed",
+		RunE: cmdF.Cmd(func() error {
+			return Supervise(ctx, configF)
+		}),
+	}
+}
+
+func imagesCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	imagesCmd := &cobra.Command{
+		Use:   "images",
+		Short: "Lists docker images required by the environment and reports the missing ones",
+		RunE: cmdF.Cmd(func() error {
+			return Images(ctx, configF)
+		}),
+	}
+
+	saveCmd := &cobra.Command{
+		Use:   "save file",
+		Short: "Saves all the docker images required by the environment to the tarball",
+		Args:  cobra.ExactArgs(1),
+		RunE: cmdF.CmdWithArgs(func(args []string) error {
+			return SaveImages(ctx, configF, args[0])
+		}),
+	}
+	addOfflineFlag(saveCmd, configF)
+	imagesCmd.AddCommand(saveCmd)
+
+	imagesCmd.AddCommand(&cobra.Command{
+		Use:   "load file",
+		Short: "Loads docker images from the tarball created by save command",
+		Args:  cobra.ExactArgs(1),
+		RunE: cmdF.CmdWithArgs(func(args []string) error {
+			return LoadImages(ctx, configF, args[0])
+		}),
+	})
+
+	lockCmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Pins digests of docker images used by all the profiles in the lock file",
+		RunE: cmdF.Cmd(func() error {
+			return LockImages(ctx, configF)
+		}),
+	}
+	addRootDirFlag(lockCmd, configF)
+	addOfflineFlag(lockCmd, configF)
+	imagesCmd.AddCommand(lockCmd)
+
+	for _, cmd := range []*cobra.Command{imagesCmd, saveCmd} {
+		addRootDirFlag(cmd, configF)
+		addProfileFlag(cmd, configF)
+		addCoredVersionFlag(cmd, configF)
+		addEnvFileFlag(cmd, configF)
+	}
+
+	return imagesCmd
+}
+
+func consoleCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	return &cobra.Command{
+		Use:   "console",
+		Short: "Starts tmux session containing logs of all the running applications",
+		RunE: cmdF.Cmd(func() error {
+			return Console(ctx, configF)
+		}),
+	}
+}
+
+func exportCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Exports environment, so it might be started without znet",
+	}
+
+	var outputDir string
+	composeCmd := &cobra.Command{
+		Use:   "compose",
+		Short: "Generates files of the environment and docker compose file starting it",
+		RunE: cmdF.Cmd(func() error {
+			return ExportCompose(ctx, configF, outputDir)
+		}),
+	}
+	exportCmd.AddCommand(composeCmd)
+
+	k8sCmd := &cobra.Command{
+		Use:   "k8s",
+		Short: "Generates files of the environment and kubernetes manifests starting it",
+		RunE: cmdF.Cmd(func() error {
+			return ExportKubernetes(ctx, configF, outputDir)
+		}),
+	}
+	exportCmd.AddCommand(k8sCmd)
+
+	for _, cmd := range []*cobra.Command{composeCmd, k8sCmd} {
+		cmd.Flags().StringVar(&outputDir, "output", "znet-export", "Directory where exported environment is stored")
+		addRootDirFlag(cmd, configF)
+		addProfileFlag(cmd, configF)
+		addCoredVersionFlag(cmd, configF)
+		addTimeoutCommitFlag(cmd, configF)
+		addEnvFileFlag(cmd, configF)
+		addLimitsFlag(cmd, configF)
+	}
+
+	return exportCmd
+}
+
 func coverageConvertCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF *CmdFactory) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "coverage-convert",
@@ -125,6 +382,144 @@ func coverageConvertCmd(ctx context.Context, configF *infra.ConfigFactory, cmdF
 	return cmd
 }
 
+func addEnvFileFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().StringVar(
+		&configF.EnvFile,
+		"from-file",
+		defaultString("CRUST_ZNET_ENV_FILE", ""),
+		"Path to the YAML file defining the environment, used instead of profiles",
+	)
+}
+
+func addTargetFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().StringVar(
+		&configF.Target,
+		"target",
+		defaultString("CRUST_ZNET_TARGET", ""),
+		"Target apps are deployed to: "+infra.TargetDocker+" | "+infra.TargetNative+
+			", the existing environment keeps its target",
+	)
+}
+
+func addLimitsFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().Var(
+		&limitsFlag{limits: &configF.Resources},
+		"limits",
+		"Resource limits replacing the default ones of the app type, "+
+			"in the format <app type>:cpus=<cpus>,memory=<memory>,pids=<pids> or <app type>:none, may be repeated",
+	)
+}
+
+func addCascadeFlag(cmd *cobra.Command, cascade *bool) {
+	cmd.Flags().BoolVar(
+		cascade,
+		"cascade",
+		false,
+		"Stop also the applications depending on the selected ones without asking",
+	)
+}
+
+func addOfflineFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().BoolVar(
+		&configF.Offline,
+		"offline",
+		defaultString("CRUST_ZNET_OFFLINE", "") == "true",
+		"Don't pull docker images, fail if any of them is missing",
+	)
+}
+
+func addAllowUnpinnedImagesFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().BoolVar(
+		&configF.AllowUnpinnedImages,
+		"allow-unpinned-images",
+		false,
+		"Use docker images which are not pinned in the lock file or don't match their pinned digests",
+	)
+}
+
+func addGenesisPatchFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().StringVar(
+		&configF.GenesisPatchFile,
+		"genesis-patch",
+		defaultString("CRUST_ZNET_GENESIS_PATCH", ""),
+		"Path to the JSON patch (RFC 6902) or JSON merge patch (RFC 7396) applied to genesis of cored network",
+	)
+}
+
+func addGenesisFixtureFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().StringVar(
+		&configF.GenesisFixtureFile,
+		"genesis-fixture",
+		defaultString("CRUST_ZNET_GENESIS_FIXTURE", ""),
+		"Path to the YAML or JSON file declaring balances, assets and messages included in genesis of cored network",
+	)
+}
+
+func addCoredNodesFlags(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	for _, flag := range []struct {
+		value *int
+		name  string
+		usage string
+	}{
+		{value: &configF.Validators, name: "validators", usage: "Number of cored validators"},
+		{value: &configF.Sentries, name: "sentries", usage: "Number of cored sentry nodes"},
+		{value: &configF.Seeds, name: "seeds", usage: "Number of cored seed nodes"},
+		{value: &configF.FullNodes, name: "full-nodes", usage: "Number of cored full nodes"},
+	} {
+		cmd.Flags().IntVar(
+			flag.value,
+			flag.name,
+			-1,
+			flag.usage+", overrides the number defined by profiles or --from-file, negative value keeps it",
+		)
+	}
+}
+
+func addStakeWeightsFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().IntSliceVar(
+		&configF.StakeWeights,
+		"stake-weights",
+		nil,
+		"Comma-separated stakes of cored validators as multiples of the default stake, missing ones are set to 1",
+	)
+}
+
+func addCoredNodeVersionFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().StringToStringVar(
+		&configF.CoredNodeVersions,
+		"cored-node-version",
+		nil,
+		"Version of the binary used by the cored node, in the format <node>=<version>, may be repeated",
+	)
+}
+
+func addEventsFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().StringVar(
+		&configF.Events,
+		"events",
+		defaultString("CRUST_ZNET_EVENTS", ""),
+		"File or socket (unix://path, tcp://host:port) deployment events are written to as JSON lines",
+	)
+}
+
+func addProgressFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().BoolVar(
+		&configF.Progress,
+		"progress",
+		false,
+		"Print progress of the deployment",
+	)
+}
+
+func addSuperviseFlag(cmd *cobra.Command, supervise *bool) {
+	cmd.Flags().BoolVar(
+		supervise,
+		"supervise",
+		false,
+		"Keep running after applications are started, restarting the crashed ones",
+	)
+}
+
 func addRootDirFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
 	cmd.Flags().StringVar(
 		&configF.RootDir,
@@ -139,7 +534,8 @@ func addProfileFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
 		&configF.Profiles,
 		"profiles",
 		defaultStrings("CRUST_ZNET_PROFILES", apps.DefaultProfiles()),
-		"List of application profiles to deploy: "+strings.Join(apps.Profiles(), " | "),
+		"List of application profiles to deploy: "+strings.Join(append(append([]string{}, apps.Profiles()...),
+			lo.Map(configF.Extensions, func(ext infra.Extension, _ int) string { return ext.Profile })...), " | "),
 	)
 }
 
@@ -161,6 +557,42 @@ func addCoredVersionFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
 	)
 }
 
+func addTestGroupsFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().StringSliceVar(
+		&configF.TestGroups,
+		"test-groups",
+		defaultStrings("CRUST_ZNET_TEST_GROUPS", znettest.DefaultGroups()),
+		"Test groups to run: "+strings.Join(znettest.Groups(), " | "),
+	)
+}
+
+func addTestFilterFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().StringVar(
+		&configF.TestFilter,
+		"run",
+		defaultString("CRUST_ZNET_TEST_FILTER", ""),
+		"Regular expression used to run only those tests matching it",
+	)
+}
+
+func addTestTimeoutFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().DurationVar(
+		&configF.TestTimeout,
+		"test-timeout",
+		znettest.DefaultTimeout,
+		"Maximum time a single test group may run",
+	)
+}
+
+func addTestBinariesFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
+	cmd.Flags().StringVar(
+		&configF.TestBinariesDir,
+		"test-binaries",
+		defaultString("CRUST_ZNET_TEST_BINARIES", ""),
+		"Directory containing prebuilt test binaries named after test groups, if not set binaries are built",
+	)
+}
+
 func addCoverageOutputFlag(cmd *cobra.Command, configF *infra.ConfigFactory) {
 	cmd.Flags().StringVar(
 		&configF.CoverageOutputFile,
@@ -201,3 +633,40 @@ func defaultStrings(env string, def []string) []string {
 	}
 	return def
 }
+
+// limitsFlag parses resource limits of app types passed in the command line.
+type limitsFlag struct {
+	limits *map[infra.AppType]infra.Resources
+}
+
+func (f *limitsFlag) String() string {
+	if f.limits == nil || len(*f.limits) == 0 {
+		return ""
+	}
+	limits := make([]string, 0, len(*f.limits))
+	for appType, resources := range *f.limits {
+		limits = append(limits, string(appType)+":"+resources.String())
+	}
+	sort.Strings(limits)
+	return strings.Join(limits, " ")
+}
+
+func (f *limitsFlag) Set(value string) error {
+	appType, limits, ok := strings.Cut(value, ":")
+	if !ok || appType == "" {
+		return errors.Errorf("invalid limits %q, expected <app type>:<limits>", value)
+	}
+	resources, err := infra.ParseResources(limits)
+	if err != nil {
+		return err
+	}
+	if *f.limits == nil {
+		*f.limits = map[infra.AppType]infra.Resources{}
+	}
+	(*f.limits)[infra.AppType(appType)] = resources
+	return nil
+}
+
+func (f *limitsFlag) Type() string {
+	return "limits"
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/CoreumFoundation/coreum-tools/pkg/must"
 	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps"
+	"github.com/CoreumFoundation/crust/znet/infra/targets"
 )
 
 // CmdFactory is a wrapper around cobra RunE.
@@ -25,10 +27,18 @@ func NewCmdFactory(configF *infra.ConfigFactory) *CmdFactory {
 
 // Cmd returns function compatible with RunE.
 func (f *CmdFactory) Cmd(cmdFunc func() error) func(cmd *cobra.Command, args []string) error {
+	return f.CmdWithArgs(func([]string) error {
+		return cmdFunc()
+	})
+}
+
+// CmdWithArgs returns function compatible with RunE, passing positional arguments to the command.
+func (f *CmdFactory) CmdWithArgs(cmdFunc func(args []string) error) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		f.configF.VerboseLogging = cmd.Flags().Lookup("verbose").Value.String() == "true"
 		f.configF.LogFormat = cmd.Flags().Lookup("log-format").Value.String()
-		return cmdFunc()
+		f.configF.ProfilesSet = cmd.Flags().Changed("profiles") || os.Getenv("CRUST_ZNET_PROFILES") != ""
+		return cmdFunc(args)
 	}
 }
 
@@ -40,19 +50,41 @@ func NewConfig(configF *infra.ConfigFactory, spec *infra.Spec) infra.Config {
 		panic(err)
 	}
 
+	definition := apps.PresetDefinition(spec.Profiles)
+	if spec.Definition != nil {
+		definition = *spec.Definition
+	}
+
+	resources := apps.DefaultResources()
+	for appType, limits := range configF.Resources {
+		resources[appType] = limits
+	}
+
 	config := infra.Config{
-		EnvName:            configF.EnvName,
-		Profiles:           spec.Profiles,
-		TimeoutCommit:      spec.TimeoutCommit,
-		CoredVersion:       configF.CoredVersion,
-		HomeDir:            homeDir,
-		RootDir:            configF.RootDir,
-		AppDir:             homeDir + "/app",
-		WrapperDir:         homeDir + "/bin",
-		VerboseLogging:     configF.VerboseLogging,
-		LogFormat:          configF.LogFormat,
-		CoverageOutputFile: configF.CoverageOutputFile,
-		CoredUpgrades:      configF.CoredUpgrades,
+		EnvName:             configF.EnvName,
+		Profiles:            spec.Profiles,
+		Definition:          definition,
+		TimeoutCommit:       spec.TimeoutCommit,
+		CoredVersion:        configF.CoredVersion,
+		Resources:           resources,
+		HomeDir:             homeDir,
+		RootDir:             configF.RootDir,
+		AppDir:              homeDir + "/app",
+		WrapperDir:          homeDir + "/bin",
+		Offline:             configF.Offline,
+		ImageLockFile:       imageLockFile(configF, spec),
+		AllowUnpinnedImages: configF.AllowUnpinnedImages,
+		VerboseLogging:      configF.VerboseLogging,
+		LogFormat:           configF.LogFormat,
+		CoverageOutputFile:  configF.CoverageOutputFile,
+		GenesisPatch:        spec.GenesisPatch,
+		GenesisFixture:      spec.GenesisFixture,
+		CoredUpgrades:       configF.CoredUpgrades,
+		Extensions:          configF.Extensions,
+		TestGroups:          configF.TestGroups,
+		TestFilter:          configF.TestFilter,
+		TestTimeout:         configF.TestTimeout,
+		TestBinariesDir:     configF.TestBinariesDir,
 	}
 
 	createDirs(config)
@@ -60,6 +92,23 @@ func NewConfig(configF *infra.ConfigFactory, spec *infra.Spec) infra.Config {
 	return config
 }
 
+// NewTarget creates the target apps of the environment are deployed to.
+func NewTarget(config infra.Config, spec *infra.Spec) infra.Target {
+	if spec.TargetName() == infra.TargetNative {
+		return targets.NewNative(config, spec)
+	}
+	return targets.NewDocker(config, spec)
+}
+
+// imageLockFile returns the path of the file pinning docker images. Native target doesn't use images, so they are
+// not verified.
+func imageLockFile(configF *infra.ConfigFactory, spec *infra.Spec) string {
+	if spec.TargetName() == infra.TargetNative {
+		return ""
+	}
+	return filepath.Join(configF.RootDir, infra.ImageLockFile)
+}
+
 func createDirs(config infra.Config) {
 	must.OK(os.MkdirAll(config.AppDir, 0o700))
 	must.OK(os.MkdirAll(config.WrapperDir, 0o700))
@@ -0,0 +1,420 @@
+package znet
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/cored"
+)
+
+const (
+	snapshotDir          = "snapshots"
+	snapshotArchiveExt   = ".tar.gz"
+	snapshotMetadataExt  = ".json"
+	snapshotSpecFile     = "spec.json"
+	snapshotAppDirPrefix = "app"
+)
+
+var snapshotNameRegExp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// SnapshotMetadata describes the saved snapshot of the environment.
+type SnapshotMetadata struct {
+	// Name is the name of the snapshot
+	Name string `json:"name"`
+
+	// Env is the name of the environment snapshot was taken from
+	Env string `json:"env"`
+
+	// Profiles is the list of profiles deployed in the environment
+	Profiles []string `json:"profiles"`
+
+	// CoredVersion is the version of cored used by the environment
+	CoredVersion string `json:"coredVersion"`
+
+	// Height is the height of the coreum chain at the moment of taking the snapshot
+	Height int64 `json:"height,omitempty"`
+
+	// CreatedAt is the time when snapshot was taken
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SnapshotSave stops the environment and stores its state in the snapshot.
+func SnapshotSave(ctx context.Context, configF *infra.ConfigFactory, name string) error {
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	if len(spec.Apps) == 0 {
+		return errors.New("there are no applications deployed in the environment, start it first")
+	}
+
+	archivePath, metadataPath, err := snapshotPaths(configF, name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(metadataPath); err == nil {
+		return errors.Errorf("snapshot %s already exists", name)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+
+	log := logger.Get(ctx).With(zap.String("snapshot", name))
+
+	height, err := chainHeight(ctx, config, spec)
+	if err != nil {
+		log.Warn("Retrieving chain height failed", zap.Error(err))
+	}
+
+	log.Info("Stopping environment")
+	if err := Stop(ctx, configF); err != nil {
+		return err
+	}
+
+	log.Info("Archiving environment", zap.String("path", archivePath))
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0o700); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := writeSnapshotArchive(archivePath, config); err != nil {
+		return err
+	}
+
+	metadata := SnapshotMetadata{
+		Name:         name,
+		Env:          config.EnvName,
+		Profiles:     spec.Profiles,
+		CoredVersion: coredVersion(spec, config.Definition),
+		Height:       height,
+		CreatedAt:    time.Now().UTC(),
+	}
+	metadataRaw, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(metadataPath, metadataRaw, 0o600); err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Info("Snapshot saved, environment is stopped, use `start` to continue", zap.Int64("height", height))
+	return nil
+}
+
+// SnapshotRestore replaces the environment with the state stored in the snapshot and starts it.
+func SnapshotRestore(ctx context.Context, configF *infra.ConfigFactory, name string) error {
+	archivePath, metadataPath, err := snapshotPaths(configF, name)
+	if err != nil {
+		return err
+	}
+	metadata, err := readSnapshotMetadata(metadataPath)
+	if err != nil {
+		return err
+	}
+	if metadata.Env != configF.EnvName {
+		return errors.Errorf("snapshot %s was taken from environment %s, it can't be restored to %s",
+			name, metadata.Env, configF.EnvName)
+	}
+
+	// Snapshot is extracted next to the environment before it is removed, so broken snapshot doesn't destroy it.
+	log := logger.Get(ctx).With(zap.String("snapshot", name))
+	log.Info("Extracting snapshot", zap.String("path", archivePath))
+	if err := os.MkdirAll(configF.HomeDir, 0o700); err != nil {
+		return errors.WithStack(err)
+	}
+	tmpDir, err := os.MkdirTemp(configF.HomeDir, "."+configF.EnvName+"-snapshot-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := extractSnapshotArchive(archivePath, tmpDir); err != nil {
+		return errors.Wrapf(err, "extracting snapshot %s failed", name)
+	}
+
+	log.Info("Removing current environment")
+	if err := Remove(ctx, configF); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpDir, filepath.Join(configF.HomeDir, configF.EnvName)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// The same binaries must be used, otherwise chains might not be able to continue from the saved state.
+	configF.CoredVersion = metadata.CoredVersion
+
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	target := NewTarget(config, spec)
+	appSet, _, err := apps.BuildAppSet(ctx, apps.NewFactory(config, spec), config.Definition, config.CoredVersion)
+	if err != nil {
+		return err
+	}
+
+	return target.Deploy(ctx, appSet)
+}
+
+// SnapshotList prints the list of saved snapshots.
+func SnapshotList(configF *infra.ConfigFactory) error {
+	paths, err := filepath.Glob(filepath.Join(configF.HomeDir, snapshotDir, "*"+snapshotMetadataExt))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	snapshots := make([]SnapshotMetadata, 0, len(paths))
+	for _, path := range paths {
+		metadata, err := readSnapshotMetadata(path)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, metadata)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tENV\tPROFILES\tCORED VERSION\tHEIGHT\tCREATED")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", s.Name, s.Env, strings.Join(s.Profiles, ","), s.CoredVersion,
+			s.Height, s.CreatedAt.Local().Format(time.DateTime))
+	}
+	return errors.WithStack(w.Flush())
+}
+
+func snapshotPaths(configF *infra.ConfigFactory, name string) (string, string, error) {
+	if !snapshotNameRegExp.MatchString(name) {
+		return "", "", errors.Errorf("invalid snapshot name: %s", name)
+	}
+	dir := filepath.Join(configF.HomeDir, snapshotDir)
+	return filepath.Join(dir, name+snapshotArchiveExt), filepath.Join(dir, name+snapshotMetadataExt), nil
+}
+
+func readSnapshotMetadata(path string) (SnapshotMetadata, error) {
+	metadataRaw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return SnapshotMetadata{}, errors.Errorf("snapshot %s does not exist",
+			strings.TrimSuffix(filepath.Base(path), snapshotMetadataExt))
+	}
+	if err != nil {
+		return SnapshotMetadata{}, errors.WithStack(err)
+	}
+
+	var metadata SnapshotMetadata
+	if err := json.Unmarshal(metadataRaw, &metadata); err != nil {
+		return SnapshotMetadata{}, errors.Wrapf(err, "unmarshalling snapshot metadata %s failed", path)
+	}
+	return metadata, nil
+}
+
+// coredVersion returns the version of cored used by the environment. Nodes running the versions set explicitly
+// by the definition are skipped.
+func coredVersion(spec *infra.Spec, definition infra.EnvDefinition) string {
+	names := lo.Keys(spec.Apps)
+	sort.Strings(names)
+	for _, name := range names {
+		appInfo := spec.Apps[name]
+		if appInfo.Type() != cored.AppType {
+			continue
+		}
+		if _, exists := definition.Cored.Versions[name]; exists {
+			continue
+		}
+		if version := appInfo.Version(); version != cored.DefaultBinaryVersion {
+			return version
+		}
+		break
+	}
+	return ""
+}
+
+// chainHeight returns the latest height of the coreum chain.
+func chainHeight(ctx context.Context, config infra.Config, spec *infra.Spec) (int64, error) {
+	appSet, _, err := apps.BuildAppSet(ctx, apps.NewFactory(config, spec), config.Definition, config.CoredVersion)
+	if err != nil {
+		return 0, err
+	}
+	for _, app := range appSet {
+		coredApp, ok := app.(cored.Cored)
+		if !ok || coredApp.Info().Status != infra.AppStatusRunning {
+			continue
+		}
+		status, err := coredApp.ClientContext().RPCClient().Status(ctx)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		return status.SyncInfo.LatestBlockHeight, nil
+	}
+	return 0, errors.Errorf("no running %s app found", cored.AppType)
+}
+
+// writeSnapshotArchive stores spec file and app directory in the compressed tarball.
+func writeSnapshotArchive(archivePath string, config infra.Config) (retErr error) {
+	// Archive is written to temporary file first, so broken snapshot is never left behind.
+	tmpPath := archivePath + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		_ = f.Close()
+		if retErr != nil {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	if err := addToArchive(tw, filepath.Join(config.HomeDir, snapshotSpecFile), snapshotSpecFile); err != nil {
+		return err
+	}
+	err = filepath.WalkDir(config.AppDir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		relPath, err := filepath.Rel(config.AppDir, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return addToArchive(tw, path, filepath.ToSlash(filepath.Join(snapshotAppDirPrefix, relPath)))
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := gw.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := f.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmpPath, archivePath))
+}
+
+func addToArchive(tw *tar.Writer, path, name string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var link string
+	switch {
+	case info.Mode().IsDir(), info.Mode().IsRegular():
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err = os.Readlink(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	default:
+		// Sockets, pipes and devices are created by running applications and can't be archived.
+		return nil
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return errors.WithStack(err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return errors.Wrapf(err, "archiving file %s failed", path)
+}
+
+func extractSnapshotArchive(archivePath, dstDir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return errors.WithStack(err)
+		}
+
+		path := filepath.Join(dstDir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(path, filepath.Clean(dstDir)+string(filepath.Separator)) {
+			return errors.Errorf("invalid path in snapshot: %s", header.Name)
+		}
+		mode := header.FileInfo().Mode()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, mode.Perm()|0o700); err != nil {
+				return errors.WithStack(err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+				return errors.WithStack(err)
+			}
+			if err := extractFile(tr, path, mode.Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+				return errors.WithStack(err)
+			}
+			if err := os.Symlink(header.Linkname, path); err != nil {
+				return errors.WithStack(err)
+			}
+		default:
+			return errors.Errorf("unsupported file type in snapshot: %d", header.Typeflag)
+		}
+	}
+}
+
+func extractFile(r io.Reader, path string, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(f.Close())
+}
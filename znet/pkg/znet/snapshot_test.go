@@ -0,0 +1,102 @@
+package znet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/logger"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps/cored"
+)
+
+func TestSnapshotRestoreKeepsEnvironment(t *testing.T) {
+	testCases := []struct {
+		name string
+		// archive is the content of the snapshot archive, it is not created if it is nil
+		archive     []byte
+		expectedErr string
+	}{
+		{
+			name:        "missing_archive",
+			expectedErr: "no such file or directory",
+		},
+		{
+			name:        "corrupted_archive",
+			archive:     []byte("not a tarball"),
+			expectedErr: "extracting snapshot test failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configF := &infra.ConfigFactory{EnvName: "znet", HomeDir: t.TempDir()}
+			archivePath, metadataPath, err := snapshotPaths(configF, "test")
+			require.NoError(t, err)
+			require.NoError(t, os.MkdirAll(filepath.Dir(metadataPath), 0o700))
+			require.NoError(t, os.WriteFile(metadataPath, []byte(`{"name":"test","env":"znet"}`), 0o600))
+			if tc.archive != nil {
+				require.NoError(t, os.WriteFile(archivePath, tc.archive, 0o600))
+			}
+
+			specPath := filepath.Join(configF.HomeDir, configF.EnvName, snapshotSpecFile)
+			require.NoError(t, os.MkdirAll(filepath.Dir(specPath), 0o700))
+			require.NoError(t, os.WriteFile(specPath, []byte("{}"), 0o600))
+
+			ctx := logger.WithLogger(t.Context(), logger.New(logger.Config{
+				Format:  logger.FormatJSON,
+				Verbose: true,
+			}))
+			err = SnapshotRestore(ctx, configF, "test")
+			require.ErrorContains(t, err, tc.expectedErr)
+			assert.FileExists(t, specPath)
+
+			// Temporary directory is cleaned up.
+			entries, err := os.ReadDir(configF.HomeDir)
+			require.NoError(t, err)
+			assert.Len(t, entries, 2)
+		})
+	}
+}
+
+func TestCoredVersion(t *testing.T) {
+	testCases := []struct {
+		name            string
+		versions        map[string]string
+		definition      infra.EnvDefinition
+		expectedVersion string
+	}{
+		{
+			name:            "default",
+			versions:        map[string]string{"cored-00-val": cored.DefaultBinaryVersion},
+			expectedVersion: "",
+		},
+		{
+			name:            "version",
+			versions:        map[string]string{"cored-00-val": "v5.0.0", "cored-01-val": "v5.0.0"},
+			expectedVersion: "v5.0.0",
+		},
+		{
+			name:     "node_version",
+			versions: map[string]string{"cored-00-val": "v4.0.0", "cored-01-val": "v5.0.0"},
+			definition: infra.EnvDefinition{
+				Cored: infra.CoredDefinition{Versions: map[string]string{"cored-00-val": "v4.0.0"}},
+			},
+			expectedVersion: "v5.0.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := infra.NewSpec(&infra.ConfigFactory{EnvName: "znet", HomeDir: t.TempDir()})
+			spec.DescribeApp("postgres", "postgres").SetVersion("16")
+			for name, version := range tc.versions {
+				spec.DescribeApp(cored.AppType, name).SetVersion(version)
+			}
+			assert.Equal(t, tc.expectedVersion, coredVersion(spec, tc.definition))
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package znet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"github.com/CoreumFoundation/coreum-tools/pkg/parallel"
+	"github.com/CoreumFoundation/coreum/v6/pkg/client"
+	"github.com/CoreumFoundation/crust/znet/infra"
+	"github.com/CoreumFoundation/crust/znet/infra/apps"
+)
+
+// healthCheckTimeout is the time given to a single health check executed by status command.
+const healthCheckTimeout = 15 * time.Second
+
+// AppStatus describes the live status of an application.
+type AppStatus struct {
+	Name             string               `json:"name"`
+	Type             infra.AppType        `json:"type"`
+	Version          string               `json:"version,omitempty"`
+	Container        string               `json:"container,omitempty"`
+	State            string               `json:"state"`
+	ExitCode         int                  `json:"exitCode,omitempty"`
+	Healthy          bool                 `json:"healthy"`
+	Crashes          []infra.Crash        `json:"crashes,omitempty"`
+	Ports            map[string]int       `json:"ports,omitempty"` // ports published on the host
+	Usage            *infra.ResourceUsage `json:"usage,omitempty"`
+	Chain            *ChainStatus         `json:"chain,omitempty"`
+	Error            string               `json:"error,omitempty"`
+	DependsOn        []string             `json:"dependsOn,omitempty"`
+	DeploymentStatus infra.AppStatus      `json:"deploymentStatus"`
+}
+
+// ChainStatus describes the status of blockchain node.
+type ChainStatus struct {
+	Height     int64 `json:"height"`
+	CatchingUp bool  `json:"catchingUp"`
+}
+
+// chainNode is implemented by apps running blockchain nodes.
+type chainNode interface {
+	ClientContext() client.Context
+}
+
+// Status prints live status of applications running in the environment.
+func Status(ctx context.Context, configF *infra.ConfigFactory, jsonOutput bool) error {
+	spec := infra.NewSpec(configF)
+	config := NewConfig(configF, spec)
+
+	if len(spec.Apps) == 0 {
+		return errors.New("there are no applications deployed in the environment, start it first")
+	}
+
+	target := NewTarget(config, spec)
+	states, err := target.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	appSet, _, err := apps.BuildAppSet(ctx, apps.NewFactory(config, spec), config.Definition, config.CoredVersion)
+	if err != nil {
+		return err
+	}
+	healthChecks := map[string]infra.HealthCheckCapable{}
+	for _, hc := range infra.BuildWaitForApps(appSet) {
+		healthChecks[hc.Name()] = hc
+	}
+
+	var mu sync.Mutex
+	statuses := make([]AppStatus, 0, len(spec.Apps))
+	err = parallel.Run(ctx, func(ctx context.Context, spawn parallel.SpawnFn) error {
+		for appName, appInfo := range spec.Apps {
+			spawn("status."+appName, parallel.Continue, func(ctx context.Context) error {
+				status := appStatus(ctx, appName, appInfo, states[appName], appSet.FindAppByName(appName),
+					healthChecks[appName])
+
+				mu.Lock()
+				defer mu.Unlock()
+				statuses = append(statuses, status)
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	if jsonOutput {
+		return errors.WithStack(json.NewEncoder(os.Stdout).Encode(statuses))
+	}
+	printStatuses(statuses)
+	return nil
+}
+
+func appStatus(
+	ctx context.Context,
+	appName string,
+	appInfo *infra.AppInfo,
+	state infra.RuntimeState,
+	app infra.App,
+	healthCheck infra.HealthCheckCapable,
+) AppStatus {
+	info := appInfo.Info()
+	status := AppStatus{
+		Name:             appName,
+		Type:             appInfo.Type(),
+		Version:          appInfo.Version(),
+		Container:        info.Container,
+		State:            state.State,
+		ExitCode:         state.ExitCode,
+		Usage:            state.Usage,
+		Crashes:          appInfo.Crashes(),
+		Ports:            lo.MapValues(info.Ports, func(port int, _ string) int { return info.HostPort(port) }),
+		DependsOn:        info.DependsOn,
+		DeploymentStatus: info.Status,
+	}
+	if status.State == "" {
+		status.State = "missing"
+	}
+
+	if healthCheck == nil {
+		status.Error = "application is not a part of the environment profiles"
+		return status
+	}
+	if info.Status == infra.AppStatusFailed {
+		status.Error = "deployment failed: " + info.Error
+		return status
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	if err := healthCheck.HealthCheck(ctx); err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Healthy = true
+	}
+
+	if node, ok := app.(chainNode); ok && info.Status == infra.AppStatusRunning {
+		nodeStatus, err := node.ClientContext().RPCClient().Status(ctx)
+		if err != nil {
+			if status.Error == "" {
+				status.Error = errors.Wrap(err, "retrieving node status failed").Error()
+			}
+		} else {
+			status.Chain = &ChainStatus{
+				Height:     nodeStatus.SyncInfo.LatestBlockHeight,
+				CatchingUp: nodeStatus.SyncInfo.CatchingUp,
+			}
+		}
+	}
+
+	return status
+}
+
+func printStatuses(statuses []AppStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "APP\tTYPE\tVERSION\tSTATE\tHEALTHY\tCRASHES\tCPU\tMEMORY\tPIDS\tPORTS\tHEIGHT\tCATCHING UP\tERROR")
+	for _, s := range statuses {
+		height, catchingUp := "-", "-"
+		if s.Chain != nil {
+			height = strconv.FormatInt(s.Chain.Height, 10)
+			catchingUp = strconv.FormatBool(s.Chain.CatchingUp)
+		}
+		state := s.State
+		if s.ExitCode != 0 {
+			state += fmt.Sprintf(" (%d)", s.ExitCode)
+		}
+		version := s.Version
+		if version == "" {
+			version = "-"
+		}
+		cpu, memory, pids := formatUsage(s.Usage)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name, s.Type, version, state, s.Healthy, len(s.Crashes), cpu, memory, pids, formatPorts(s.Ports), height,
+			catchingUp, firstLine(s.Error))
+	}
+	_ = w.Flush()
+}
+
+// formatUsage formats usage of cpu, memory and processes against their limits.
+func formatUsage(usage *infra.ResourceUsage) (string, string, string) {
+	if usage == nil {
+		return "-", "-", "-"
+	}
+
+	cpu := fmt.Sprintf("%.1f%%", usage.CPUPercent)
+	if usage.CPULimit > 0 {
+		cpu += fmt.Sprintf("/%.0f%%", usage.CPULimit*100)
+	}
+	memory := formatBytes(usage.Memory)
+	if usage.MemoryLimit > 0 {
+		memory += "/" + formatBytes(usage.MemoryLimit)
+	}
+	pids := strconv.FormatInt(usage.Pids, 10)
+	if usage.PidsLimit > 0 {
+		pids += "/" + strconv.FormatInt(usage.PidsLimit, 10)
+	}
+	return cpu, memory, pids
+}
+
+func formatBytes(n int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value := float64(n)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return strconv.FormatInt(n, 10) + units[0]
+	}
+	return fmt.Sprintf("%.1f%s", value, units[unit])
+}
+
+func formatPorts(ports map[string]int) string {
+	if len(ports) == 0 {
+		return "-"
+	}
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res := make([]string, 0, len(names))
+	for _, name := range names {
+		res = append(res, name+":"+strconv.Itoa(ports[name]))
+	}
+	return strings.Join(res, ",")
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}